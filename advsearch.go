@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// advancedField is a box of the advanced search form and the search field
+// it fills in.
+type advancedField struct {
+	label string
+	field string
+	hint  string
+}
+
+var advancedFields = []advancedField{
+	{label: "Title", field: "title"},
+	{label: "Author", field: "author"},
+	{label: "Subject", field: "subject", hint: "e.g. ghost stories or sea"},
+	{label: "Language", field: "lang", hint: "a code such as en, fr or de"},
+	{label: "Century", field: "century", hint: "authors alive then, e.g. 19; Gutenberg only"},
+}
+
+// advancedSearch is the advanced search form: one box per search field,
+// assembled into the query the search box takes.
+type advancedSearch struct {
+	inputs []textinput.Model
+	focus  int
+}
+
+func (m *model) openAdvancedSearch() tea.Cmd {
+	if m.advanced.inputs == nil {
+		for _, f := range advancedFields {
+			input := textinput.New()
+			input.Prompt = ""
+			input.CharLimit = 120
+			input.Width = 40
+			input.Placeholder = tr(f.hint)
+			m.advanced.inputs = append(m.advanced.inputs, input)
+		}
+	}
+	m.advanced.focus = 0
+	m.focusAdvancedField()
+	m.status = ""
+	m.mode = modeAdvancedSearch
+	return textinput.Blink
+}
+
+func (m *model) focusAdvancedField() {
+	for i := range m.advanced.inputs {
+		if i == m.advanced.focus {
+			m.advanced.inputs[i].Focus()
+		} else {
+			m.advanced.inputs[i].Blur()
+		}
+	}
+}
+
+// advancedQuery assembles the form into a field query, quoting values of
+// more than one word.
+func (m model) advancedQuery() string {
+	var parts []string
+	for i, f := range advancedFields {
+		value := strings.Join(strings.Fields(strings.ReplaceAll(m.advanced.inputs[i].Value(), "\"", "")), " ")
+		if value == "" {
+			continue
+		}
+		if strings.Contains(value, " ") {
+			value = strconv.Quote(value)
+		}
+		parts = append(parts, f.field+":"+value)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (m model) updateAdvancedSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch m.keys.lookup(modeAdvancedSearch, key.String()) {
+		case actionNextField:
+			m.advanced.focus = (m.advanced.focus + 1) % len(m.advanced.inputs)
+			m.focusAdvancedField()
+			return m, nil
+		case actionPrevField:
+			m.advanced.focus = (m.advanced.focus + len(m.advanced.inputs) - 1) % len(m.advanced.inputs)
+			m.focusAdvancedField()
+			return m, nil
+		case actionSearch:
+			query := m.advancedQuery()
+			if query == "" {
+				m.status = tr("Fill in at least one field to search")
+				return m, nil
+			}
+			if _, err := parseCentury(parseSearchQuery(query).Century); err != nil {
+				m.status = err.Error()
+				return m, nil
+			}
+			m.authorInput.SetValue(query)
+			m.authorInput.CursorEnd()
+			m.mode = modeAuthorSearch
+			cmd := m.startLoading(tr("Searching books"), fetchBooksCmd(m.sources[m.sourceIndex], query))
+			return m, cmd
+		case actionBack:
+			m.mode = modeAuthorSearch
+			return m, nil
+		case actionQuit:
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.advanced.inputs[m.advanced.focus], cmd = m.advanced.inputs[m.advanced.focus].Update(msg)
+	return m, cmd
+}
+
+func (m model) advancedSearchView() string {
+	lines := []string{titleStyle().Render(tr("Advanced search")), helpLine(sourceLabel(m.sources, m.sourceIndex)), ""}
+	width := 0
+	for _, f := range advancedFields {
+		width = max(width, runewidth.StringWidth(tr(f.label)))
+	}
+	for i, f := range advancedFields {
+		marker := "  "
+		if i == m.advanced.focus {
+			marker = "> "
+		}
+		lines = append(lines, marker+runewidth.FillRight(tr(f.label), width)+"  "+m.advanced.inputs[i].View())
+	}
+	lines = append(lines, "")
+	if query := m.advancedQuery(); query != "" {
+		lines = append(lines, metaStyle().Render(trf("Query: %s", query)), "")
+	}
+	if status := m.statusText(); status != "" {
+		lines = append(lines, status, "")
+	}
+	lines = append(lines, helpLine(m.keys.hint(modeAdvancedSearch, actionNextField, actionPrevField, actionSearch, actionBack, actionQuit)))
+	return strings.Join(lines, "\n")
+}
+
+// parseCentury reads a century field, "19" or "19th", as the years it
+// spans, 1800 to 1899.
+func parseCentury(value string) ([2]int, error) {
+	if value == "" {
+		return [2]int{}, nil
+	}
+	digits := strings.TrimRight(strings.ToLower(value), "stndrh")
+	century, err := strconv.Atoi(digits)
+	if err != nil || century < 1 || century > 21 {
+		return [2]int{}, fmt.Errorf(tr("%q is not a century: use a number such as 19"), value)
+	}
+	return [2]int{(century - 1) * 100, century*100 - 1}, nil
+}
+
+// gutendexSearch searches Gutenberg's catalog on Gutendex, which can filter
+// by the years the authors lived, for queries with a century. Gutendex
+// pages hold 32 books and count from 1.
+func gutendexSearch(ctx context.Context, q searchQuery, page int) ([]bookResult, error) {
+	years, err := parseCentury(q.Century)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("author_year_start", strconv.Itoa(years[0]))
+	params.Set("author_year_end", strconv.Itoa(years[1]))
+	if search := strings.Join(append([]string{q.Author, q.Title}, q.Terms...), " "); strings.TrimSpace(search) != "" {
+		params.Set("search", strings.Join(strings.Fields(search), " "))
+	}
+	if q.Subject != "" {
+		params.Set("topic", q.Subject)
+	}
+	if q.Language != "" {
+		params.Set("languages", q.Language)
+	}
+	var data struct {
+		Count   int            `json:"count"`
+		Results []gutendexBook `json:"results"`
+	}
+	if page > 0 {
+		// Asking past the last page is an error; the first page, cached,
+		// tells how many there are.
+		if err := fetchGutendex(ctx, "https://gutendex.com/books/?"+params.Encode(), &data); err != nil {
+			return nil, err
+		}
+		if page*gutendexPageSize >= data.Count {
+			return nil, nil
+		}
+		params.Set("page", strconv.Itoa(page+1))
+	}
+	if err := fetchGutendex(ctx, "https://gutendex.com/books/?"+params.Encode(), &data); err != nil {
+		return nil, err
+	}
+	books := make([]bookResult, 0, len(data.Results))
+	for _, b := range data.Results {
+		books = append(books, b.result())
+	}
+	return books, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	_ "embed"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:generate go run authors_gen.go fold.go
+
+// authorsIndexData is the built-in author catalog, prebuilt from all.txt by
+// authors_gen.go. It lives in the binary's read-only data, so it is only
+// read, line by line, the first time the catalog is searched.
+//
+//go:embed authors.idx
+var authorsIndexData string
+
+// authorCatalog holds the author names, sorted by their lowercase form, with
+// the folded keys fuzzy search matches. Both are built on first use.
+type authorCatalog struct {
+	once  sync.Once
+	load  func() ([]string, []authorKey)
+	names []string
+	keys  []authorKey
+}
+
+// indexedAuthors reads a prebuilt catalog, whose keys are already folded:
+// the built-in one or one fetched by update-authors.
+func indexedAuthors(data string) *authorCatalog {
+	return &authorCatalog{load: func() ([]string, []authorKey) {
+		n := strings.Count(data, "\n")
+		names := make([]string, 0, n)
+		keys := make([]authorKey, 0, n)
+		for line := range strings.Lines(data) {
+			folded, name, ok := strings.Cut(strings.TrimSuffix(line, "\n"), "\t")
+			if !ok {
+				continue
+			}
+			names = append(names, name)
+			keys = append(keys, authorKey{folded: folded, tokens: tokenize(folded)})
+		}
+		return names, keys
+	}}
+}
+
+// newAuthorCatalog is a catalog of names read at run time, from
+// authors_file, folded and sorted when it is first used.
+func newAuthorCatalog(names []string) *authorCatalog {
+	return &authorCatalog{load: func() ([]string, []authorKey) {
+		names := append([]string(nil), names...)
+		sort.SliceStable(names, func(i, j int) bool {
+			return strings.ToLower(names[i]) < strings.ToLower(names[j])
+		})
+		return names, buildAuthorKeys(names)
+	}}
+}
+
+// writeAuthorIndex writes names as a prebuilt catalog, in the format
+// authors_gen.go gives authors.idx.
+func writeAuthorIndex(path string, names []string) (int, error) {
+	seen := make(map[string]bool, len(names))
+	unique := names[:0:0]
+	for _, name := range names {
+		if name != "" && !seen[name] && !strings.Contains(name, "\t") {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	catalog := newAuthorCatalog(unique)
+	var b strings.Builder
+	for i, name := range catalog.Names() {
+		b.WriteString(catalog.Keys()[i].folded + "\t" + name + "\n")
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o644); err != nil {
+		return 0, err
+	}
+	return len(unique), os.Rename(tmp, path)
+}
+
+func (c *authorCatalog) loaded() {
+	c.once.Do(func() {
+		c.names, c.keys = c.load()
+	})
+}
+
+func (c *authorCatalog) Names() []string {
+	if c == nil {
+		return nil
+	}
+	c.loaded()
+	return c.names
+}
+
+func (c *authorCatalog) Keys() []authorKey {
+	if c == nil {
+		return nil
+	}
+	c.loaded()
+	return c.keys
+}
+
+func (c *authorCatalog) Len() int {
+	return len(c.Names())
+}
+
+// prefixRange is the range of names starting with prefix, lowercase, found
+// by binary search.
+func (c *authorCatalog) prefixRange(prefix string) (int, int) {
+	names := c.Names()
+	lo := sort.Search(len(names), func(i int) bool {
+		return strings.ToLower(names[i]) >= prefix
+	})
+	hi := lo + sort.Search(len(names)-lo, func(i int) bool {
+		return !strings.HasPrefix(strings.ToLower(names[lo+i]), prefix)
+	})
+	return lo, hi
+}
+
+// letterRanges are the names under each letter of a to z that the catalog
+// language keeps as a letter of its own, as ranges of the sorted names.
+// Names outside them, starting with an accent, another letter or a sign,
+// are few and left to the letter grouper.
+func (c *authorCatalog) letterRanges(g *letterGrouper) map[string][2]int {
+	ranges := make(map[string][2]int)
+	for _, letter := range initialLetters[:26] {
+		if g.letter(letter) != letter {
+			continue
+		}
+		if lo, hi := c.prefixRange(letter); hi > lo {
+			ranges[letter] = [2]int{lo, hi}
+		}
+	}
+	return ranges
+}
+
+// outside calls fn for each name in none of ranges.
+func (c *authorCatalog) outside(ranges map[string][2]int, fn func(name string)) {
+	covered := make([]bool, c.Len())
+	for _, r := range ranges {
+		for i := r[0]; i < r[1]; i++ {
+			covered[i] = true
+		}
+	}
+	for i, name := range c.Names() {
+		if !covered[i] {
+			fn(name)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAuthorsIndexIsFolded(t *testing.T) {
+	for line := range strings.Lines(authorsIndexData) {
+		folded, name, ok := strings.Cut(strings.TrimSuffix(line, "\n"), "\t")
+		if !ok {
+			t.Fatalf("malformed authors.idx line %q", line)
+		}
+		if want := foldString(name); folded != want {
+			t.Errorf("authors.idx folds %q as %q, foldString gives %q", name, folded, want)
+		}
+	}
+}
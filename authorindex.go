@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const indexColumns = 9
+
+type letterCount struct {
+	letter string
+	count  int
+}
+
+// buildLetterIndex counts the authors under each letter, with the letters
+// in the order of the catalog language and # last. Names under a to z are
+// counted from the sorted catalog without looking at them.
+func buildLetterIndex(authors *authorCatalog) []letterCount {
+	counts := make(map[string]int)
+	grouper := newLetterGrouper()
+	ranges := authors.letterRanges(grouper)
+	for letter, r := range ranges {
+		counts[letter] += r[1] - r[0]
+	}
+	authors.outside(ranges, func(name string) {
+		counts[grouper.letter(name)]++
+	})
+	names := make([]string, 0, len(counts))
+	for letter := range counts {
+		if letter != "#" {
+			names = append(names, letter)
+		}
+	}
+	newCollator().SortStrings(names)
+	if counts["#"] > 0 {
+		names = append(names, "#")
+	}
+	letters := make([]letterCount, 0, len(names))
+	for _, letter := range names {
+		letters = append(letters, letterCount{letter: letter, count: counts[letter]})
+	}
+	return letters
+}
+
+func authorsForLetter(authors *authorCatalog, letter string) []list.Item {
+	grouper := newLetterGrouper()
+	ranges := authors.letterRanges(grouper)
+	var names []string
+	if r, ok := ranges[letter]; ok {
+		names = append(names, authors.Names()[r[0]:r[1]]...)
+	}
+	authors.outside(ranges, func(name string) {
+		if grouper.letter(name) == letter {
+			names = append(names, name)
+		}
+	})
+	newCollator().SortStrings(names)
+	items := make([]list.Item, 0, len(names))
+	for _, name := range names {
+		items = append(items, authorItem{name: name})
+	}
+	return items
+}
+
+func (m model) updateAuthorIndex(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.indexLetter != "" {
+		return m.updateAuthorIndexList(msg)
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch m.keys.lookup(modeAuthorIndex, msg.String()) {
+		case actionLeft:
+			if m.indexCursor > 0 {
+				m.indexCursor--
+			}
+		case actionRight:
+			if m.indexCursor < len(m.indexLetters)-1 {
+				m.indexCursor++
+			}
+		case actionUp:
+			if m.indexCursor >= indexColumns {
+				m.indexCursor -= indexColumns
+			}
+		case actionDown:
+			if m.indexCursor+indexColumns < len(m.indexLetters) {
+				m.indexCursor += indexColumns
+			}
+		case actionOpen:
+			if m.indexCursor < len(m.indexLetters) {
+				letter := m.indexLetters[m.indexCursor].letter
+				m.indexLetter = letter
+				m.indexList.Title = trf("Authors: %s", strings.ToUpper(letter))
+				m.indexList.ResetFilter()
+				m.indexList.Select(0)
+				return m, m.indexList.SetItems(authorsForLetter(m.authors, letter))
+			}
+		case actionBack:
+			m.mode = modeLibrary
+		case actionQuit:
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m model) updateAuthorIndexList(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && m.indexList.FilterState() != list.Filtering {
+		switch m.keys.lookup(modeAuthorIndex, key.String()) {
+		case actionOpen:
+			if item, ok := m.indexList.SelectedItem().(authorItem); ok {
+				cmd := m.startLoading(tr("Searching books"), fetchBooksCmd(m.sources[m.sourceIndex], item.name))
+				return m, cmd
+			}
+		case actionBack:
+			m.indexLetter = ""
+			return m, nil
+		case actionQuit:
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.indexList, cmd = m.indexList.Update(msg)
+	return m, cmd
+}
+
+func (m model) authorIndexView() string {
+	if m.indexLetter != "" {
+		return m.indexList.View() + "\n" + m.footerLine(tr("/: filter  ")+m.keys.hint(modeAuthorIndex, actionOpen, actionBack, actionHelp, actionQuit))
+	}
+
+	cursorStyle := lipgloss.NewStyle().Bold(true).Reverse(true)
+	lines := []string{titleStyle().Render(tr("Author index")), ""}
+	var row []string
+	for i, lc := range m.indexLetters {
+		cell := fmt.Sprintf(" %s %5d ", strings.ToUpper(lc.letter), lc.count)
+		if i == m.indexCursor {
+			cell = cursorStyle.Render(cell)
+		}
+		row = append(row, cell)
+		if len(row) == indexColumns {
+			lines = append(lines, strings.Join(row, " "))
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		lines = append(lines, strings.Join(row, " "))
+	}
+	lines = append(lines, "", metaStyle().Render(sourceLabel(m.sources, m.sourceIndex)))
+	lines = append(lines, "", m.footerLine(m.keys.hint(modeAuthorIndex, actionOpen, actionBack, actionHelp, actionQuit)))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,61 @@
+//go:build ignore
+
+// authors_gen builds authors.idx, the author catalog gutberg embeds, from
+// all.txt: one "folded<TAB>name" line per author, sorted by the lowercase
+// name. Run it with go generate after editing all.txt; it is built with
+// fold.go, so names are folded as gutberg folds its searches.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	in, err := os.Open("all.txt")
+	if err != nil {
+		fail(err)
+	}
+	defer in.Close()
+
+	var names []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.Contains(name, "\t") || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if err := scanner.Err(); err != nil {
+		fail(err)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	out, err := os.Create("authors.idx")
+	if err != nil {
+		fail(err)
+	}
+	w := bufio.NewWriter(out)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", foldString(name), name)
+	}
+	if err := w.Flush(); err != nil {
+		fail(err)
+	}
+	if err := out.Close(); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "authors_gen:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	catalogCSVURL      = "https://www.gutenberg.org/cache/epub/feeds/pg_catalog.csv"
+	authorsFileName    = "authors.idx"
+	authorsRefreshAge  = 30 * 24 * time.Hour
+	catalogAuthorsName = "Authors"
+)
+
+// authorRoleRe is the role the catalog adds after a contributor's name, as
+// in "Constance Garnett [Translator]"; notes such as "[pseud.]" stay.
+var authorRoleRe = regexp.MustCompile(`\s*\[[A-Z][^\]]*\]$`)
+
+// authorDatesRe is the life dates the catalog adds after a name, as in
+// "Dickens, Charles, 1812-1870" or "Plato, 428? BCE-348? BCE"; the built-in
+// list has none, and names are matched against it.
+var authorDatesRe = regexp.MustCompile(`,\s*\d{1,4}\??(\s*BCE)?-\d*\??(\s*BCE)?$`)
+
+// unnamedAuthors are catalog entries that name no one.
+var unnamedAuthors = map[string]bool{"Anonymous": true, "Various": true, "Unknown": true}
+
+type authorsUpdatedMsg struct {
+	count int
+	err   error
+}
+
+// updatedAuthorsPath is where update-authors keeps the author list, next to
+// the state file; it is preferred over the list built into the binary.
+func updatedAuthorsPath(cfg Config) string {
+	return filepath.Join(filepath.Dir(cfg.StateFile), authorsFileName)
+}
+
+// catalogAuthors reads the names in the Authors column of Gutenberg's
+// catalog, where each book lists its contributors separated by "; ".
+func catalogAuthors(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	column := -1
+	for i, name := range header {
+		if strings.TrimSpace(name) == catalogAuthorsName {
+			column = i
+		}
+	}
+	if column < 0 {
+		return nil, errors.New(tr("the catalog has no Authors column"))
+	}
+	var names []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if column >= len(record) {
+			continue
+		}
+		for _, name := range strings.Split(record[column], ";") {
+			name = authorRoleRe.ReplaceAllString(strings.TrimSpace(name), "")
+			name = strings.TrimSpace(authorDatesRe.ReplaceAllString(name, ""))
+			if name != "" && !unnamedAuthors[name] {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// updateAuthors fetches the author list from the Gutenberg catalog into the
+// data dir.
+func updateAuthors(ctx context.Context, cfg Config) (int, error) {
+	resp, err := getURL(ctx, featureAuthors, catalogCSVURL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	names, err := catalogAuthors(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf(tr("read the catalog: %w"), err)
+	}
+	if len(names) == 0 {
+		return 0, errors.New(tr("the catalog lists no authors"))
+	}
+	return writeAuthorIndex(updatedAuthorsPath(cfg), names)
+}
+
+func runUpdateAuthors(cfg Config, args []string) error {
+	fs := flag.NewFlagSet("update-authors", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage: gutberg update-authors"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if cfg.AuthorsFile != "" {
+		fmt.Fprintln(os.Stderr, trf("authors_file is set, so %s is used instead of the updated list", cfg.AuthorsFile))
+	}
+	count, err := updateAuthors(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Println(trf("Updated the author list: %d authors in %s", count, updatedAuthorsPath(cfg)))
+	return nil
+}
+
+// authorsRefreshCmd fetches the author list again once it is a month old.
+// Only a list update-authors fetched is refreshed, so nothing is downloaded
+// until it has been run once.
+func authorsRefreshCmd(cfg Config) tea.Cmd {
+	if cfg.AuthorsFile != "" || offline() {
+		return nil
+	}
+	info, err := os.Stat(updatedAuthorsPath(cfg))
+	if err != nil || time.Since(info.ModTime()) < authorsRefreshAge {
+		return nil
+	}
+	return func() tea.Msg {
+		count, err := updateAuthors(context.Background(), cfg)
+		return authorsUpdatedMsg{count: count, err: err}
+	}
+}
+
+func (m model) updateAuthorsUpdated(msg authorsUpdatedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		debugLog.Warn("author list not refreshed", "err", msg.err)
+		return m, nil
+	}
+	authors, err := loadAuthors(m.config)
+	if err != nil {
+		return m, nil
+	}
+	m.authors = authors
+	m.indexLetters = nil
+	debugLog.Info("author list refreshed", "authors", msg.count)
+	return m, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const minAutoTurn = 3 * time.Second
+
+// sleepTimers are the sleep timer lengths the reader cycles through, in
+// minutes.
+var sleepTimers = []int{15, 30, 45, 60, 90}
+
+// autoTurnMsg and sleepMsg carry the id of the timer that sent them, so a
+// timer switched off or restarted is ignored when its tick arrives.
+type autoTurnMsg struct{ id int }
+
+type sleepMsg struct {
+	id   int
+	warn bool
+}
+
+// sleepWarning is how long before the sleep timer expires the reader is
+// told, with a cue.
+const sleepWarning = time.Minute
+
+// autoTurnDelay is how long the current page stays up: the configured
+// interval, or the page's words at the reading speed when it is zero.
+func (m model) autoTurnDelay() time.Duration {
+	if m.config.AutoTurn > 0 {
+		return time.Duration(m.config.AutoTurn) * time.Second
+	}
+	words := len(strings.Fields(stripStyles(m.currentBook.Page(m.state.Page))))
+	return max(time.Duration(words)*time.Minute/time.Duration(max(m.config.WPM, minWPM)), minAutoTurn)
+}
+
+func (m model) autoTurnCmd() tea.Cmd {
+	id := m.autoTurnID
+	return tea.Tick(m.autoTurnDelay(), func(time.Time) tea.Msg { return autoTurnMsg{id: id} })
+}
+
+func (m *model) toggleAutoTurn() tea.Cmd {
+	m.autoTurnID++
+	m.autoTurning = !m.autoTurning
+	if !m.autoTurning {
+		m.status = tr("Automatic page turns off")
+		return nil
+	}
+	m.status = trf("Turning pages automatically (%s for this page)", m.autoTurnDelay().Round(time.Second))
+	return m.autoTurnCmd()
+}
+
+// updateAutoTurn turns the page and waits for the next one, stopping at
+// the end of the book. It only turns pages while the reader is on screen.
+func (m model) updateAutoTurn(msg autoTurnMsg) (tea.Model, tea.Cmd) {
+	if !m.autoTurning || msg.id != m.autoTurnID {
+		return m, nil
+	}
+	if m.mode != modeReader {
+		return m, m.autoTurnCmd()
+	}
+	if m.state.Page >= m.currentBook.PageCount()-1 {
+		m.autoTurning = false
+		m.status = tr("End of the book, automatic page turns off")
+		return m, nil
+	}
+	turn := m.turnPage(m.state.Page + 1)
+	return m, tea.Batch(turn, m.autoTurnCmd())
+}
+
+// cycleSleepTimer sets the next sleep timer length, or switches the timer
+// off after the longest one.
+func (m *model) cycleSleepTimer() tea.Cmd {
+	m.sleepID++
+	next := sleepTimers[0]
+	if !m.sleepAt.IsZero() {
+		next = 0
+		left := time.Until(m.sleepAt)
+		for _, minutes := range sleepTimers {
+			if time.Duration(minutes)*time.Minute > left+time.Minute {
+				next = minutes
+				break
+			}
+		}
+	}
+	if next == 0 {
+		m.sleepAt = time.Time{}
+		m.status = tr("Sleep timer off")
+		return nil
+	}
+	d := time.Duration(next) * time.Minute
+	m.sleepAt = time.Now().Add(d)
+	m.status = trf("gutberg will save and quit in %d minutes", next)
+	id := m.sleepID
+	return tea.Batch(
+		tea.Tick(d-sleepWarning, func(time.Time) tea.Msg { return sleepMsg{id: id, warn: true} }),
+		tea.Tick(d, func(time.Time) tea.Msg { return sleepMsg{id: id} }),
+	)
+}
+
+func (m model) updateSleep(msg sleepMsg) (tea.Model, tea.Cmd) {
+	if m.sleepAt.IsZero() || msg.id != m.sleepID {
+		return m, nil
+	}
+	if msg.warn {
+		m.status = tr("Sleep timer: saving and quitting in a minute (z to extend)")
+		cue := m.notify()
+		return m, cue
+	}
+	if err := saveStateNow(m.store, m.state); err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+	return m, tea.Quit
+}
+
+// sleepLeft is the sleep timer's countdown for the status bar.
+func (m model) sleepLeft() string {
+	if m.sleepAt.IsZero() {
+		return ""
+	}
+	return trf("sleep in %d min", max(int(time.Until(m.sleepAt).Round(time.Minute).Minutes()), 1))
+}
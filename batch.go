@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Stages of a book in gutberg download, as reported by -events.
+const (
+	stageStart    = "start"
+	stageDownload = "download"
+	stageConvert  = "convert"
+	stageDone     = "done"
+	stageSkipped  = "skipped"
+	stageError    = "error"
+)
+
+// progressEvent is one line of -events output. Like the -json output of the
+// query commands, its fields may grow but are not renamed.
+type progressEvent struct {
+	Time    time.Time `json:"time"`
+	Book    string    `json:"book"`
+	Index   int       `json:"index"`
+	Total   int       `json:"total"`
+	Stage   string    `json:"stage"`
+	Percent int       `json:"percent"`
+	Path    string    `json:"path,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// batchReporter prints the progress of a batch: JSON lines on stderr for
+// wrappers, or a line per finished book on stdout for people.
+type batchReporter struct {
+	events  bool
+	enc     *json.Encoder
+	percent int
+}
+
+func (r *batchReporter) report(e progressEvent) {
+	e.Time = time.Now().UTC()
+	if r.events {
+		if err := r.enc.Encode(e); err != nil {
+			debugLog.Warn("progress event not written", "err", err)
+		}
+		return
+	}
+	switch e.Stage {
+	case stageDone:
+		fmt.Println(trf("[%d/%d] %s: %s", e.Index, e.Total, e.Book, e.Path))
+	case stageSkipped:
+		fmt.Println(trf("[%d/%d] %s: already in the library (%s)", e.Index, e.Total, e.Book, e.Path))
+	case stageError:
+		fmt.Println(trf("[%d/%d] %s: %s", e.Index, e.Total, e.Book, e.Error))
+	}
+}
+
+// progress reports download progress, once per percent.
+func (r *batchReporter) progress(e progressEvent) progressFunc {
+	r.percent = -1
+	return func(done, total int64) {
+		if total <= 0 {
+			return
+		}
+		if percent := int(done * 100 / total); percent != r.percent {
+			r.percent = percent
+			e.Stage, e.Percent = stageDownload, percent
+			r.report(e)
+		}
+	}
+}
+
+// runDownload is gutberg download: several books fetched into the library
+// and converted to cleaned text ahead of reading, for a nightly sync. Books
+// already in the library are skipped, so an interrupted batch is resumed by
+// running it again.
+func runDownload(cfg Config, args []string) error {
+	fs, _ := queryFlags("download", tr("Usage: gutberg download [-events] <book>... (- reads the books from stdin)"))
+	events := fs.Bool("events", false, tr("write JSON progress events to stderr, one per line"))
+	fs.Parse(args)
+	books, err := batchBooks(fs.Args(), os.Stdin)
+	if err != nil {
+		return err
+	}
+	if len(books) == 0 {
+		fs.Usage()
+		return errors.New(tr("download needs at least one book"))
+	}
+	r := &batchReporter{events: *events, enc: json.NewEncoder(os.Stderr)}
+	failed := 0
+	for i, book := range books {
+		e := progressEvent{Book: book, Index: i + 1, Total: len(books)}
+		path, ok := libraryCopy(cfg.BooksDir, book)
+		var err error
+		if ok {
+			// A batch interrupted while converting left the file without its
+			// text; loading it again is cheap when the text is cached.
+			_, err = loadAddedBook(path, pageLineWidth, pageLineCount)
+			if err == nil {
+				e.Stage, e.Percent, e.Path = stageSkipped, 100, path
+				r.report(e)
+				continue
+			}
+		} else {
+			e.Stage = stageStart
+			r.report(e)
+			path, err = resolveBookArg(context.Background(), cfg.BooksDir, book, r.progress(e))
+			if err == nil {
+				e.Stage, e.Percent, e.Path = stageConvert, 0, path
+				r.report(e)
+				_, err = loadAddedBook(path, pageLineWidth, pageLineCount)
+			}
+		}
+		if err != nil {
+			failed++
+			e.Stage, e.Error = stageError, err.Error()
+			r.report(e)
+			continue
+		}
+		e.Stage, e.Percent = stageDone, 100
+		r.report(e)
+	}
+	if failed > 0 {
+		return fmt.Errorf(tr("%d of %d books failed"), failed, len(books))
+	}
+	return nil
+}
+
+// batchBooks takes the books from the arguments, reading one per line from
+// stdin for "-".
+func batchBooks(args []string, stdin io.Reader) ([]string, error) {
+	var books []string
+	for _, arg := range args {
+		if arg != "-" {
+			books = append(books, arg)
+			continue
+		}
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				books = append(books, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return books, nil
+}
+
+// libraryCopy finds a book already in the library: a file there, or a
+// Gutenberg number downloaded before.
+func libraryCopy(booksDir, book string) (string, bool) {
+	for _, candidate := range []string{book, filepath.Join(booksDir, book), filepath.Join(booksDir, book+".html")} {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	id := ebookIDFromURL(normalizeEbookURL(book))
+	if id == "" {
+		return "", false
+	}
+	lib, err := loadLibrary(booksDir)
+	if err != nil {
+		return "", false
+	}
+	return lib.pathForID(booksDir, id)
+}
@@ -0,0 +1,24 @@
+//go:build darwin
+
+package main
+
+import (
+	"os/exec"
+	"regexp"
+	"strconv"
+)
+
+var pmsetPercentRe = regexp.MustCompile(`(\d+)%`)
+
+func batteryPercent() (int, bool) {
+	out, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return 0, false
+	}
+	m := pmsetPercentRe.FindSubmatch(out)
+	if m == nil {
+		return 0, false
+	}
+	percent, err := strconv.Atoi(string(m[1]))
+	return percent, err == nil
+}
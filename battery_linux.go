@@ -0,0 +1,24 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func batteryPercent() (int, bool) {
+	paths, _ := filepath.Glob("/sys/class/power_supply/BAT*/capacity")
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if percent, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			return percent, true
+		}
+	}
+	return 0, false
+}
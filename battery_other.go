@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package main
+
+func batteryPercent() (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	xhtml "golang.org/x/net/html"
+)
+
+const bookshelvesURL = "https://www.gutenberg.org/ebooks/bookshelf/"
+
+var bookshelfLinkRe = regexp.MustCompile(`^/ebooks/bookshelf/\d+/?$`)
+
+// bookshelf is one of Gutenberg's curated lists of books, in the category
+// the bookshelves page files it under.
+type bookshelf struct {
+	Name     string
+	Category string
+	URL      string
+}
+
+type bookshelfItem struct {
+	shelf bookshelf
+}
+
+func (b bookshelfItem) Title() string       { return b.shelf.Name }
+func (b bookshelfItem) Description() string { return b.shelf.Category }
+func (b bookshelfItem) FilterValue() string { return b.shelf.Name + " " + b.shelf.Category }
+
+type bookshelvesMsg struct {
+	items []list.Item
+	err   error
+}
+
+// bookshelfSource pages through the books of a bookshelf: its query is the
+// bookshelf's URL.
+type bookshelfSource struct {
+	gutenbergSource
+}
+
+func (s bookshelfSource) Search(ctx context.Context, shelfURL string) ([]bookResult, error) {
+	return s.SearchPage(ctx, shelfURL, 0)
+}
+
+func (bookshelfSource) SearchPage(ctx context.Context, shelfURL string, page int) ([]bookResult, error) {
+	if page > 0 {
+		shelfURL += fmt.Sprintf("?start_index=%d", page*gutenbergPageSize+1)
+	}
+	return fetchBookLinks(ctx, shelfURL)
+}
+
+// fetchBookshelves reads the bookshelves page: every link to a bookshelf,
+// under the last heading before it.
+func fetchBookshelves(ctx context.Context) ([]bookshelf, error) {
+	data, err := getCachedURL(ctx, featureSearch, bookshelvesURL)
+	if err != nil {
+		return nil, err
+	}
+	root, err := xhtml.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var shelves []bookshelf
+	seen := make(map[string]bool)
+	category := ""
+	var walk func(*xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if n.Type == xhtml.ElementNode {
+			switch n.Data {
+			case "h2", "h3":
+				category = strings.Join(strings.Fields(textContent(n)), " ")
+				return
+			case "a":
+				href, _ := attr(n, "href")
+				name := strings.Join(strings.Fields(textContent(n)), " ")
+				if bookshelfLinkRe.MatchString(href) && name != "" && !seen[href] {
+					seen[href] = true
+					shelves = append(shelves, bookshelf{Name: name, Category: category, URL: "https://www.gutenberg.org" + strings.TrimSuffix(href, "/")})
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	if len(shelves) == 0 {
+		return nil, fmt.Errorf(tr("no bookshelves found on %s"), bookshelvesURL)
+	}
+	return shelves, nil
+}
+
+func fetchBookshelvesCmd() loadCmd {
+	return func(ctx context.Context) tea.Msg {
+		shelves, err := fetchBookshelves(ctx)
+		if err != nil {
+			return bookshelvesMsg{err: err}
+		}
+		items := make([]list.Item, len(shelves))
+		for i, shelf := range shelves {
+			items[i] = bookshelfItem{shelf: shelf}
+		}
+		return bookshelvesMsg{items: items}
+	}
+}
+
+// openBookshelfCmd lists the books of a bookshelf, a page at a time as
+// search results are.
+func openBookshelfCmd(shelf bookshelf) loadCmd {
+	fetch := fetchBooksCmd(bookshelfSource{}, shelf.URL)
+	return func(ctx context.Context) tea.Msg {
+		msg := fetch(ctx).(booksMsg)
+		msg.title = tr("Bookshelf · ") + shelf.Name
+		return msg
+	}
+}
+
+// openBookshelves shows the bookshelves, fetching them the first time.
+func (m *model) openBookshelves() tea.Cmd {
+	if len(m.shelfList.Items()) > 0 {
+		m.mode = modeBookshelves
+		return nil
+	}
+	return m.startLoading(tr("Loading bookshelves"), fetchBookshelvesCmd())
+}
+
+func (m model) updateBookshelves(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && m.shelfList.FilterState() != list.Filtering {
+		switch m.keys.lookup(modeBookshelves, key.String()) {
+		case actionOpen:
+			if item, ok := m.shelfList.SelectedItem().(bookshelfItem); ok {
+				cmd := m.startLoading(trf("Loading %s", item.shelf.Name), openBookshelfCmd(item.shelf))
+				return m, cmd
+			}
+		case actionBack:
+			m.mode = modeLibrary
+			return m, nil
+		case actionQuit:
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.shelfList, cmd = m.shelfList.Update(msg)
+	return m, cmd
+}
+
+func (m model) bookshelvesView() string {
+	return m.shelfList.View() + "\n" + m.footerLine(m.keys.hint(modeBookshelves, actionOpen, actionBack, actionHelp, actionQuit))
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// bookTextDir holds the cleaned text of opened books, so chapters can be
+// read back from disk instead of staying in memory. When empty, chapter
+// text is kept in memory.
+var bookTextDir string
+
+// bookText is a book's chapter text on disk plus the paginated chapters
+// around the one being read. It is shared by every copy of the Book. The
+// file is opened for each chapter read, so a book dropped by the reader
+// holds nothing open.
+type bookText struct {
+	path      string
+	mu        sync.Mutex
+	width     int
+	lines     int
+	pages     map[int][]string
+	locations map[int]int
+}
+
+// storeChapterText writes the chapters to one file in bookTextDir, named
+// after the book and its content, and records where each chapter lives.
+// The text is dropped from the chapters once it is on disk.
+func storeChapterText(path string, chapters []Chapter) (string, error) {
+	var all strings.Builder
+	for i := range chapters {
+		chapters[i].offset = int64(all.Len())
+		chapters[i].size = int64(len(chapters[i].Text))
+		all.WriteString(chapters[i].Text)
+	}
+	if bookTextDir == "" {
+		return "", nil
+	}
+	if err := os.MkdirAll(bookTextDir, 0o755); err != nil {
+		return "", err
+	}
+
+	pathSum := sha256.Sum256([]byte(path))
+	textSum := sha256.Sum256([]byte(all.String()))
+	prefix := hex.EncodeToString(pathSum[:8])
+	name := filepath.Join(bookTextDir, prefix+"-"+hex.EncodeToString(textSum[:8])+".txt")
+	if _, err := os.Stat(name); err != nil {
+		tmp := name + ".tmp"
+		if err := os.WriteFile(tmp, []byte(all.String()), 0o644); err != nil {
+			return "", err
+		}
+		if err := os.Rename(tmp, name); err != nil {
+			return "", err
+		}
+	}
+
+	stale, _ := filepath.Glob(filepath.Join(bookTextDir, prefix+"-*.txt"))
+	for _, old := range stale {
+		if old != name {
+			os.Remove(old)
+		}
+	}
+	for i := range chapters {
+		chapters[i].Text = ""
+	}
+	return name, nil
+}
+
+// removeChapterText deletes the stored text of a book removed from the
+// library.
+func removeChapterText(path string) {
+	if bookTextDir == "" {
+		return
+	}
+	pathSum := sha256.Sum256([]byte(path))
+	stale, _ := filepath.Glob(filepath.Join(bookTextDir, hex.EncodeToString(pathSum[:8])+"-*.txt"))
+	for _, old := range stale {
+		os.Remove(old)
+	}
+	os.Remove(parsedBookPath(path))
+}
+
+// parsedBookVersion is bumped whenever chapter extraction or cleaning
+// changes, so books parsed by an older gutberg are parsed again.
+const parsedBookVersion = 1
+
+// maxParsedLayouts bounds the page sizes remembered per book; a terminal
+// resized by hand goes through many.
+const maxParsedLayouts = 8
+
+// parsedBook is what loadBookFromHTML learnt from a book file, kept next to
+// its chapter text so the book opens again without cleaning it, and, for
+// the page sizes it was opened at, without paginating it.
+type parsedBook struct {
+	Version  int              `json:"version"`
+	Key      string           `json:"key"`
+	Title    string           `json:"title"`
+	Language string           `json:"language,omitempty"`
+	Words    int              `json:"words"`
+	Text     string           `json:"text"`
+	Chapters []parsedChapter  `json:"chapters"`
+	Layouts  map[string][]int `json:"layouts,omitempty"`
+}
+
+type parsedChapter struct {
+	Title    string `json:"title"`
+	Language string `json:"language,omitempty"`
+	Words    int    `json:"words"`
+	Offset   int64  `json:"offset"`
+	Size     int64  `json:"size"`
+}
+
+func parsedBookPath(path string) string {
+	pathSum := sha256.Sum256([]byte(path))
+	return filepath.Join(bookTextDir, hex.EncodeToString(pathSum[:8])+".json")
+}
+
+// parsedBookKey identifies a parse by the file's content and the filters
+// the book is read with.
+func parsedBookKey(data []byte, preset string) string {
+	sum := sha256.New()
+	sum.Write(data)
+	sum.Write([]byte(filtersKey(preset)))
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// loadParsedBook rebuilds a book from its cached parse, if the parse is of
+// the same content and filters and its text is still there.
+func loadParsedBook(path, key string) (Book, parsedBook, bool) {
+	var parsed parsedBook
+	if bookTextDir == "" {
+		return Book{}, parsed, false
+	}
+	data, err := os.ReadFile(parsedBookPath(path))
+	if err != nil {
+		return Book{}, parsed, false
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil || parsed.Version != parsedBookVersion || parsed.Key != key {
+		return Book{}, parsed, false
+	}
+	name := filepath.Join(bookTextDir, parsed.Text)
+	if _, err := os.Stat(name); err != nil {
+		return Book{}, parsed, false
+	}
+	book := Book{Title: parsed.Title, Words: parsed.Words, Language: parsed.Language, text: &bookText{path: name}}
+	for _, ch := range parsed.Chapters {
+		book.Chapters = append(book.Chapters, Chapter{Title: ch.Title, Language: ch.Language, Words: ch.Words, offset: ch.Offset, size: ch.Size})
+	}
+	return book, parsed, true
+}
+
+// storeParsedBook caches the parse of a laid out book, adding its page
+// size to the layouts already known.
+func storeParsedBook(path, key string, book Book, layouts map[string][]int) error {
+	if bookTextDir == "" || book.text == nil || book.text.path == "" {
+		return nil
+	}
+	parsed := parsedBook{
+		Version:  parsedBookVersion,
+		Key:      key,
+		Title:    book.Title,
+		Language: book.Language,
+		Words:    book.Words,
+		Text:     filepath.Base(book.text.path),
+		Layouts:  layouts,
+	}
+	if len(parsed.Layouts) >= maxParsedLayouts {
+		parsed.Layouts = nil
+	}
+	if parsed.Layouts == nil {
+		parsed.Layouts = make(map[string][]int)
+	}
+	counts := make([]int, 0, len(book.Chapters))
+	for _, ch := range book.Chapters {
+		parsed.Chapters = append(parsed.Chapters, parsedChapter{Title: ch.Title, Language: ch.Language, Words: ch.Words, Offset: ch.offset, Size: ch.size})
+		counts = append(counts, ch.Pages)
+	}
+	parsed.Layouts[layoutKey(book.text.width, book.text.lines)] = counts
+	data, err := json.Marshal(parsed)
+	if err != nil {
+		return err
+	}
+	name := parsedBookPath(path)
+	if err := os.WriteFile(name+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// ChapterText returns the full text of a chapter, reading it from disk when
+// the book was stored there.
+func (b Book) ChapterText(index int) string {
+	ch := b.Chapters[index]
+	if b.text == nil || b.text.path == "" || ch.size == 0 {
+		return ch.Text
+	}
+	file, err := os.Open(b.text.path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	buf := make([]byte, ch.size)
+	if _, err := file.ReadAt(buf, ch.offset); err != nil {
+		return ""
+	}
+	return string(buf)
+}
+
+func (b Book) PageCount() int {
+	if len(b.Chapters) == 0 {
+		return 0
+	}
+	last := b.Chapters[len(b.Chapters)-1]
+	return last.StartPage + last.Pages
+}
+
+// Page returns a page of the book, paginating its chapter on demand. Only
+// the chapter being read and its neighbours are kept paginated.
+func (b Book) Page(page int) string {
+	index := chapterForPage(b, page)
+	if index < 0 || b.text == nil {
+		return ""
+	}
+	pages := b.chapterPages(index)
+	if i := page - b.Chapters[index].StartPage; i >= 0 && i < len(pages) {
+		return pages[i]
+	}
+	return ""
+}
+
+// chapterPages paginates a chapter outside the lock, so a chapter being
+// prefetched in the background does not hold up the page on screen.
+func (b Book) chapterPages(index int) []string {
+	t := b.text
+	t.mu.Lock()
+	pages, ok := t.pages[index]
+	width, lines := t.width, t.lines
+	t.mu.Unlock()
+	if ok {
+		return pages
+	}
+
+	pages = paginateChapter(b.Chapters[index], b.ChapterText(index), width, lines)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.width != width || t.lines != lines {
+		return pages
+	}
+	for cached := range t.pages {
+		if cached < index-1 || cached > index+1 {
+			delete(t.pages, cached)
+		}
+	}
+	t.pages[index] = pages
+	return pages
+}
+
+// layoutPages sets the page size, counts the pages of every chapter and
+// numbers them across the book. Chapter pages are paginated again, lazily,
+// when they are read.
+func (b *Book) layoutPages(width, lines int) {
+	width, lines = max(width, 20), max(lines, 5)
+	counts := make([]int, len(b.Chapters))
+	forEachChapter(len(b.Chapters), func(i int) {
+		counts[i] = len(paginateChapter(b.Chapters[i], b.ChapterText(i), width, lines))
+	})
+	b.setLayout(width, lines, counts)
+}
+
+// forEachChapter runs fn for every chapter index on as many goroutines as
+// there are CPUs. Each call only writes the results of its own index, so
+// they come out in chapter order whatever finishes first.
+func forEachChapter(n int, fn func(i int)) {
+	workers := min(runtime.GOMAXPROCS(0), n)
+	if workers <= 1 {
+		for i := range n {
+			fn(i)
+		}
+		return
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// setLayout sets the page size with the page count of every chapter,
+// already known for that size.
+func (b *Book) setLayout(width, lines int, counts []int) {
+	width, lines = max(width, 20), max(lines, 5)
+	if b.text == nil {
+		b.text = &bookText{}
+	}
+	b.text.mu.Lock()
+	b.text.width, b.text.lines = width, lines
+	b.text.pages = make(map[int][]string)
+	b.text.locations = make(map[int]int)
+	b.text.mu.Unlock()
+
+	// Copies of the book handed to background prefetches keep reading the
+	// old chapter list, so it is replaced rather than updated in place.
+	chapters := slices.Clone(b.Chapters)
+	start := 0
+	for i := range chapters {
+		chapters[i].StartPage = start
+		chapters[i].Pages = counts[i]
+		start += chapters[i].Pages
+	}
+	b.Chapters = chapters
+}
+
+// layoutKey names a page size in the layouts of a parsed book.
+func layoutKey(width, lines int) string {
+	return fmt.Sprintf("%dx%d", max(width, 20), max(lines, 5))
+}
+
+func paginateChapter(ch Chapter, text string, width, lines int) []string {
+	header := fmt.Sprintf("%s%s%s\n\n", styleBoldOn, ch.Title, styleBoldOff)
+	pages := paginate(strings.TrimSpace(header+text), lines, width)
+	rtl := isRTL(ch.Language)
+	for i, page := range pages {
+		pages[i] = visualPage(page, rtl)
+		if rtl {
+			pages[i] = alignRight(pages[i], width)
+		}
+	}
+	return pages
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !windows
+
+package main
+
+import "os/exec"
+
+func browserCommand(url string) *exec.Cmd {
+	return exec.Command("xdg-open", url)
+}
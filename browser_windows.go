@@ -0,0 +1,9 @@
+//go:build windows
+
+package main
+
+import "os/exec"
+
+func browserCommand(url string) *exec.Cmd {
+	return exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// interaction is one recorded HTTP exchange. Bodies that are not UTF-8
+// text, such as cover images, are stored base64 encoded.
+type interaction struct {
+	Method string              `json:"method"`
+	URL    string              `json:"url"`
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header,omitempty"`
+	Body   string              `json:"body,omitempty"`
+	Base64 bool                `json:"base64,omitempty"`
+}
+
+// cassetteHeader is the first line of a cassette. Each line after it is
+// an interaction, appended as the request is made.
+type cassetteHeader struct {
+	Recorded time.Time `json:"recorded"`
+}
+
+// cassetteSecrets are the headers left out of a recording.
+var cassetteSecrets = []string{"Set-Cookie", "Cookie", "Authorization", "Proxy-Authorization"}
+
+// cassette records every HTTP request to a file or replays them from one,
+// so a failing search or download can be attached to a bug report and the
+// scrapers run offline against it. Passwords in URLs and credentials in
+// headers are left out of the recording.
+type cassette struct {
+	mu           sync.Mutex
+	path         string
+	replay       bool
+	interactions []interaction
+	used         []bool
+	next         http.RoundTripper
+}
+
+// configureCassette installs a recording or replaying transport on the
+// default HTTP client; at most one of record and replay may be set.
+func configureCassette(record, replay string) error {
+	switch {
+	case record != "" && replay != "":
+		return errors.New(tr("use either -record or -replay, not both"))
+	case record != "":
+		data, err := json.Marshal(cassetteHeader{Recorded: time.Now()})
+		if err == nil {
+			err = os.WriteFile(record, append(data, '\n'), 0o644)
+		}
+		if err != nil {
+			return fmt.Errorf(tr("create cassette: %w"), err)
+		}
+		http.DefaultClient.Transport = &cassette{path: record, next: http.DefaultTransport}
+	case replay != "":
+		c, err := readCassette(replay)
+		if err != nil {
+			return err
+		}
+		http.DefaultClient.Transport = c
+	}
+	return nil
+}
+
+func readCassette(path string) (*cassette, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf(tr("read cassette: %w"), err)
+	}
+	defer file.Close()
+	c := &cassette{path: path, replay: true}
+	dec := json.NewDecoder(file)
+	var header cassetteHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf(tr("read cassette %s: %w"), path, err)
+	}
+	for {
+		var rec interaction
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf(tr("read cassette %s: %w"), path, err)
+		}
+		c.interactions = append(c.interactions, rec)
+	}
+	c.used = make([]bool, len(c.interactions))
+	return c, nil
+}
+
+func (c *cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	if c.replay {
+		return c.play(req)
+	}
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	for _, secret := range cassetteSecrets {
+		header.Del(secret)
+	}
+	rec := interaction{Method: req.Method, URL: req.URL.Redacted(), Status: resp.StatusCode, Header: header}
+	if utf8.Valid(body) {
+		rec.Body = string(body)
+	} else {
+		rec.Body, rec.Base64 = base64.StdEncoding.EncodeToString(body), true
+	}
+	if err := c.append(rec); err != nil {
+		debugLog.Error("cassette save failed", "path", c.path, "err", err)
+	}
+	return resp, nil
+}
+
+// play answers with the first unused recording of the same request, so a
+// URL fetched twice replays both answers in order.
+func (c *cassette) play(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	url := req.URL.Redacted()
+	for i, rec := range c.interactions {
+		if c.used[i] || rec.Method != req.Method || rec.URL != url {
+			continue
+		}
+		c.used[i] = true
+		body := []byte(rec.Body)
+		if rec.Base64 {
+			var err error
+			if body, err = base64.StdEncoding.DecodeString(rec.Body); err != nil {
+				return nil, fmt.Errorf(tr("cassette %s: %w"), c.path, err)
+			}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+			StatusCode:    rec.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header(rec.Header),
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf(tr("%s %s is not in cassette %s"), req.Method, url, c.path)
+}
+
+// append adds an interaction to the end of the cassette, one line each.
+func (c *cassette) append(rec interaction) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	file, err := os.OpenFile(c.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func useCassette(t *testing.T, record, replay string) {
+	t.Helper()
+	transport := http.DefaultClient.Transport
+	t.Cleanup(func() { http.DefaultClient.Transport = transport })
+	if err := configureCassette(record, replay); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCassetteReplaysSearch(t *testing.T) {
+	useCassette(t, "", filepath.Join("testdata", "search-dickens.jsonl"))
+	books, err := fetchBooks(t.Context(), "dickens", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bookResult{
+		{Title: "A Tale of Two Cities", Subtitle: "Charles Dickens", Extra: "18403 downloads", URL: "https://www.gutenberg.org/ebooks/98"},
+		{Title: "Great Expectations", Subtitle: "Charles Dickens", Extra: "16027 downloads", URL: "https://www.gutenberg.org/ebooks/1400"},
+	}
+	if len(books) != len(want) {
+		t.Fatalf("got %d books, want %d: %+v", len(books), len(want), books)
+	}
+	for i := range want {
+		if got := books[i]; got.Title != want[i].Title || got.Subtitle != want[i].Subtitle || got.Extra != want[i].Extra || got.URL != want[i].URL {
+			t.Errorf("book %d = %+v, want %+v", i, books[i], want[i])
+		}
+	}
+	if _, err := fetchBooks(t.Context(), "dickens", 1); err == nil || !strings.Contains(err.Error(), "start_index=26") {
+		t.Errorf("a request missing from the cassette should fail naming it, got %v", err)
+	}
+}
+
+func TestCassetteRecordsWithoutSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=cookie-secret")
+		w.Header().Set("Authorization", "Bearer header-secret")
+		io.WriteString(w, "answer to "+r.URL.Path)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL + "/books")
+	u.User = url.UserPassword("reader", "url-secret")
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	useCassette(t, path, "")
+	for range 2 {
+		body, err := readURL(t.Context(), featureSearch, u.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "answer to /books" {
+			t.Fatalf("recorded body = %q", body)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"cookie-secret", "header-secret", "url-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette keeps %s:\n%s", secret, data)
+		}
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("cassette has %d lines, want a header and 2 interactions", lines)
+	}
+
+	useCassette(t, "", path)
+	for range 2 {
+		body, err := readURL(t.Context(), featureSearch, u.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "answer to /books" {
+			t.Errorf("replayed body = %q", body)
+		}
+	}
+}
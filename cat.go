@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// catText is the -json output of gutberg cat.
+type catText struct {
+	Title    string       `json:"title"`
+	Path     string       `json:"path"`
+	Language string       `json:"language,omitempty"`
+	Chapters []catChapter `json:"chapters"`
+}
+
+type catChapter struct {
+	Number     int      `json:"number"`
+	Title      string   `json:"title"`
+	Paragraphs []string `json:"paragraphs"`
+}
+
+// runCat is gutberg cat: the cleaned text of a book, or one chapter of it,
+// on stdout for grep, wc, fmt or espeak.
+func runCat(cfg Config, args []string) error {
+	fs, asJSON := queryFlags("cat", tr("Usage: gutberg cat <book> [-chapter N] [-json]"))
+	chapter := fs.Int("chapter", 0, tr("print only this chapter (from 1)"))
+	fs.Parse(args)
+	// The book usually comes before the flags, where flag stops parsing.
+	bookArg := fs.Arg(0)
+	fs.Parse(fs.Args()[min(1, fs.NArg()):])
+	if bookArg == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return errors.New(tr("cat needs one book: a Gutenberg number, URL or library file"))
+	}
+	path, err := resolveBookArg(context.Background(), cfg.BooksDir, bookArg, nil)
+	if err != nil {
+		return err
+	}
+	book, err := loadBook(path, pageLineWidth, pageLineCount)
+	if err != nil {
+		return err
+	}
+	first, last := 0, len(book.Chapters)-1
+	if *chapter != 0 {
+		if *chapter < 1 || *chapter > len(book.Chapters) {
+			return fmt.Errorf(tr("%s has %d chapters, not %d"), book.Title, len(book.Chapters), *chapter)
+		}
+		first, last = *chapter-1, *chapter-1
+	}
+	text := catText{Title: book.Title, Path: path, Language: book.Language}
+	for c := first; c <= last; c++ {
+		ch := catChapter{Number: c + 1, Title: book.Chapters[c].Title, Paragraphs: []string{}}
+		for _, para := range strings.Split(book.ChapterText(c), paragraphBreak) {
+			if plain := plainParagraph(para); plain != "" {
+				ch.Paragraphs = append(ch.Paragraphs, plain)
+			}
+		}
+		text.Chapters = append(text.Chapters, ch)
+	}
+	if *asJSON {
+		return printJSON(text)
+	}
+	out := bufio.NewWriter(os.Stdout)
+	for _, ch := range text.Chapters {
+		fmt.Fprintf(out, "%s\n\n", ch.Title)
+		for _, para := range ch.Paragraphs {
+			fmt.Fprintf(out, "%s\n\n", para)
+		}
+	}
+	return out.Flush()
+}
+
+// plainParagraph is a paragraph of chapter text without styles, unwrapped
+// except for the line breaks of verse.
+func plainParagraph(para string) string {
+	para = strings.Trim(stripStyles(para), "\n")
+	if !strings.Contains(para, verseMark) {
+		return strings.Join(strings.Fields(para), " ")
+	}
+	lines := strings.Split(para, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(strings.TrimPrefix(line, verseMark), " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// copiedText is what y copies in the reader: the selected paragraphs,
+// unwrapped and followed by where they come from, ready to be quoted.
+func (m model) copiedText() (string, int) {
+	paras := m.selectedParagraphs()
+	if len(paras) == 0 {
+		return "", 0
+	}
+	source := displayTitle(m.currentBook.Title)
+	if location := pageLocation(m.currentBook, m.state.Page); location > 0 {
+		source += ", " + trf("loc %d", location)
+	}
+	text := strings.Join(paras, "\n\n")
+	return fmt.Sprintf("%s\n\n— %s\n", text, source), len(strings.Fields(text))
+}
+
+// selectedParagraphs is the passage copy and quote act on: the paragraph
+// picked for glosses while they are shown, the page otherwise.
+func (m model) selectedParagraphs() []string {
+	paras := pageParagraphs(m.currentBook.Page(m.state.Page))
+	if len(paras) > 0 && m.glossing && m.dict != nil {
+		paras = paras[min(m.glossIndex, len(paras)-1):][:1]
+	}
+	return paras
+}
+
+// copyToClipboard puts text on the system clipboard through the terminal,
+// with OSC 52, so it also works over SSH. Terminals that ignore OSC 52, or
+// tmux without set-clipboard, leave the clipboard as it was.
+func copyToClipboard(text string) tea.Cmd {
+	return func() tea.Msg {
+		termenv.NewOutput(os.Stdout).Copy(text)
+		return nil
+	}
+}
+
+func (m *model) copyPage() tea.Cmd {
+	text, words := m.copiedText()
+	if text == "" {
+		return nil
+	}
+	m.status = trf("Copied the page to the clipboard (%d words)", words)
+	if m.glossing && m.dict != nil {
+		m.status = trf("Copied the paragraph to the clipboard (%d words)", words)
+	}
+	return copyToClipboard(text)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"unicode"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// catalogLanguage is the language setting, the catalog language author and
+// title lists are sorted for. Without one they follow the interface.
+var catalogLanguage string
+
+// initialLetters are the letters the author index groups names under: the
+// Latin alphabet, then the letters some languages sort on their own (ñ in
+// Spanish, å ä ö in Swedish...). Elsewhere those join their base letter.
+var initialLetters = []string{
+	"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m",
+	"n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z",
+	"ñ", "ç", "æ", "ø", "å", "ä", "ö", "ü", "þ", "ð",
+}
+
+func collationTag() language.Tag {
+	lang := catalogLanguage
+	if lang == "" {
+		lang = uiLanguage
+	}
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return language.English
+	}
+	return tag
+}
+
+// newCollator compares names the way the catalog language sorts them. A
+// collator keeps buffers of its own, so each sort takes a new one.
+func newCollator(options ...collate.Option) *collate.Collator {
+	return collate.New(collationTag(), options...)
+}
+
+// letterGrouper finds the index letter of names, remembering the letter of
+// each first rune it has seen.
+type letterGrouper struct {
+	collator *collate.Collator
+	letters  map[rune]string
+}
+
+func newLetterGrouper() *letterGrouper {
+	return &letterGrouper{collator: newCollator(collate.Loose), letters: map[rune]string{}}
+}
+
+// letter is the index letter of name: the initial letter that sorts the
+// same ignoring accents and case, or # for names starting otherwise.
+func (g *letterGrouper) letter(name string) string {
+	for _, r := range name {
+		if letter, ok := g.letters[r]; ok {
+			return letter
+		}
+		letter := "#"
+		if unicode.IsLetter(r) {
+			// The rune's own letter goes before the other extra letters, so
+			// Swedish ä, a letter of its own, isn't taken for a variant of æ.
+			candidates := append(initialLetters[:26:26], string(unicode.ToLower(r)))
+			for _, candidate := range append(candidates, initialLetters[26:]...) {
+				if g.collator.CompareString(string(r), candidate) == 0 {
+					letter = candidate
+					break
+				}
+			}
+		}
+		g.letters[r] = letter
+		return letter
+	}
+	return "#"
+}
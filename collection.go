@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LibraryStory is one story of a book read as a collection.
+type LibraryStory struct {
+	Title string `json:"title"`
+	Words int    `json:"words"`
+}
+
+type collectionMsg struct {
+	title   string
+	stories int
+	err     error
+}
+
+// storyKey names a story of a collection wherever a book path is used for
+// state: progress, read chapters, translations.
+func storyKey(path string, story int) string {
+	return fmt.Sprintf("%s#%d", path, story+1)
+}
+
+// splitStoryKey returns the book file of a key and its story, or -1 when
+// the key is a whole book. The story follows the last "#", as paths may
+// have one too.
+func splitStoryKey(key string) (string, int) {
+	i := strings.LastIndex(key, "#")
+	if i < 0 {
+		return key, -1
+	}
+	path, num := key[:i], key[i+1:]
+	n, err := strconv.Atoi(num)
+	if err != nil || n < 1 {
+		return key, -1
+	}
+	return path, n - 1
+}
+
+// loadBook opens a library key: a whole book, or a single story of a
+// collection laid out as a book of its own.
+func loadBook(key string, width, lines int) (Book, error) {
+	path, story := splitStoryKey(key)
+	book, err := loadBookFromHTML(path, width, lines)
+	if err != nil || story < 0 {
+		return book, err
+	}
+	if story >= len(book.Chapters) {
+		return Book{}, fmt.Errorf(tr("%s has no story %d"), filepath.Base(path), story+1)
+	}
+	ch := book.Chapters[story]
+	book.Title = ch.Title
+	book.Chapters = []Chapter{ch}
+	book.Words = ch.Words
+	if ch.Language != "" {
+		book.Language = ch.Language
+	}
+	book.layoutPages(width, lines)
+	return book, nil
+}
+
+// toggleCollectionCmd switches a book between a single library entry and
+// one entry per top-level chapter, recording the stories' titles and sizes
+// so the library can list them without opening the book.
+func toggleCollectionCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		dir, name := filepath.Split(path)
+		lib, err := loadLibrary(dir)
+		if err != nil {
+			return collectionMsg{err: err}
+		}
+		entry := lib.Books[name]
+		title := strings.TrimSuffix(name, ".html")
+		if entry.Collection {
+			entry.Collection = false
+			entry.Stories = nil
+		} else {
+			book, err := loadBookFromHTML(path, pageLineWidth, pageLineCount)
+			if err != nil {
+				return collectionMsg{err: err}
+			}
+			if len(book.Chapters) < 2 {
+				return collectionMsg{err: fmt.Errorf(tr("%s has no chapters to read as stories"), book.Title)}
+			}
+			title = book.Title
+			entry.Collection = true
+			entry.Stories = make([]LibraryStory, len(book.Chapters))
+			for i, ch := range book.Chapters {
+				entry.Stories[i] = LibraryStory{Title: ch.Title, Words: ch.Words}
+			}
+		}
+		lib.Books[name] = entry
+		if err := saveLibrary(dir, lib); err != nil {
+			return collectionMsg{err: err}
+		}
+		return collectionMsg{title: title, stories: len(entry.Stories)}
+	}
+}
+
+func storyItems(book libraryItem, stories []LibraryStory, state State, wpm int) []list.Item {
+	items := make([]list.Item, 0, len(stories))
+	for i, story := range stories {
+		item := book
+		item.key = storyKey(book.path, i)
+		item.title = book.title + " · " + story.Title
+		if book.series != "" {
+			item.volumeTitle = strings.TrimPrefix(book.volumeTitle+" · "+story.Title, " · ")
+		}
+		item.story = trf("story %d/%d · %d words", i+1, len(stories), story.Words)
+		if wpm > 0 {
+			item.story += trf(" · ~%d min", max(story.Words/wpm, 1))
+		}
+		switch {
+		case state.chapterRead(item.key, 0):
+			item.story += trf(" · finished %s", readMark)
+		case state.Pages[item.key] > 0:
+			item.story += tr(" · started")
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (m model) updateCollection(msg collectionMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		return m, m.libraryList.NewStatusMessage(msg.err.Error())
+	}
+	status := trf("%s is one book again", msg.title)
+	if msg.stories > 0 {
+		status = trf("%s: %d stories, each with its own progress", msg.title, msg.stories)
+	}
+	items, _ := loadLibraryItems(m.config.BooksDir, m.state, m.config.WPM)
+	return m, tea.Batch(m.libraryList.SetItems(items), m.libraryList.NewStatusMessage(status))
+}
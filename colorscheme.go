@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Terminals that support it (kitty, foot, Ghostty, recent VTE, tmux...)
+// report switches between their light and dark color schemes once asked
+// to with DEC mode 2031, as CSI ? 997 ; 1 n for dark and ; 2 n for light.
+const (
+	colorSchemeReportsOn  = "\x1b[?2031h"
+	colorSchemeReportsOff = "\x1b[?2031l"
+	colorSchemeDark       = "?997;1n"
+	colorSchemeLight      = "?997;2n"
+)
+
+// colorSchemeMsg is a background found dark or light after startup.
+type colorSchemeMsg struct {
+	dark bool
+	// asked is set when the user asked to detect it again, so the result
+	// is reported even when nothing changed.
+	asked bool
+}
+
+// colorSchemeReport recognizes a color scheme report. Bubble Tea passes it
+// on as an unknown CSI sequence, printed as ?CSI[bytes]?.
+func colorSchemeReport(msg tea.Msg) (tea.Msg, bool) {
+	s, ok := msg.(fmt.Stringer)
+	if !ok || !strings.HasPrefix(s.String(), "?CSI[") {
+		return nil, false
+	}
+	var seq []byte
+	for _, field := range strings.Fields(strings.Trim(strings.TrimPrefix(s.String(), "?CSI"), "[]?")) {
+		b, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, false
+		}
+		seq = append(seq, byte(b))
+	}
+	switch string(seq) {
+	case colorSchemeDark:
+		return colorSchemeMsg{dark: true}, true
+	case colorSchemeLight:
+		return colorSchemeMsg{dark: false}, true
+	}
+	return nil, false
+}
+
+// backgroundProbe asks the terminal for its background color with OSC 11.
+// It runs through tea.Exec, which hands over the terminal so the answer is
+// not read as key presses.
+type backgroundProbe struct {
+	dark *bool
+}
+
+func (p backgroundProbe) SetStdin(io.Reader)  {}
+func (p backgroundProbe) SetStdout(io.Writer) {}
+func (p backgroundProbe) SetStderr(io.Writer) {}
+
+func (p backgroundProbe) Run() error {
+	*p.dark = termenv.NewOutput(os.Stdout).HasDarkBackground()
+	return nil
+}
+
+// redetectBackgroundCmd detects the background again, for terminals that
+// do not report scheme switches.
+func redetectBackgroundCmd() tea.Cmd {
+	dark := new(bool)
+	return tea.Exec(backgroundProbe{dark: dark}, func(err error) tea.Msg {
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return colorSchemeMsg{dark: *dark, asked: true}
+	})
+}
+
+// setBackground records the terminal background, for the auto theme and
+// the adaptive colors of lists.
+func setBackground(dark bool) {
+	backgroundTheme()
+	detectedTheme = "light"
+	if dark {
+		detectedTheme = "dark"
+	}
+	lipgloss.SetHasDarkBackground(dark)
+}
+
+func (m model) updateColorScheme(msg colorSchemeMsg) (tea.Model, tea.Cmd) {
+	before := detectedTheme
+	setBackground(msg.dark)
+	if m.config.Theme == themeAuto {
+		setTheme(themeAuto)
+	}
+	if detectedTheme == before && !msg.asked {
+		return m, nil
+	}
+	m.status = tr("The terminal background is light")
+	if msg.dark {
+		m.status = tr("The terminal background is dark")
+	}
+	if m.config.Theme != themeAuto {
+		m.status = trf("%s; the theme stays %s (auto follows the terminal)", m.status, m.config.Theme)
+	}
+	return m, tea.Batch(tea.ClearScreen, m.libraryList.NewStatusMessage(m.status))
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// adultSubjects are what hide_adult filters out: the subjects and
+// bookshelves Gutenberg files its erotica under.
+var adultSubjects = []string{"erotica", "erotic", "pornograph", "sex instruction"}
+
+// gutendexPageSize is how many books a Gutendex page holds.
+const gutendexPageSize = 32
+
+// contentFilter drops search and browse results filed under a blocked
+// subject. subjects keeps the Gutendex subjects of Gutenberg books already
+// looked up, by ebook number.
+var contentFilter struct {
+	mu       sync.RWMutex
+	blocked  []string
+	subjects map[int][]string
+}
+
+func configureContentFilter(cfg Config) {
+	var blocked []string
+	if cfg.HideAdult {
+		blocked = append(blocked, adultSubjects...)
+	}
+	for _, subject := range cfg.BlockedSubjects {
+		blocked = append(blocked, strings.ToLower(subject))
+	}
+	contentFilter.mu.Lock()
+	defer contentFilter.mu.Unlock()
+	contentFilter.blocked = blocked
+	if contentFilter.subjects == nil {
+		contentFilter.subjects = make(map[int][]string)
+	}
+}
+
+// splitSubjects reads the comma separated blocked_subjects setting.
+func splitSubjects(value string) []string {
+	var subjects []string
+	for _, subject := range strings.Split(value, ",") {
+		if subject = strings.TrimSpace(subject); subject != "" {
+			subjects = append(subjects, subject)
+		}
+	}
+	return subjects
+}
+
+// blockedSubject is the blocked subject one of subjects falls under, if any.
+// A blocked subject matches any subject containing it, so "horror" also
+// filters "Horror tales".
+func blockedSubject(subjects []string) (string, bool) {
+	contentFilter.mu.RLock()
+	defer contentFilter.mu.RUnlock()
+	for _, subject := range subjects {
+		subject = strings.ToLower(subject)
+		for _, blocked := range contentFilter.blocked {
+			if strings.Contains(subject, blocked) {
+				return blocked, true
+			}
+		}
+	}
+	return "", false
+}
+
+func contentFilterActive() bool {
+	contentFilter.mu.RLock()
+	defer contentFilter.mu.RUnlock()
+	return len(contentFilter.blocked) > 0
+}
+
+// gutenbergNumber is the ebook number of a Gutenberg result.
+func gutenbergNumber(r bookResult) (int, bool) {
+	if r.Feed || !strings.Contains(r.URL, "gutenberg.org/") {
+		return 0, false
+	}
+	match := ebookIDRe.FindStringSubmatch(r.URL)
+	if match == nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(match[1])
+	return id, err == nil
+}
+
+// filterResults leaves out the results with a blocked subject. Gutenberg
+// search pages and the popular lists don't show subjects, so those books
+// are looked up on Gutendex; when that fails the results are withheld
+// rather than shown unfiltered. Results from other sources are checked
+// against the categories their feed lists, if any.
+func filterResults(ctx context.Context, results []bookResult) ([]bookResult, error) {
+	if !contentFilterActive() {
+		return results, nil
+	}
+	if err := lookupSubjects(ctx, results); err != nil {
+		return nil, fmt.Errorf(tr("content filter: %w"), err)
+	}
+	kept := results[:0]
+	for _, r := range results {
+		subjects := r.Subjects
+		if id, ok := gutenbergNumber(r); ok {
+			contentFilter.mu.RLock()
+			subjects = slices.Concat(subjects, contentFilter.subjects[id])
+			contentFilter.mu.RUnlock()
+		}
+		if blocked, ok := blockedSubject(subjects); ok {
+			debugLog.Debug("content filter", "title", r.Title, "subject", blocked)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept, nil
+}
+
+// lookupSubjects fetches the subjects of the Gutenberg books in results
+// not looked up yet, in one Gutendex request per page of them.
+func lookupSubjects(ctx context.Context, results []bookResult) error {
+	var ids []string
+	contentFilter.mu.RLock()
+	for _, r := range results {
+		if id, ok := gutenbergNumber(r); ok {
+			if _, known := contentFilter.subjects[id]; !known {
+				ids = append(ids, strconv.Itoa(id))
+			}
+		}
+	}
+	contentFilter.mu.RUnlock()
+
+	for len(ids) > 0 {
+		batch := ids[:min(len(ids), gutendexPageSize)]
+		ids = ids[len(batch):]
+		var data struct {
+			Results []gutendexBook `json:"results"`
+		}
+		if err := fetchGutendex(ctx, "https://gutendex.com/books/?ids="+strings.Join(batch, ","), &data); err != nil {
+			return err
+		}
+		contentFilter.mu.Lock()
+		for _, book := range data.Results {
+			contentFilter.subjects[book.ID] = book.allSubjects()
+		}
+		// Books Gutendex doesn't know have no subjects to block.
+		for _, id := range batch {
+			n, _ := strconv.Atoi(id)
+			if _, ok := contentFilter.subjects[n]; !ok {
+				contentFilter.subjects[n] = []string{}
+			}
+		}
+		contentFilter.mu.Unlock()
+	}
+	return nil
+}
+
+// allowedBook reports whether a Gutendex book passes the content filter.
+func allowedBook(book gutendexBook) bool {
+	_, blocked := blockedSubject(book.allSubjects())
+	return !blocked
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	coverColumns = 3
+	coverRows    = 2
+)
+
+type coverItem interface {
+	coverID() string
+}
+
+type coverMsg struct {
+	id    string
+	thumb string
+	rest  []string
+}
+
+type coverDelegate struct {
+	list.DefaultDelegate
+	covers map[string]string
+}
+
+func newCoverDelegate(covers map[string]string) coverDelegate {
+	return coverDelegate{DefaultDelegate: list.NewDefaultDelegate(), covers: covers}
+}
+
+func (d coverDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	var buf bytes.Buffer
+	d.DefaultDelegate.Render(&buf, m, index, item)
+	thumb := blankCover()
+	if c, ok := item.(coverItem); ok {
+		if rendered, ok := d.covers[c.coverID()]; ok {
+			thumb = rendered
+		}
+	}
+	fmt.Fprint(w, lipgloss.JoinHorizontal(lipgloss.Top, thumb, " ", buf.String()))
+}
+
+func (b bookItem) coverID() string {
+	if !isGutenberg(b.source) {
+		return ""
+	}
+	return ebookIDFromURL(b.result.URL)
+}
+
+func (l libraryItem) coverID() string { return l.id }
+
+func coverIDs(items []list.Item, covers map[string]string) []string {
+	ids := []string{}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		c, ok := item.(coverItem)
+		if !ok {
+			continue
+		}
+		id := c.coverID()
+		if id == "" || seen[id] {
+			continue
+		}
+		if _, ok := covers[id]; ok {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func fetchCoversCmd(cacheDir string, ids []string) tea.Cmd {
+	if len(ids) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		id := ids[0]
+		thumb, err := loadCover(context.Background(), cacheDir, id)
+		if err != nil {
+			thumb = blankCover()
+		}
+		return coverMsg{id: id, thumb: thumb, rest: ids[1:]}
+	}
+}
+
+func loadCover(ctx context.Context, cacheDir, id string) (string, error) {
+	path := filepath.Join(cacheDir, "covers", id+".jpg")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		data, err = downloadCover(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return renderHalfBlocks(img, coverColumns, coverRows), nil
+}
+
+func downloadCover(ctx context.Context, id string) ([]byte, error) {
+	coverURL := fmt.Sprintf("https://www.gutenberg.org/cache/epub/%s/pg%s.cover.small.jpg", id, id)
+	resp, err := getURL(ctx, featureCovers, coverURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func renderHalfBlocks(img image.Image, columns, rows int) string {
+	bounds := img.Bounds()
+	pixelRows := rows * 2
+	lines := make([]string, 0, rows)
+	for row := 0; row < rows; row++ {
+		var b strings.Builder
+		for col := 0; col < columns; col++ {
+			top := averageColor(img, bounds, col, row*2, columns, pixelRows)
+			bottom := averageColor(img, bounds, col, row*2+1, columns, pixelRows)
+			b.WriteString(lipgloss.NewStyle().Foreground(top).Background(bottom).Render("▀"))
+		}
+		lines = append(lines, b.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func averageColor(img image.Image, bounds image.Rectangle, col, row, columns, rows int) lipgloss.Color {
+	x0 := bounds.Min.X + col*bounds.Dx()/columns
+	x1 := bounds.Min.X + (col+1)*bounds.Dx()/columns
+	y0 := bounds.Min.Y + row*bounds.Dy()/rows
+	y1 := bounds.Min.Y + (row+1)*bounds.Dy()/rows
+	var r, g, b, n uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			pr, pg, pb, _ := img.At(x, y).RGBA()
+			r += uint64(pr >> 8)
+			g += uint64(pg >> 8)
+			b += uint64(pb >> 8)
+			n++
+		}
+	}
+	if n == 0 {
+		return lipgloss.Color("#000000")
+	}
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r/n, g/n, b/n))
+}
+
+func blankCover() string {
+	line := strings.Repeat(" ", coverColumns)
+	lines := make([]string, coverRows)
+	for i := range lines {
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// crashGuard wraps the model so a panic in Update, View or a command saves
+// the reading position and a report, then quits cleanly, letting Bubble Tea
+// leave the alternate screen instead of dying with the terminal in raw mode.
+type crashGuard struct {
+	model  model
+	report *string
+}
+
+type crashMsg struct {
+	value any
+	stack []byte
+}
+
+func newCrashGuard(m model) crashGuard {
+	return crashGuard{model: m, report: new(string)}
+}
+
+func (g crashGuard) Init() tea.Cmd {
+	return guardCmd(g.model.Init())
+}
+
+func (g crashGuard) Update(msg tea.Msg) (next tea.Model, cmd tea.Cmd) {
+	if *g.report != "" {
+		return g, tea.Quit
+	}
+	if crash, ok := msg.(crashMsg); ok {
+		return g.crash(crash.value, crash.stack)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			next, cmd = g.crash(r, debug.Stack())
+		}
+	}()
+	updated, cmd := g.model.Update(msg)
+	g.model = updated.(model)
+	return g, guardCmd(cmd)
+}
+
+func (g crashGuard) View() (view string) {
+	if *g.report != "" {
+		return crashNotice(*g.report) + "\n" + tr("Press any key to quit.") + "\n"
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			g.crash(r, debug.Stack())
+			view = crashNotice(*g.report) + "\n" + tr("Press any key to quit.") + "\n"
+		}
+	}()
+	return g.model.View()
+}
+
+func (g crashGuard) crash(value any, stack []byte) (tea.Model, tea.Cmd) {
+	debugLog.Error("panic", "value", value, "mode", g.model.mode, "book", g.model.state.CurrentBook, "page", g.model.state.Page)
+	saveStateNow(g.model.store, g.model.state)
+	path, err := writeCrashReport(g.model, value, stack)
+	if err != nil {
+		path = trf("(not saved: %s)", err)
+	}
+	saveCrashRestore(g.model, path)
+	*g.report = path
+	return g, tea.Quit
+}
+
+// guardCmd makes a command report its panic as a crashMsg; batches are
+// guarded command by command, as Bubble Tea runs each on its own.
+func guardCmd(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if r := recover(); r != nil {
+				msg = crashMsg{value: r, stack: debug.Stack()}
+			}
+		}()
+		msg = cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			guarded := make(tea.BatchMsg, len(batch))
+			for i, c := range batch {
+				guarded[i] = guardCmd(c)
+			}
+			return guarded
+		}
+		return msg
+	}
+}
+
+func writeCrashReport(m model, value any, stack []byte) (string, error) {
+	if err := os.MkdirAll(m.config.CacheDir, 0o755); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	path := filepath.Join(m.config.CacheDir, "crash-"+now.Format("20060102-150405")+".log")
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\npanic: %v\nmode: %d\nbook: %s\npage: %d\npage size: %dx%d\n\n%s", now.Format(time.RFC3339), value, m.mode, m.state.CurrentBook, m.state.Page, m.pageWidth, m.pageLines, stack)
+	if lines := recentLog.recent(); len(lines) > 0 {
+		fmt.Fprintf(&b, "\nlast %d log lines:\n%s\n", len(lines), strings.Join(lines, "\n"))
+	}
+	return path, os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+func crashNotice(report string) string {
+	return trf("gutberg hit an internal error. Your reading position was saved and will be offered next time.\nCrash report: %s\n", report)
+}
+
+// crashRestore is where the reader was when gutberg crashed, kept as a
+// position within the chapter so it survives a different window size.
+type crashRestore struct {
+	Book    string  `json:"book"`
+	Title   string  `json:"title"`
+	Chapter int     `json:"chapter"`
+	Offset  float64 `json:"offset"`
+	Report  string  `json:"report"`
+}
+
+func crashRestorePath(cacheDir string) string {
+	return filepath.Join(cacheDir, "restore.json")
+}
+
+func saveCrashRestore(m model, report string) {
+	index, offset := pagePosition(m.currentBook, m.state.Page)
+	if m.state.CurrentBook == "" || index < 0 {
+		return
+	}
+	r := crashRestore{Book: m.state.CurrentBook, Title: m.currentBook.Title, Chapter: index, Offset: offset, Report: report}
+	if data, err := json.Marshal(r); err == nil {
+		os.WriteFile(crashRestorePath(m.config.CacheDir), data, 0o644)
+	}
+}
+
+// takeCrashRestore returns the position saved by the last crash, once.
+func takeCrashRestore(cacheDir string) *crashRestore {
+	path := crashRestorePath(cacheDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	os.Remove(path)
+	var r crashRestore
+	if err := json.Unmarshal(data, &r); err != nil || r.Book == "" {
+		return nil
+	}
+	return &r
+}
+
+func (m model) updateRestorePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := m.restore
+	switch msg.String() {
+	case "y", "enter":
+		m.restore, m.restoreAt = nil, r
+		cmd := m.startLoading(tr("Loading book"), openBookCmd(r.Book, m.pageWidth, m.pageLines))
+		return m, cmd
+	case "n", "esc":
+		m.restore = nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// restorePage places the reader where the crash left it in a book just
+// loaded for a restore.
+func (m *model) restorePage(path string) {
+	r := m.restoreAt
+	if r == nil || r.Book != path {
+		return
+	}
+	m.restoreAt = nil
+	if r.Chapter >= len(m.currentBook.Chapters) {
+		return
+	}
+	page := positionPage(m.currentBook, r.Chapter, r.Offset)
+	m.state.Page = page
+	m.state.Pages[path] = page
+}
+
+func (m model) restoreView() string {
+	r := m.restore
+	lines := []string{
+		titleStyle().Render(tr("gutberg closed unexpectedly")),
+		"",
+		trf("Reopen %s at chapter %d, %d%% through it?", r.Title, r.Chapter+1, int(r.Offset*100)),
+		"",
+		metaStyle().Render(tr("Crash report: ") + r.Report),
+		"",
+		helpLine(tr("y/enter: reopen  n/esc: continue")),
+	}
+	return strings.Join(lines, "\n")
+}
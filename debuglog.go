@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	logLevelOff   = "off"
+	logMaxSize    = 1 << 20
+	logMaxBackups = 3
+	crashLogLines = 50
+)
+
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// recentLog keeps the last lines logged at any level, for crash reports,
+// whether or not the log file is on.
+var recentLog = &logRing{size: crashLogLines}
+
+// debugLog records HTTP requests, parsing decisions and state saves so they
+// can be attached to bug reports. Until configureLogging opens the log file
+// it only feeds recentLog.
+var debugLog = slog.New(recentLog.handler())
+
+// configureLogging opens the log file when level is not "off". The returned
+// function closes it.
+func configureLogging(path, level string) (func(), error) {
+	level = strings.ToLower(level)
+	if level == "" || level == logLevelOff {
+		debugLog = slog.New(recentLog.handler())
+		return func() {}, nil
+	}
+	lvl, ok := logLevels[level]
+	if !ok {
+		return nil, fmt.Errorf(tr("unknown log level %q (use off, error, warn, info or debug)"), level)
+	}
+	w := &rotatingFile{path: path}
+	if err := w.open(); err != nil {
+		return nil, fmt.Errorf(tr("open log file: %w"), err)
+	}
+	debugLog = slog.New(fanoutHandler{recentLog.handler(), slog.NewTextHandler(w, &slog.HandlerOptions{Level: lvl})})
+	debugLog.Info("logging started", "level", level, "pid", os.Getpid())
+	return w.close, nil
+}
+
+// rotatingFile appends to path, moving it to path.1 (and older copies one
+// number up) once it grows past logMaxSize.
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+func (w *rotatingFile) open() error {
+	file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+func (w *rotatingFile) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return len(p), nil
+	}
+	if w.size+int64(len(p)) > logMaxSize && w.size > 0 {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingFile) rotate() error {
+	w.file.Close()
+	for i := logMaxBackups - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	os.Rename(w.path, w.path+".1")
+	return w.open()
+}
+
+func (w *rotatingFile) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+}
+
+// logRing holds the last size lines written to it.
+type logRing struct {
+	mu    sync.Mutex
+	size  int
+	lines []string
+}
+
+func (r *logRing) handler() slog.Handler {
+	return slog.NewTextHandler(r, &slog.HandlerOptions{Level: slog.LevelDebug})
+}
+
+func (r *logRing) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, strings.TrimRight(string(p), "\n"))
+	if len(r.lines) > r.size {
+		r.lines = r.lines[len(r.lines)-r.size:]
+	}
+	return len(p), nil
+}
+
+func (r *logRing) recent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.lines)
+}
+
+// fanoutHandler hands each record to every handler that wants its level.
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return slices.ContainsFunc(f, func(h slog.Handler) bool { return h.Enabled(ctx, level) })
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var err error
+	for _, h := range f {
+		if h.Enabled(ctx, record.Level) {
+			err = errors.Join(err, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return err
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanoutHandler, len(f))
+	for i, h := range f {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	out := make(fanoutHandler, len(f))
+	for i, h := range f {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// appDirs are where gutberg keeps its files: the config, the data that
+// cannot be rebuilt (books, reading state, logs) and caches.
+type appDirs struct {
+	config string
+	data   string
+	cache  string
+}
+
+// defaultDirs follows the XDG base directories on Linux and the BSDs; on
+// macOS and Windows data sits with the config, as those systems have no
+// separate place for it.
+func defaultDirs(profile string) (appDirs, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return appDirs{}, err
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return appDirs{}, err
+	}
+	data, err := userDataDir(config)
+	if err != nil {
+		return appDirs{}, err
+	}
+	return appDirs{
+		config: profileDir(filepath.Join(config, "gutberg"), profile),
+		data:   profileDir(filepath.Join(data, "gutberg"), profile),
+		cache:  profileDir(filepath.Join(cache, "gutberg"), profile),
+	}, nil
+}
+
+func userDataDir(configDir string) (string, error) {
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+		return configDir, nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// migrateLegacyDirs moves books, state, logs and cache out of the config
+// dir, where older versions kept them, to their places in dirs. Only paths
+// still at the old defaults are moved; ones set by hand are left alone. It
+// reports whether cfg changed and needs writing back.
+func migrateLegacyDirs(cfg *Config, dirs appDirs) bool {
+	legacy := dirs.config
+	if legacy == dirs.data && legacy == dirs.cache {
+		return false
+	}
+	oldBooks, oldState := cfg.BooksDir, cfg.StateFile
+	moves := []struct {
+		field    *string
+		from, to string
+	}{
+		{&cfg.BooksDir, filepath.Join(legacy, "books"), filepath.Join(dirs.data, "books")},
+		{&cfg.StateFile, filepath.Join(legacy, "state.json"), filepath.Join(dirs.data, "state.json")},
+		{&cfg.AuditFile, filepath.Join(legacy, "requests.log"), filepath.Join(dirs.data, "requests.log")},
+		{&cfg.LogFile, filepath.Join(legacy, "debug.log"), filepath.Join(dirs.data, "debug.log")},
+		{&cfg.CacheDir, filepath.Join(legacy, "cache"), dirs.cache},
+	}
+	changed := false
+	for _, mv := range moves {
+		if *mv.field != mv.from || mv.from == mv.to {
+			continue
+		}
+		if err := moveLegacy(mv.from, mv.to); err != nil {
+			debugLog.Warn("could not move to the new directory", "from", mv.from, "to", mv.to, "err", err)
+			continue
+		}
+		*mv.field = mv.to
+		changed = true
+	}
+	if cfg.StateFile != oldState {
+		moveLegacy(filepath.Join(filepath.Dir(oldState), serialsFileName), serialsPath(*cfg))
+	}
+	if cfg.BooksDir != oldBooks {
+		for _, path := range []string{cfg.StateFile, serialsPath(*cfg)} {
+			if err := rewriteBookPaths(path, oldBooks, cfg.BooksDir); err != nil {
+				debugLog.Warn("could not update book paths", "path", path, "err", err)
+			}
+		}
+	}
+	return changed
+}
+
+// moveLegacy renames from to to, creating the parent of to. A missing from
+// is not an error: there is just nothing to move. An empty directory at to
+// is replaced.
+func moveLegacy(from, to string) error {
+	if _, err := os.Stat(from); os.IsNotExist(err) {
+		return nil
+	}
+	if entries, err := os.ReadDir(to); err == nil && len(entries) == 0 {
+		os.Remove(to)
+	}
+	if _, err := os.Stat(to); err == nil {
+		return os.ErrExist
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	debugLog.Info("moved to the new directory", "from", from, "to", to)
+	return nil
+}
+
+// rewriteBookPaths updates the book paths a JSON file is keyed by after the
+// books dir moved.
+func rewriteBookPaths(path, oldDir, newDir string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	prefix := func(dir string) string {
+		quoted, _ := json.Marshal(dir + string(filepath.Separator))
+		return strings.TrimSuffix(string(quoted), `"`)
+	}
+	return os.WriteFile(path, []byte(strings.ReplaceAll(string(data), prefix(oldDir), prefix(newDir))), 0o644)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	maxRandomProbes = 5
+	maxProbeID      = 75000
+)
+
+type gutendexBook struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Authors []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Subjects      []string `json:"subjects"`
+	Bookshelves   []string `json:"bookshelves"`
+	Languages     []string `json:"languages"`
+	DownloadCount int      `json:"download_count"`
+}
+
+type discoverMsg struct {
+	book     gutendexBook
+	featured bool
+	err      error
+}
+
+func (b gutendexBook) author() string {
+	names := make([]string, 0, len(b.Authors))
+	for _, a := range b.Authors {
+		names = append(names, a.Name)
+	}
+	return strings.Join(names, "; ")
+}
+
+// allSubjects are the book's subjects and the bookshelves it is on.
+func (b gutendexBook) allSubjects() []string {
+	return append(slices.Clone(b.Subjects), b.Bookshelves...)
+}
+
+func (b gutendexBook) result() bookResult {
+	return bookResult{
+		Title:    b.Title,
+		Subtitle: b.author(),
+		URL:      fmt.Sprintf("https://www.gutenberg.org/ebooks/%d", b.ID),
+		Subjects: b.allSubjects(),
+	}
+}
+
+func fetchGutendex(ctx context.Context, rawURL string, v any) error {
+	data, err := getCachedURL(ctx, featureSearch, rawURL)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func featuredBook(ctx context.Context, day time.Time) (gutendexBook, error) {
+	var data struct {
+		Results []gutendexBook `json:"results"`
+	}
+	if err := fetchGutendex(ctx, "https://gutendex.com/books/?sort=popular", &data); err != nil {
+		return gutendexBook{}, err
+	}
+	books := slices.DeleteFunc(data.Results, func(b gutendexBook) bool { return !allowedBook(b) })
+	if len(books) == 0 {
+		return gutendexBook{}, errors.New(tr("no popular books found"))
+	}
+	return books[day.YearDay()%len(books)], nil
+}
+
+func randomBook(ctx context.Context) (gutendexBook, error) {
+	var err error
+	for i := 0; i < maxRandomProbes; i++ {
+		var book gutendexBook
+		id := rand.Intn(maxProbeID) + 1
+		if err = fetchGutendex(ctx, fmt.Sprintf("https://gutendex.com/books/%d", id), &book); err == nil && book.ID != 0 && allowedBook(book) {
+			return book, nil
+		}
+	}
+	if err == nil {
+		err = errors.New(tr("no book found"))
+	}
+	return gutendexBook{}, err
+}
+
+func discoverCmd(featured bool) loadCmd {
+	return func(ctx context.Context) tea.Msg {
+		var book gutendexBook
+		var err error
+		if featured {
+			book, err = featuredBook(ctx, time.Now())
+		} else {
+			book, err = randomBook(ctx)
+		}
+		return discoverMsg{book: book, featured: featured, err: err}
+	}
+}
+
+func (m model) updateDiscover(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch m.keys.lookup(modeDiscover, msg.String()) {
+		case actionOpen:
+			if m.discovered.ID != 0 {
+				cmd := m.download(bookItem{result: m.discovered.result(), source: gutenbergSource{language: m.config.Language}})
+				return m, cmd
+			}
+		case actionDiscover:
+			cmd := m.startLoading(tr("Picking a random book"), discoverCmd(false))
+			return m, cmd
+		case actionFeatured:
+			cmd := m.startLoading(tr("Fetching today's featured book"), discoverCmd(true))
+			return m, cmd
+		case actionBack:
+			m.status = ""
+			m.mode = modeAuthorSearch
+			m.authorInput.Focus()
+		case actionQuit:
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m model) discoverView() string {
+	heading := tr("Random pick")
+	if m.discoverFeatured {
+		heading = tr("Featured today")
+	}
+	lines := []string{titleStyle().Render(trf("Discover · %s", heading)), ""}
+	if book := m.discovered; book.ID != 0 {
+		lines = append(lines, titleStyle().Render(book.Title))
+		if author := book.author(); author != "" {
+			lines = append(lines, "by "+author)
+		}
+		lines = append(lines, "")
+		meta := []string{fmt.Sprintf("#%d", book.ID), trf("%d downloads", book.DownloadCount)}
+		if len(book.Languages) > 0 {
+			meta = append(meta, strings.Join(book.Languages, ", "))
+		}
+		lines = append(lines, metaStyle().Render(strings.Join(meta, " · ")))
+		for _, subject := range book.Subjects {
+			lines = append(lines, metaStyle().Render("  "+subject))
+		}
+		lines = append(lines, "")
+	}
+	if status := m.statusText(); status != "" {
+		lines = append(lines, status, "")
+	}
+	lines = append(lines, helpLine(m.keys.hint(modeDiscover, actionOpen, actionDiscover, actionFeatured, actionBack, actionHelp, actionQuit)))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type progressFunc func(done, total int64)
+
+type progressReader struct {
+	r      io.Reader
+	done   int64
+	total  int64
+	report progressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	p.report(p.done, p.total)
+	return n, err
+}
+
+func trackProgress(resp *http.Response, report progressFunc) io.Reader {
+	if report == nil {
+		return resp.Body
+	}
+	return &progressReader{r: resp.Body, total: resp.ContentLength, report: report}
+}
+
+type downloadProgressMsg struct {
+	url     string
+	done    int64
+	total   int64
+	updates <-chan tea.Msg
+}
+
+func waitForDownload(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+func downloadLabel(b bookItem) string {
+	spin := strings.TrimSpace(b.spin)
+	switch {
+	case b.downloaded:
+		return "✓"
+	case !b.downloading:
+		return ""
+	case b.total > 0:
+		return fmt.Sprintf("%s %3d%%", spin, b.done*100/b.total)
+	case b.done > 0:
+		return fmt.Sprintf("%s %dKB", spin, b.done/1024)
+	}
+	return spin
+}
+
+func (m *model) updateDownloadRow(url string, update func(*bookItem)) {
+	for i, item := range m.bookList.Items() {
+		if b, ok := item.(bookItem); ok && b.result.URL == url {
+			update(&b)
+			m.bookList.SetItem(i, b)
+		}
+	}
+}
+
+func (m *model) spinDownloadRows() bool {
+	active := false
+	for i, item := range m.bookList.Items() {
+		if b, ok := item.(bookItem); ok && b.downloading {
+			b.spin = m.spinner.View()
+			m.bookList.SetItem(i, b)
+			active = true
+		}
+	}
+	return active
+}
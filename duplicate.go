@@ -0,0 +1,184 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// duplicatePrompt asks what to do with a search result the library already
+// has: open the copy there, replace it, or, for another book that only
+// shares its title and author, keep both.
+type duplicatePrompt struct {
+	item bookItem
+	path string
+	// same is set when the copy is the very book (same Gutenberg number or
+	// source), so keeping both makes no sense.
+	same bool
+}
+
+// normalizeTitle folds case, accents and punctuation, so "Don Quixote" and
+// "Don Quixote." compare equal.
+func normalizeTitle(title string) string {
+	return strings.Join(tokenize(foldString(title)), " ")
+}
+
+// sameAuthor compares author names as written by different sources
+// ("Cervantes Saavedra, Miguel de", "Miguel de Cervantes"): they match when
+// they share a word longer than a particle, or when either is unknown.
+func sameAuthor(a, b string) bool {
+	wordsA, wordsB := tokenize(foldString(a)), tokenize(foldString(b))
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return true
+	}
+	for _, w := range wordsA {
+		if len(w) > 3 && slices.Contains(wordsB, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// findDuplicate looks for result in the library at dir.
+func findDuplicate(dir string, item bookItem) *duplicatePrompt {
+	lib, err := loadLibrary(dir)
+	if err != nil {
+		return nil
+	}
+	id := ebookIDFromURL(item.result.URL)
+	title := normalizeTitle(item.result.Title)
+	names := make([]string, 0, len(lib.Books))
+	for name := range lib.Books {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var match *duplicatePrompt
+	for _, name := range names {
+		entry := lib.Books[name]
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if id != "" && entry.ID == id || entry.Source != "" && entry.Source == item.result.URL {
+			return &duplicatePrompt{item: item, path: path, same: true}
+		}
+		if match == nil && title != "" && normalizeTitle(entry.Title) == title && sameAuthor(entry.Author, item.result.Subtitle) {
+			match = &duplicatePrompt{item: item, path: path}
+		}
+	}
+	return match
+}
+
+// startDownload downloads a search result into the library and opens it,
+// with its progress shown on its row of the results.
+func (m *model) startDownload(item bookItem) tea.Cmd {
+	if cmd, ok := m.chooseFormat(item); ok {
+		return cmd
+	}
+	m.status = ""
+	if m.mode == modeDiscover || m.mode == modeFinished {
+		m.status = tr("Downloading book...")
+	}
+	m.updateDownloadRow(item.result.URL, func(b *bookItem) {
+		b.downloading = true
+		b.downloaded = false
+		b.done, b.total = 0, 0
+		b.spin = m.spinner.View()
+	})
+	return tea.Batch(downloadAndLoadCmd(item.source, item.result, m.config.BooksDir, m.pageWidth, m.pageLines), m.spinner.Tick)
+}
+
+// download starts a download, first asking when the book is already in
+// the library.
+func (m *model) download(item bookItem) tea.Cmd {
+	if d := findDuplicate(m.config.BooksDir, item); d != nil {
+		m.duplicate = d
+		return nil
+	}
+	return m.startDownload(item)
+}
+
+func (m model) updateDuplicatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := m.duplicate
+	switch msg.String() {
+	case "o", "enter":
+		m.duplicate = nil
+		cmd := m.startLoading(tr("Loading book"), openBookCmd(d.path, m.pageWidth, m.pageLines))
+		return m, cmd
+	case "r":
+		m.duplicate = nil
+		if err := removeBookFile(d.path); err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		cmd := m.startDownload(d.item)
+		return m, cmd
+	case "k":
+		if d.same {
+			return m, nil
+		}
+		m.duplicate = nil
+		cmd := m.startDownload(d.item)
+		return m, cmd
+	case "n", "esc":
+		m.duplicate = nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) duplicateView() string {
+	d := m.duplicate
+	question := trf("%s is already in the library as %s.", d.item.result.Title, filepath.Base(d.path))
+	keys := tr("o/enter: open it  r: replace it  n/esc: cancel")
+	if !d.same {
+		question = trf("The library has a book with the same title: %s.", filepath.Base(d.path))
+		keys = tr("o/enter: open it  r: replace it  k: keep both  n/esc: cancel")
+	}
+	return strings.Join([]string{
+		titleStyle().Render(tr("Already downloaded")),
+		"",
+		question,
+		"",
+		helpLine(keys),
+	}, "\n")
+}
+
+// removeBookFile deletes a book file with its library entry and cached
+// text, leaving the reading state to whoever replaces it.
+func removeBookFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	dir, name := filepath.Split(path)
+	lib, err := loadLibrary(dir)
+	if err != nil {
+		return err
+	}
+	delete(lib.Books, name)
+	removeChapterText(path)
+	return saveLibrary(dir, lib)
+}
+
+// freeBookName returns fileName, or fileName with a number added when
+// another book already has that name in dir: two editions, or books
+// sharing a title and author, get files of their own.
+func freeBookName(dir, fileName string, lib Library, entry LibraryEntry) string {
+	base := strings.TrimSuffix(fileName, ".html")
+	for n := 2; ; n++ {
+		existing, known := lib.Books[fileName]
+		if _, err := os.Stat(filepath.Join(dir, fileName)); os.IsNotExist(err) {
+			return fileName
+		}
+		if known && existing.Source != "" && existing.Source == entry.Source && existing.ID == entry.ID {
+			return fileName
+		}
+		fileName = base + "-" + strconv.Itoa(n) + ".html"
+	}
+}
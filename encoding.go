@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// decodeText transcodes legacy Latin-1 and Windows-1252 books to UTF-8. The
+// charset comes from a BOM, the Content-Type or <meta> tags, and otherwise
+// from whether the bytes are valid UTF-8; text that is valid UTF-8 with
+// non-ASCII characters wins over a <meta> tag claiming a legacy charset,
+// which older Gutenberg files often get wrong.
+func decodeText(data []byte, contentType string) []byte {
+	if bytes.HasPrefix(data, utf8BOM) {
+		return data[len(utf8BOM):]
+	}
+	enc, name, _ := charset.DetermineEncoding(data, contentType)
+	if name == "utf-8" || (utf8.Valid(data) && !isASCII(data) && name != "utf-16le" && name != "utf-16be") {
+		return data
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return data
+	}
+	return decoded
+}
+
+func isASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
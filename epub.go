@@ -0,0 +1,237 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Title    string `xml:"metadata>title"`
+	Language string `xml:"metadata>language"`
+	Metas    []struct {
+		Name     string `xml:"name,attr"`
+		Content  string `xml:"content,attr"`
+		Property string `xml:"property,attr"`
+		Refines  string `xml:"refines,attr"`
+		ID       string `xml:"id,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"metadata>meta"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+var bodyRe = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+
+func epubToHTML(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf(tr("open epub: %w"), err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var container epubContainer
+	if err := decodeZipXML(files, "META-INF/container.xml", &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, errors.New(tr("epub has no rootfile"))
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := decodeZipXML(files, opfPath, &pkg); err != nil {
+		return nil, err
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		hrefs[item.ID] = item.Href
+	}
+
+	var b strings.Builder
+	if lang := strings.TrimSpace(pkg.Language); lang != "" {
+		fmt.Fprintf(&b, "<html lang=\"%s\"><head><title>", html.EscapeString(lang))
+	} else {
+		b.WriteString("<html><head><title>")
+	}
+	b.WriteString(html.EscapeString(strings.TrimSpace(pkg.Title)))
+	b.WriteString("</title>")
+	if series, volume := pkg.series(); series != "" {
+		fmt.Fprintf(&b, "<meta name=\"calibre:series\" content=\"%s\"><meta name=\"calibre:series_index\" content=\"%d\">", html.EscapeString(series), volume)
+	}
+	b.WriteString("</head><body>\n")
+	baseDir := path.Dir(opfPath)
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		content, err := readZipFile(files, path.Join(baseDir, href))
+		if err != nil {
+			return nil, err
+		}
+		if m := bodyRe.FindSubmatch(content); len(m) == 2 {
+			content = m[1]
+		}
+		b.Write(content)
+		b.WriteString("\n")
+	}
+	b.WriteString("</body></html>\n")
+	return []byte(b.String()), nil
+}
+
+func decodeZipXML(files map[string]*zip.File, name string, v any) error {
+	data, err := readZipFile(files, name)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf(tr("parse %s: %w"), name, err)
+	}
+	return nil
+}
+
+func readZipFile(files map[string]*zip.File, name string) ([]byte, error) {
+	f, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf(tr("epub entry not found: %s"), name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func bookToEPUB(book Book, author, id string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return nil, err
+	}
+
+	var manifest, spine, nav strings.Builder
+	files := map[string]string{
+		"META-INF/container.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>
+`,
+	}
+	names := []string{"META-INF/container.xml"}
+	for i, ch := range book.Chapters {
+		name := fmt.Sprintf("chapter%03d.xhtml", i+1)
+		title := ch.Title
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+		fmt.Fprintf(&manifest, "    <item id=\"c%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, name)
+		fmt.Fprintf(&spine, "    <itemref idref=\"c%d\"/>\n", i+1)
+		fmt.Fprintf(&nav, "      <li><a href=\"%s\">%s</a></li>\n", name, html.EscapeString(title))
+
+		var body strings.Builder
+		fmt.Fprintf(&body, "<h2>%s</h2>\n", html.EscapeString(title))
+		for _, para := range strings.Split(book.ChapterText(i), paragraphBreak) {
+			body.WriteString(paragraphXHTML(para))
+		}
+		files["OEBPS/"+name] = xhtmlDocument(title, body.String())
+		names = append(names, "OEBPS/"+name)
+	}
+
+	files["OEBPS/nav.xhtml"] = xhtmlDocument("Contents", "<nav epub:type=\"toc\"><ol>\n"+nav.String()+"    </ol></nav>\n")
+	files["OEBPS/content.opf"] = fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:gutberg:%s</dc:identifier>
+    <dc:title>%s</dc:title>
+    <dc:creator>%s</dc:creator>
+    <dc:language>%s</dc:language>
+    <meta property="dcterms:modified">%s</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+%s  </manifest>
+  <spine>
+%s  </spine>
+</package>
+`, html.EscapeString(id), html.EscapeString(book.Title), html.EscapeString(author), html.EscapeString(epubLanguage(book)), time.Now().UTC().Format("2006-01-02T15:04:05Z"), manifest.String(), spine.String())
+	names = append(names, "OEBPS/nav.xhtml", "OEBPS/content.opf")
+
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// paragraphXHTML renders one paragraph of chapter text, keeping the line
+// breaks of verse.
+func paragraphXHTML(para string) string {
+	para = strings.Trim(stripStyles(para), "\n")
+	switch {
+	case strings.TrimSpace(para) == "":
+		return ""
+	case strings.Contains(para, verseMark):
+		lines := strings.Split(para, "\n")
+		for j, l := range lines {
+			lines[j] = html.EscapeString(strings.TrimPrefix(l, verseMark))
+		}
+		return fmt.Sprintf("<p class=\"verse\">%s</p>\n", strings.Join(lines, "<br/>\n"))
+	default:
+		return fmt.Sprintf("<p>%s</p>\n", html.EscapeString(strings.TrimSpace(para)))
+	}
+}
+
+func epubLanguage(book Book) string {
+	if book.Language != "" {
+		return book.Language
+	}
+	return "en"
+}
+
+func xhtmlDocument(title, body string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>%s</title></head>
+<body>
+%s</body>
+</html>
+`, html.EscapeString(title), body)
+}
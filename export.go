@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	exportMarkdown = "md"
+	exportText     = "txt"
+	// exportWidth is the line width of plain text exports, for printing
+	// and for tools that expect short lines.
+	exportWidth = 72
+)
+
+var exportFormats = []string{exportMarkdown, exportText}
+
+var (
+	// markdownSpecialRe matches what Markdown would read as markup inside a
+	// paragraph.
+	markdownSpecialRe = regexp.MustCompile("([\\\\`*_\\[\\]<>])")
+	// markdownBlockRe matches paragraph starts Markdown takes for headings,
+	// quotes, rules and lists.
+	markdownBlockRe = regexp.MustCompile(`^(#|>|[-+*=]\s|\d+[.)]\s)`)
+	// styleOpenSpaceRe and styleCloseSpaceRe match the spaces just inside a
+	// style, which Markdown emphasis does not allow.
+	styleOpenSpaceRe  = regexp.MustCompile("(\x1b\\[(?:1|3)m)(\\s+)")
+	styleCloseSpaceRe = regexp.MustCompile("(\\s+)(\x1b\\[(?:22|23)m)")
+	// emptyStyleRe matches styles around nothing, which would be read as
+	// literal asterisks.
+	emptyStyleRe = regexp.MustCompile("\x1b\\[(?:1|3)m\x1b\\[(?:22|23)m")
+)
+
+type exportedMsg struct {
+	path string
+	err  error
+}
+
+// exportBook writes the cleaned text of a book to w, chapter by chapter:
+// Markdown keeps headings, emphasis and the line breaks of verse; plain
+// text is wrapped to exportWidth columns.
+func exportBook(w io.Writer, book Book, author, format string) error {
+	title := displayTitle(book.Title)
+	var b strings.Builder
+	if format == exportMarkdown {
+		fmt.Fprintf(&b, "# %s\n\n", markdownEscape(title))
+		if author != "" {
+			fmt.Fprintf(&b, "*%s*\n\n", markdownEscape(author))
+		}
+	} else {
+		fmt.Fprintf(&b, "%s\n%s\n\n", title, strings.Repeat("=", min(visibleWidth(title), exportWidth)))
+		if author != "" {
+			fmt.Fprintf(&b, "%s\n\n", author)
+		}
+	}
+	for i, ch := range book.Chapters {
+		if format == exportMarkdown {
+			fmt.Fprintf(&b, "## %s\n\n", markdownEscape(ch.Title))
+		} else {
+			fmt.Fprintf(&b, "\n%s\n%s\n\n", ch.Title, strings.Repeat("-", min(visibleWidth(ch.Title), exportWidth)))
+		}
+		for _, para := range strings.Split(book.ChapterText(i), paragraphBreak) {
+			if text := exportParagraph(para, format); text != "" {
+				fmt.Fprintf(&b, "%s\n\n", text)
+			}
+		}
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+		b.Reset()
+	}
+	return nil
+}
+
+func exportParagraph(para, format string) string {
+	if format != exportMarkdown {
+		plain := plainParagraph(para)
+		if plain == "" || strings.Contains(para, verseMark) {
+			return plain
+		}
+		return wrapParagraph(plain, exportWidth)
+	}
+	if plainParagraph(para) == "" {
+		return ""
+	}
+	para = strings.Trim(para, "\n")
+	para = styleOpenSpaceRe.ReplaceAllString(para, "$2$1")
+	para = styleCloseSpaceRe.ReplaceAllString(para, "$2$1")
+	para = emptyStyleRe.ReplaceAllString(para, "")
+	// Styles become emphasis after escaping, which would take their codes
+	// for text.
+	var spans []string
+	para = styleCodeRe.ReplaceAllStringFunc(para, func(code string) string {
+		spans = append(spans, code)
+		return "\x00"
+	})
+	para = markdownEscape(para)
+	for _, code := range spans {
+		marker := "*"
+		if code == styleBoldOn || code == styleBoldOff {
+			marker = "**"
+		}
+		para = strings.Replace(para, "\x00", marker, 1)
+	}
+	if !strings.Contains(para, verseMark) {
+		return strings.Join(strings.Fields(para), " ")
+	}
+	lines := strings.Split(para, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(strings.TrimPrefix(line, verseMark), " ")
+	}
+	// A backslash at the end of a line is a hard line break.
+	return strings.Join(lines, "\\\n")
+}
+
+func markdownEscape(s string) string {
+	s = markdownSpecialRe.ReplaceAllString(s, `\$1`)
+	if markdownBlockRe.MatchString(s) {
+		s = `\` + s
+	}
+	return s
+}
+
+// defaultExportDir is where the interface writes exports: the home
+// directory, where they are easy to find.
+func defaultExportDir() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return home
+	}
+	return "."
+}
+
+// exportName is the file name of an export of a library key: the book's
+// file name, with the story number for a story of a collection.
+func exportName(key, format string) string {
+	path, story := splitStoryKey(key)
+	name := strings.TrimSuffix(filepath.Base(path), ".html")
+	if story >= 0 {
+		name = fmt.Sprintf("%s-%d", name, story+1)
+	}
+	return name + "." + format
+}
+
+// libraryAuthor is the author the library recorded for a book file.
+func libraryAuthor(path string) string {
+	lib, err := loadLibrary(filepath.Dir(path))
+	if err != nil {
+		return ""
+	}
+	return lib.Books[filepath.Base(path)].Author
+}
+
+// exportToFile exports a library key into dir and returns the file written.
+func exportToFile(key, format, dir string) (string, error) {
+	book, err := loadBook(key, pageLineWidth, pageLineCount)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path, _ := splitStoryKey(key)
+	out := filepath.Join(dir, exportName(key, format))
+	file, err := os.Create(out)
+	if err != nil {
+		return "", err
+	}
+	if err := exportBook(file, book, libraryAuthor(path), format); err != nil {
+		file.Close()
+		return "", err
+	}
+	return out, file.Close()
+}
+
+func exportBookCmd(key, format, dir string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := exportToFile(key, format, dir)
+		return exportedMsg{path: path, err: err}
+	}
+}
+
+// exportCommands are the palette commands exporting the book a palette
+// command acts on.
+func (m model) exportCommands() []paletteCommand {
+	_, title, ok := m.paletteBook()
+	if !ok {
+		return nil
+	}
+	key := m.state.CurrentBook
+	if item, ok := m.libraryList.SelectedItem().(libraryItem); ok && m.mode == modeLibrary {
+		key = item.key
+	}
+	var commands []paletteCommand
+	for _, format := range exportFormats {
+		label := trf("export %s as Markdown", title)
+		if format == exportText {
+			label = trf("export %s as plain text", title)
+		}
+		commands = append(commands, paletteCommand{
+			label: label,
+			run: func(m model) (tea.Model, tea.Cmd) {
+				m.status = trf("Exporting %s...", title)
+				return m, tea.Batch(m.libraryList.NewStatusMessage(m.status), exportBookCmd(key, format, m.config.ExportDir))
+			},
+		})
+	}
+	return commands
+}
+
+func (m model) updateExported(msg exportedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = msg.err.Error()
+	} else {
+		m.status = trf("Exported to %s", msg.path)
+	}
+	return m, m.libraryList.NewStatusMessage(m.status)
+}
+
+// runExport is gutberg export: a book's cleaned text as Markdown or plain
+// text, on stdout or in a file.
+func runExport(cfg Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage: gutberg export <book> [-format md|txt] [-o file]"))
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", exportMarkdown, tr("md (Markdown) or txt (plain text)"))
+	output := fs.String("o", "", tr("write to this file instead of stdout"))
+	fs.Parse(args)
+	// The book usually comes before the flags, where flag stops parsing.
+	bookArg := fs.Arg(0)
+	fs.Parse(fs.Args()[min(1, fs.NArg()):])
+	if bookArg == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return errors.New(tr("export needs one book: a Gutenberg number, URL or library file"))
+	}
+	*format = strings.TrimPrefix(strings.ToLower(*format), ".")
+	if *format == "markdown" {
+		*format = exportMarkdown
+	}
+	if *format != exportMarkdown && *format != exportText {
+		return fmt.Errorf(tr("unknown export format %q: use md or txt"), *format)
+	}
+	path, err := resolveBookArg(context.Background(), cfg.BooksDir, bookArg, nil)
+	if err != nil {
+		return err
+	}
+	book, err := loadBook(path, pageLineWidth, pageLineCount)
+	if err != nil {
+		return err
+	}
+	author := libraryAuthor(path)
+	if *output == "" || *output == "-" {
+		return exportBook(os.Stdout, book, author, *format)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := exportBook(file, book, author, *format); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	presetGutenbergHTML = "gutenberg-html"
+	presetOCRText       = "ocr-txt"
+	presetRaw           = "raw"
+)
+
+type textFilter struct {
+	name  string
+	apply func(string) string
+}
+
+type FilterConfig struct {
+	Default         string
+	Sources         map[string]string
+	Rules           []RegexRule
+	KeepBoilerplate bool
+}
+
+type RegexRule struct {
+	Pattern string
+	Replace string
+}
+
+type compiledRule struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+var textFilters = []textFilter{
+	{name: "boilerplate", apply: stripGutenbergBoilerplate},
+	{name: "dehyphenate", apply: dehyphenate},
+	{name: "ocr", apply: repairOCR},
+	{name: "footnotes", apply: stripFootnoteMarkers},
+	{name: "typography", apply: smartTypography},
+	{name: "regex", apply: applyRegexRules},
+}
+
+var filterPresets = map[string][]string{
+	presetGutenbergHTML: {"boilerplate", "footnotes", "typography", "regex"},
+	presetOCRText:       {"boilerplate", "dehyphenate", "ocr", "footnotes", "typography", "regex"},
+	presetRaw:           nil,
+}
+
+var presetNames = []string{presetGutenbergHTML, presetOCRText, presetRaw}
+
+var (
+	footnoteMarkerRe = regexp.MustCompile(`\s?\[(\d{1,3}|[*†‡])\]`)
+	hyphenBreakRe    = regexp.MustCompile(`(\p{L}+)-[ \t]*\n[ \t]*(\p{Ll}+)`)
+	lineHyphenRe     = regexp.MustCompile(`(\p{L}-)[ \t]*\n[ \t]*(\p{L})`)
+	ellipsisRe       = regexp.MustCompile(`\.\s?\.\s?\.`)
+	rnWordRe         = regexp.MustCompile(`\p{L}*rn\p{L}*`)
+
+	smallPrintEndRe   = regexp.MustCompile(`(?i)\*END\*\s*THE SMALL PRINT!.*\*END\*`)
+	gutenbergLineRe   = regexp.MustCompile(`(?im)^` + verseMark + `?\s*(?:The Project Gutenberg e-?Book,? of|End of (?:the )?Project Gutenberg'?s?\b).*$`)
+	transcriberNoteRe = regexp.MustCompile(`(?i)^\W*transcriber(?:'s|’s|s'|s’|s)?\s+notes?\W*`)
+)
+
+// rnConfusions maps English words where OCR read an "m" as "rn" back to the
+// real word; only whole words are replaced, so "burn" or "modern" are left
+// alone. Words that are also real, such as "horne" or "corne", are left out.
+var rnConfusions = map[string]string{
+	"rnay": "may", "rnan": "man", "rnen": "men", "rne": "me", "rny": "my",
+	"rnore": "more", "rnost": "most", "rnuch": "much", "rnust": "must",
+	"rnade": "made", "rnake": "make", "rnind": "mind", "rnoment": "moment",
+	"rnother": "mother", "rnorning": "morning", "rnoney": "money",
+	"frorn": "from", "sorne": "some", "tirne": "time", "hirn": "him",
+	"thern": "them", "hirnself": "himself", "thernselves": "themselves",
+	"sarne": "same", "narne": "name", "cornrnon": "common",
+	"cornpany": "company", "becarne": "became", "whorn": "whom",
+	"seerned": "seemed", "arnong": "among",
+}
+
+var ocrReplacer = strings.NewReplacer(
+	"\u00b6 ", "", "\u00b6", "", "\u00ad", "",
+	"\ufb00", "ff", "\ufb01", "fi", "\ufb02", "fl", "\ufb03", "ffi", "\ufb04", "ffl",
+)
+
+var activeFilters struct {
+	mu      sync.RWMutex
+	preset  string
+	sources map[string]string
+	rules   []compiledRule
+	keep    bool
+}
+
+func configureFilters(cfg FilterConfig) error {
+	rules := make([]compiledRule, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf(tr("filter rule %q: %w"), rule.Pattern, err)
+		}
+		rules = append(rules, compiledRule{re: re, replace: rule.Replace})
+	}
+
+	activeFilters.mu.Lock()
+	defer activeFilters.mu.Unlock()
+	activeFilters.preset = presetGutenbergHTML
+	if validPreset(cfg.Default) {
+		activeFilters.preset = cfg.Default
+	}
+	activeFilters.sources = cfg.Sources
+	activeFilters.rules = rules
+	activeFilters.keep = cfg.KeepBoilerplate
+	return nil
+}
+
+func keepBoilerplate() bool {
+	activeFilters.mu.RLock()
+	defer activeFilters.mu.RUnlock()
+	return activeFilters.keep
+}
+
+// stripsBoilerplate reports whether books read with preset lose their
+// license and transcriber's notes.
+func stripsBoilerplate(preset string) bool {
+	steps, ok := filterPresets[preset]
+	if !ok {
+		steps = filterPresets[defaultPreset()]
+	}
+	return !keepBoilerplate() && slices.Contains(steps, "boilerplate")
+}
+
+func validPreset(name string) bool {
+	_, ok := filterPresets[name]
+	return ok
+}
+
+func defaultPreset() string {
+	activeFilters.mu.RLock()
+	defer activeFilters.mu.RUnlock()
+	if activeFilters.preset == "" {
+		return presetGutenbergHTML
+	}
+	return activeFilters.preset
+}
+
+// presetForEntry picks the book's own preset, then the one configured for
+// the host it was downloaded from, then the default.
+func presetForEntry(entry LibraryEntry) string {
+	if validPreset(entry.Preset) {
+		return entry.Preset
+	}
+	if u, err := url.Parse(entry.Source); err == nil && u.Host != "" {
+		activeFilters.mu.RLock()
+		preset := activeFilters.sources[u.Host]
+		activeFilters.mu.RUnlock()
+		if validPreset(preset) {
+			return preset
+		}
+	}
+	return defaultPreset()
+}
+
+func applyFilters(text, preset string) string {
+	steps, ok := filterPresets[preset]
+	if !ok {
+		steps = filterPresets[defaultPreset()]
+	}
+	for _, name := range steps {
+		for _, f := range textFilters {
+			if f.name == name {
+				text = f.apply(text)
+			}
+		}
+	}
+	// Line breaks after a hyphen that dehyphenate left, or that no filter
+	// looked at, go; the hyphen stays in the word.
+	return lineHyphenRe.ReplaceAllString(text, "$1$2")
+}
+
+func nextPreset(current string) string {
+	for i, name := range presetNames {
+		if name == current {
+			return presetNames[(i+1)%len(presetNames)]
+		}
+	}
+	return presetNames[0]
+}
+
+func stripFootnoteMarkers(text string) string {
+	return footnoteMarkerRe.ReplaceAllString(text, "")
+}
+
+// dehyphenate joins a word hyphenated at the end of a line when the text
+// has it elsewhere without the hyphen; otherwise the hyphen is kept, as in
+// "well-known".
+func dehyphenate(text string) string {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		words[w] = true
+	}
+	return hyphenBreakRe.ReplaceAllStringFunc(text, func(broken string) string {
+		parts := hyphenBreakRe.FindStringSubmatch(broken)
+		if joined := parts[1] + parts[2]; words[strings.ToLower(joined)] {
+			return joined
+		}
+		return parts[1] + "-" + parts[2]
+	})
+}
+
+// repairOCR drops stray pilcrows and soft hyphens, expands typographic ligatures and, in
+// English text, fixes the common rn/m confusion of scanned texts.
+func repairOCR(text string) string {
+	text = ocrReplacer.Replace(text)
+	if detectLanguage(text) != "en" {
+		return text
+	}
+	return rnWordRe.ReplaceAllStringFunc(text, func(word string) string {
+		fixed, ok := rnConfusions[strings.ToLower(word)]
+		if !ok {
+			return word
+		}
+		if r := []rune(word); unicode.IsUpper(r[0]) {
+			return strings.ToUpper(fixed[:1]) + fixed[1:]
+		}
+		return fixed
+	})
+}
+
+// smartTypography curls quotes and sets dashes and ellipses, leaving verse,
+// <pre> and table lines (those starting with verseMark) as they are.
+func smartTypography(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, verseMark) {
+			lines[i] = typographyLine(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func typographyLine(line string) string {
+	line = strings.ReplaceAll(line, "---", "—")
+	line = strings.ReplaceAll(line, "--", "—")
+	line = ellipsisRe.ReplaceAllString(line, "…")
+
+	runes := []rune(line)
+	for i, r := range runes {
+		if r != '"' && r != '\'' {
+			continue
+		}
+		opening := i == 0 || strings.ContainsRune(" \t([{—", runes[i-1]) || (runes[i-1] == 'm' && i > 1 && isStyleEnd(runes, i-1))
+		switch {
+		case r == '"' && opening:
+			runes[i] = '“'
+		case r == '"':
+			runes[i] = '”'
+		case opening:
+			runes[i] = '‘'
+		default:
+			runes[i] = '’'
+		}
+	}
+	return string(runes)
+}
+
+// isStyleEnd reports whether the 'm' at i closes an ANSI style sequence, so
+// a quote right after an italic marker still opens.
+func isStyleEnd(runes []rune, i int) bool {
+	for j := i - 1; j >= 0 && j >= i-4; j-- {
+		if runes[j] == '[' {
+			return j > 0 && runes[j-1] == '\x1b'
+		}
+		if runes[j] < '0' || runes[j] > '9' {
+			return false
+		}
+	}
+	return false
+}
+
+// filtersKey sums up what the text of a book read with preset depends on
+// besides its file, so cached text is cleaned again when the filters change.
+func filtersKey(preset string) string {
+	activeFilters.mu.RLock()
+	defer activeFilters.mu.RUnlock()
+	key := fmt.Sprintf("%s %v %t", preset, filterPresets[preset], activeFilters.keep)
+	for _, rule := range activeFilters.rules {
+		key += fmt.Sprintf(" %q=%q", rule.re.String(), rule.replace)
+	}
+	return key
+}
+
+func applyRegexRules(text string) string {
+	activeFilters.mu.RLock()
+	rules := activeFilters.rules
+	activeFilters.mu.RUnlock()
+	for _, rule := range rules {
+		text = rule.re.ReplaceAllString(text, rule.replace)
+	}
+	return text
+}
+
+func cycleBookPreset(path string) (string, error) {
+	dir, name := filepath.Split(path)
+	lib, err := loadLibrary(dir)
+	if err != nil {
+		return "", err
+	}
+	entry := lib.Books[name]
+	entry.Preset = nextPreset(presetForEntry(entry))
+	lib.Books[name] = entry
+	return entry.Preset, saveLibrary(dir, lib)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRepairOCRLeavesOtherLanguages(t *testing.T) {
+	in := "la corne du taureau; Carne asada; Mr. Horne came"
+	if got := repairOCR(in); got != in {
+		t.Errorf("repairOCR(%q) = %q, want it unchanged", in, got)
+	}
+}
+
+func TestRepairOCRFixesEnglish(t *testing.T) {
+	in := "He came home frorn the office and told thern what he had seen."
+	want := "He came home from the office and told them what he had seen."
+	if got := repairOCR(in); got != want {
+		t.Errorf("repairOCR(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestDehyphenateKeepsRealHyphens(t *testing.T) {
+	in := "pre- and post-war, well-\nknown"
+	if got, want := applyFilters(in, presetOCRText), "pre- and post-war, well-known"; got != want {
+		t.Errorf("applyFilters(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestDehyphenateJoinsKnownWords(t *testing.T) {
+	in := "The exam-\nination was long; the examination took all day."
+	want := "The examination was long; the examination took all day."
+	if got := dehyphenate(in); got != want {
+		t.Errorf("dehyphenate(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestCollapseSpaceKeepsHyphenatedLineBreaks(t *testing.T) {
+	in := "a  well-\n  known\n\tfact"
+	if got, want := collapseSpace(in), "a well-\nknown fact"; got != want {
+		t.Errorf("collapseSpace(%q) = %q, want %q", in, got, want)
+	}
+	if got, want := joinLines("a well-\nknown\nfact"), "a well-\nknown fact"; got != want {
+		t.Errorf("joinLines = %q, want %q", got, want)
+	}
+}
+
+func TestSmartTypographySkipsPreformatted(t *testing.T) {
+	in := `<html><body><p>He said "wait -- now".</p><pre>x = "a" -- 'b'
+if (x--) return;</pre></body></html>`
+	got := cleanHTMLToText(in, presetGutenbergHTML)
+	for _, want := range []string{"He said “wait — now”.", `x = "a" -- 'b'`, "if (x--) return;"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("cleanHTMLToText = %q, want it to contain %q", got, want)
+		}
+	}
+}
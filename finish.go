@@ -0,0 +1,377 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	maxRating      = 5
+	maxSuggestions = 8
+)
+
+// BookRecord is what the state keeps about reading a book: when it was
+// started and finished, the time spent on it, and the rating and note
+// left on the last page.
+type BookRecord struct {
+	Started  string `json:"started,omitempty"`
+	Finished string `json:"finished,omitempty"`
+	Seconds  int    `json:"seconds,omitempty"`
+	Rating   int    `json:"rating,omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
+// finishScreen is the screen turning past the last page opens: the rating,
+// the closing note, the numbers of the read and what to read next.
+type finishScreen struct {
+	note        textinput.Model
+	editing     bool
+	suggestions []suggestion
+	cursor      int
+}
+
+// suggestion is a book offered at the end of another: a library key, a
+// catalog book to download when result is set, or a search for more by
+// author. next marks the following volume of the book's series.
+type suggestion struct {
+	label  string
+	reason string
+	key    string
+	author string
+	result *bookResult
+	next   bool
+}
+
+// logBookTime adds reading time to a book's record, starting it on the
+// first page read.
+func (s *State) logBookTime(key, date string, seconds int) {
+	if key == "" {
+		return
+	}
+	if s.Books == nil {
+		s.Books = make(map[string]BookRecord)
+	}
+	record := s.Books[key]
+	if record.Started == "" {
+		record.Started = date
+	}
+	record.Seconds += seconds
+	s.Books[key] = record
+}
+
+func ratingStars(rating int) string {
+	return strings.Repeat("★", rating) + strings.Repeat("☆", maxRating-rating)
+}
+
+// finishedMark is how the library shows a finished book.
+func (r BookRecord) finishedMark() string {
+	if r.Finished == "" {
+		return ""
+	}
+	if r.Rating > 0 {
+		return trf("finished %s", ratingStars(r.Rating))
+	}
+	return tr("finished")
+}
+
+// finishSuggestions lists what to read after key: the next volumes of its
+// series and other books by its author in the library, then the books
+// already started, then unread ones, and a search for more by the author.
+func (m model) finishSuggestions(key string) []suggestion {
+	path, _ := splitStoryKey(key)
+	lib, _ := loadLibrary(filepath.Dir(path))
+	author := lib.Books[filepath.Base(path)].Author
+	title := m.currentBook.Title
+	var current LibraryEntry
+	if entry := lib.Books[filepath.Base(path)]; path == key {
+		current = entry
+		if entry.Title != "" {
+			title = entry.Title
+		}
+	}
+	series, number, inSeries := bookSeries(current, title)
+
+	type ranked struct {
+		suggestion
+		rank  int
+		order int
+	}
+	var candidates []ranked
+	for _, item := range m.libraryList.Items() {
+		item, ok := item.(libraryItem)
+		if !ok || item.key == key || m.state.Books[item.key].Finished != "" {
+			continue
+		}
+		entry := lib.Books[filepath.Base(item.path)]
+		title := item.title
+		if entry.Title != "" && item.story == "" {
+			title = entry.Title
+		}
+		if item.story != "" {
+			entry = LibraryEntry{}
+		}
+		c := ranked{suggestion: suggestion{label: displayTitle(title), reason: tr("not started yet"), key: item.key}, rank: 4}
+		if s, n, ok := bookSeries(entry, title); ok && inSeries && foldString(s) == foldString(series) && n > number {
+			c.rank, c.order, c.reason, c.next = 0, n, tr("next in the series"), n == number+1
+			if n > number+1 {
+				c.rank, c.reason = 1, tr("later in the series")
+			}
+		} else if author != "" && entry.Author == author {
+			c.rank, c.reason = 2, trf("also by %s", author)
+		} else if m.state.Pages[item.key] > 0 {
+			c.rank, c.reason = 3, tr("already started")
+		}
+		candidates = append(candidates, c)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].rank != candidates[j].rank {
+			return candidates[i].rank < candidates[j].rank
+		}
+		return candidates[i].order < candidates[j].order
+	})
+	var out []suggestion
+	for _, c := range candidates {
+		if len(out) == maxSuggestions || c.rank == 4 && len(out) >= 3 {
+			break
+		}
+		out = append(out, c.suggestion)
+	}
+	if author != "" {
+		out = append(out, suggestion{label: trf("Find more by %s", author), reason: tr("search"), author: author})
+	}
+	return out
+}
+
+// openFinish shows the end of book screen, marking the book finished the
+// first time.
+func (m *model) openFinish() tea.Cmd {
+	key := m.state.CurrentBook
+	record := m.state.Books[key]
+	if record.Finished == "" {
+		record.Finished = time.Now().Format(scheduleDateLayout)
+		if record.Started == "" {
+			record.Started = record.Finished
+		}
+	}
+	note := textinput.New()
+	note.Placeholder = tr("A few words to remember it by")
+	note.CharLimit = 500
+	note.Width = max(m.width-12, 20)
+	note.SetValue(record.Note)
+	m.finish = finishScreen{note: note, suggestions: m.finishSuggestions(key)}
+	m.mode = modeFinished
+	m.status = ""
+	return tea.Batch(m.saveRecord(record), m.findRelated(key))
+}
+
+// saveRecord stores the record of the open book and shows it in the
+// library.
+func (m *model) saveRecord(record BookRecord) tea.Cmd {
+	if m.state.Books == nil {
+		m.state.Books = make(map[string]BookRecord)
+	}
+	m.state.Books[m.state.CurrentBook] = record
+	items, _ := loadLibraryItems(m.config.BooksDir, m.state, m.config.WPM)
+	m.libraryList.SetItems(items)
+	return saveStateCmd(m.store, m.state)
+}
+
+func (m model) updateFinish(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.finish.editing {
+		return m.updateFinishNote(msg)
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	record := m.state.Books[m.state.CurrentBook]
+	switch m.keys.lookup(modeFinished, key.String()) {
+	case actionRate:
+		rating := (record.Rating % maxRating) + 1
+		if n, err := strconv.Atoi(key.String()); err == nil && n >= 1 && n <= maxRating {
+			rating = n
+		}
+		if rating == record.Rating {
+			rating = 0
+		}
+		record.Rating = rating
+		return m, m.saveRecord(record)
+	case actionNote:
+		m.finish.editing = true
+		m.finish.note.CursorEnd()
+		return m, m.finish.note.Focus()
+	case actionMarkFinished:
+		if record.Finished != "" {
+			record.Finished = ""
+			m.status = tr("No longer marked as finished")
+		} else {
+			record.Finished = time.Now().Format(scheduleDateLayout)
+			m.status = ""
+		}
+		return m, m.saveRecord(record)
+	case actionUp:
+		m.finish.cursor = max(m.finish.cursor-1, 0)
+	case actionDown:
+		m.finish.cursor = min(m.finish.cursor+1, max(len(m.finish.suggestions)-1, 0))
+	case actionNextVolume:
+		if next, ok := m.finish.nextVolume(); ok {
+			cmd := m.startLoading(tr("Loading book"), openBookCmd(next.key, m.pageWidth, m.pageLines))
+			return m, cmd
+		}
+	case actionOpen:
+		if len(m.finish.suggestions) == 0 {
+			return m, nil
+		}
+		next := m.finish.suggestions[m.finish.cursor]
+		if next.result != nil {
+			cmd := m.downloadRelated(next)
+			return m, cmd
+		}
+		if next.key == "" {
+			source := m.sources[m.sourceIndex]
+			cmd := m.startLoading(tr("Searching books"), fetchBooksCmd(source, next.author))
+			return m, cmd
+		}
+		cmd := m.startLoading(tr("Loading book"), openBookCmd(next.key, m.pageWidth, m.pageLines))
+		return m, cmd
+	case actionBack:
+		m.status = ""
+		m.mode = modeReader
+	case actionLibrary:
+		m.status = ""
+		m.mode = modeLibrary
+	case actionQuit:
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// nextVolume is the following volume of the finished book's series, when
+// it is in the library and not read yet.
+func (f finishScreen) nextVolume() (suggestion, bool) {
+	for _, s := range f.suggestions {
+		if s.next {
+			return s, true
+		}
+	}
+	return suggestion{}, false
+}
+
+func (m model) updateFinishNote(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "enter":
+			record := m.state.Books[m.state.CurrentBook]
+			record.Note = strings.TrimSpace(m.finish.note.Value())
+			m.finish.editing = false
+			m.finish.note.Blur()
+			return m, m.saveRecord(record)
+		case "esc":
+			m.finish.editing = false
+			m.finish.note.SetValue(m.state.Books[m.state.CurrentBook].Note)
+			m.finish.note.Blur()
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.finish.note, cmd = m.finish.note.Update(msg)
+	return m, cmd
+}
+
+// readStats sums up the read of a book: its length, and the time it took
+// when the reading log has it.
+func readStats(book Book, record BookRecord) []string {
+	lines := []string{trf("%d pages · %d words · %d chapters", book.PageCount(), book.Words, len(book.Chapters))}
+	minutes := record.Seconds / 60
+	if minutes == 0 {
+		return lines
+	}
+	spent := trf("%d min", minutes)
+	if minutes >= 60 {
+		spent = trf("%d h %d min", minutes/60, minutes%60)
+	}
+	started, err1 := time.Parse(scheduleDateLayout, record.Started)
+	finished, err2 := time.Parse(scheduleDateLayout, record.Finished)
+	if err1 == nil && err2 == nil && finished.After(started) {
+		days := int(finished.Sub(started).Hours()/24) + 1
+		lines = append(lines, trf("Read in %s over %d days, from %s to %s", spent, days, record.Started, record.Finished))
+	} else {
+		lines = append(lines, trf("Read in %s", spent))
+	}
+	return append(lines, trf("About %d words a minute", book.Words/minutes))
+}
+
+func (m model) finishView() string {
+	record := m.state.Books[m.state.CurrentBook]
+	path, _ := splitStoryKey(m.state.CurrentBook)
+	lines := []string{titleStyle().Render(trf("Finished · %s", displayTitle(m.currentBook.Title)))}
+	if author := libraryAuthor(path); author != "" {
+		lines = append(lines, trf("by %s", author))
+	}
+	lines = append(lines, "")
+
+	rating := metaStyle().Render(tr("(not rated)"))
+	if record.Rating > 0 {
+		rating = ratingStars(record.Rating)
+	}
+	note := m.finish.note.View()
+	if !m.finish.editing {
+		note = record.Note
+		if note == "" {
+			note = metaStyle().Render(tr("(none)"))
+		}
+	}
+	finished := metaStyle().Render(tr("not marked as finished"))
+	if record.Finished != "" {
+		finished = trf("finished on %s", record.Finished)
+	}
+	lines = append(lines,
+		fmt.Sprintf("%-10s %s", tr("Rating"), rating),
+		fmt.Sprintf("%-10s %s", tr("Note"), note),
+		fmt.Sprintf("%-10s %s", tr("Status"), finished),
+		"",
+	)
+	for _, line := range readStats(m.currentBook, record) {
+		lines = append(lines, metaStyle().Render(line))
+	}
+	if next, ok := m.finish.nextVolume(); ok {
+		lines = append(lines, "", titleStyle().Render(trf("Continue with %s", next.label))+"  "+helpLine(m.keys.hint(modeFinished, actionNextVolume)))
+	}
+
+	if len(m.finish.suggestions) > 0 {
+		cursorStyle := lipgloss.NewStyle().Bold(true)
+		related := false
+		for i, s := range m.finish.suggestions {
+			switch {
+			case i == 0 && s.result == nil:
+				lines = append(lines, "", titleStyle().Render(tr("Read next")), "")
+			case s.result != nil && !related:
+				related = true
+				lines = append(lines, "", titleStyle().Render(tr("You might also like")), "")
+			}
+			line := "  " + s.label
+			if i == m.finish.cursor {
+				line = cursorStyle.Render("> " + s.label)
+			}
+			lines = append(lines, line+" "+metaStyle().Render("· "+s.reason))
+		}
+	}
+	lines = append(lines, "")
+	if status := m.statusText(); status != "" {
+		lines = append(lines, status, "")
+	}
+	if m.finish.editing {
+		lines = append(lines, helpLine(tr("enter: save  esc: cancel")))
+	} else {
+		lines = append(lines, helpLine(m.keys.hint(modeFinished, actionRate, actionNote, actionMarkFinished, actionOpen, actionBack, actionLibrary, actionHelp, actionQuit)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldString lowercases input and drops its accents, for matching names and
+// titles however they were typed. authors_gen.go builds with this file, so
+// the catalog's keys are folded the same way.
+func foldString(input string) string {
+	var b strings.Builder
+	b.Grow(len(input))
+	for _, r := range norm.NFD.String(input) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	presenceInterval = time.Second
+	// presenceStale is how long a presence file lives without a heartbeat
+	// before its gutberg is taken as gone.
+	presenceStale = 5 * time.Second
+)
+
+// presence is what a running gutberg tells the others sharing its cache
+// dir: the book it shows and where, as a location and as a chapter and the
+// fraction of it, so panes of different sizes land on the same text. The
+// file's modification time is its heartbeat.
+type presence struct {
+	PID        int       `json:"pid"`
+	Book       string    `json:"book,omitempty"`
+	Title      string    `json:"title,omitempty"`
+	Chapter    int       `json:"chapter"`
+	Offset     float64   `json:"offset"`
+	Location   int       `json:"location,omitempty"`
+	Presenting bool      `json:"presenting,omitempty"`
+	Updated    time.Time `json:"updated"`
+}
+
+// presenceMsg carries the other instances and the presence this one has
+// published, zero when publishing it failed.
+type presenceMsg struct {
+	others    []presence
+	published presence
+}
+
+func presenceDir(cacheDir string) string {
+	return filepath.Join(cacheDir, "instances")
+}
+
+func presencePath(cacheDir string, pid int) string {
+	return filepath.Join(presenceDir(cacheDir), strconv.Itoa(pid)+".json")
+}
+
+func writePresence(cacheDir string, p presence) error {
+	if err := os.MkdirAll(presenceDir(cacheDir), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	tmp := presencePath(cacheDir, p.PID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, presencePath(cacheDir, p.PID))
+}
+
+// removePresence is called on exit; a gutberg that dies without it goes
+// stale instead.
+func removePresence(cacheDir string) {
+	os.Remove(presencePath(cacheDir, os.Getpid()))
+	os.Remove(remotePath(cacheDir, os.Getpid()))
+}
+
+// readPresences lists the other live instances, clearing out stale ones.
+func readPresences(cacheDir string, now time.Time) []presence {
+	entries, err := os.ReadDir(presenceDir(cacheDir))
+	if err != nil {
+		return nil
+	}
+	var others []presence
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(presenceDir(cacheDir), entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var p presence
+		if err := json.Unmarshal(data, &p); err != nil || p.PID == os.Getpid() {
+			continue
+		}
+		p.Updated = info.ModTime()
+		if now.Sub(p.Updated) > presenceStale {
+			os.Remove(path)
+			continue
+		}
+		others = append(others, p)
+	}
+	return others
+}
+
+func (m model) presence() presence {
+	p := presence{PID: os.Getpid()}
+	if m.mode == modeReader && m.state.CurrentBook != "" {
+		p.Book, p.Title = m.state.CurrentBook, m.currentBook.Title
+		p.Chapter, p.Offset = pagePosition(m.currentBook, m.state.Page)
+		p.Location = pageLocation(m.currentBook, m.state.Page)
+		p.Presenting = m.presenting
+	}
+	return p
+}
+
+// presenceCmd publishes where this instance is and, a moment later, reads
+// where the others are. The presence file is only written again when it
+// changed; otherwise it is touched.
+func (m model) presenceCmd() tea.Cmd {
+	self, published, cacheDir := m.presence(), m.published, m.config.CacheDir
+	return tea.Tick(presenceInterval, func(now time.Time) tea.Msg {
+		var err error
+		if self == published {
+			err = os.Chtimes(presencePath(cacheDir, self.PID), now, now)
+		} else {
+			written := self
+			written.Updated = now
+			err = writePresence(cacheDir, written)
+		}
+		if err != nil {
+			debugLog.Warn("presence write failed", "err", err)
+			self = presence{}
+		}
+		return presenceMsg{others: readPresences(cacheDir, now), published: self}
+	})
+}
+
+// peerWithBook returns another instance showing the open book.
+func (m model) peerWithBook() (presence, bool) {
+	for _, p := range m.peers {
+		if p.Book != "" && p.Book == m.state.CurrentBook {
+			return p, true
+		}
+	}
+	return presence{}, false
+}
+
+// updatePresence mentions, once per book, that another pane has it open too,
+// and moves a following reader to the page the leader is on.
+func (m model) updatePresence(msg presenceMsg) (tea.Model, tea.Cmd) {
+	m.peers, m.published = msg.others, msg.published
+	var turn tea.Cmd
+	if m.following != 0 {
+		turn = m.followLeader()
+	} else if p, ok := m.peerWithBook(); ok && m.mode == modeReader {
+		if notice := fmt.Sprintf("%d:%s", p.PID, p.Book); m.peerNotice != notice {
+			m.peerNotice = notice
+			m.status = trf("This book is also open in another gutberg (pid %d) · F to follow its page turns", p.PID)
+		}
+	}
+	return m, tea.Batch(turn, m.presenceCmd())
+}
+
+func (m *model) followLeader() tea.Cmd {
+	var leader presence
+	found := false
+	for _, p := range m.peers {
+		if p.PID == m.following {
+			leader, found = p, true
+		}
+	}
+	switch {
+	case !found:
+		m.following = 0
+		m.status = tr("Stopped following: the other gutberg has closed")
+		return nil
+	case leader.Book == "":
+		return nil
+	case leader.Book != m.state.CurrentBook:
+		m.following = 0
+		m.status = trf("Stopped following: the other gutberg opened %s", leader.Title)
+		return nil
+	case leader.Chapter < 0 || leader.Chapter >= len(m.currentBook.Chapters):
+		return nil
+	}
+	page := positionPage(m.currentBook, leader.Chapter, leader.Offset)
+	if leader.Location > 0 {
+		page = locationPage(m.currentBook, leader.Location)
+	}
+	if page != m.state.Page {
+		return m.turnPage(page)
+	}
+	return nil
+}
+
+func (m *model) toggleFollow() tea.Cmd {
+	if m.following != 0 {
+		m.following = 0
+		m.status = tr("Stopped following")
+		return nil
+	}
+	p, ok := m.peerWithBook()
+	if !ok {
+		m.status = tr("No other gutberg has this book open")
+		return nil
+	}
+	m.following = p.PID
+	cmd := m.followLeader()
+	m.status = trf("Following gutberg pid %d: its page turns show here (F to stop)", p.PID)
+	return cmd
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	xhtml "golang.org/x/net/html"
+)
+
+// Formats of a Gutenberg book, as kept in download_format. Ask shows the
+// formats the book page lists before each download.
+const (
+	formatAsk          = "ask"
+	formatHTML         = "html"
+	formatHTMLNoImages = "html-noimages"
+	formatText         = "txt"
+	formatEPUB         = "epub"
+)
+
+var downloadFormats = []string{formatAsk, formatHTML, formatHTMLNoImages, formatText, formatEPUB}
+
+func validDownloadFormat(name string) bool {
+	for _, f := range downloadFormats {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func formatLabel(format string) string {
+	switch format {
+	case formatHTML:
+		return tr("HTML with images")
+	case formatHTMLNoImages:
+		return tr("HTML without images")
+	case formatText:
+		return tr("Plain text (UTF-8)")
+	case formatEPUB:
+		return tr("EPUB")
+	}
+	return format
+}
+
+// formatOfLink tells the format of a download link on a Gutenberg book
+// page, by the /ebooks/ name of the file or by its name in the cache.
+func formatOfLink(href string) string {
+	switch {
+	case strings.HasSuffix(href, ".html.images"), strings.HasSuffix(href, "-images.html"):
+		return formatHTML
+	case strings.HasSuffix(href, ".html.noimages"), strings.HasSuffix(href, ".html"):
+		return formatHTMLNoImages
+	case strings.HasSuffix(href, ".txt.utf-8"), strings.HasSuffix(href, ".txt"):
+		return formatText
+	case strings.Contains(href, ".epub"):
+		return formatEPUB
+	}
+	return ""
+}
+
+func gutenbergFormatURL(id, format string) string {
+	switch format {
+	case formatHTMLNoImages:
+		return fmt.Sprintf("https://www.gutenberg.org/cache/epub/%s/pg%s.html", id, id)
+	case formatText:
+		return plainTextURL(id)
+	case formatEPUB:
+		return fmt.Sprintf("https://www.gutenberg.org/cache/epub/%s/pg%s-images-3.epub", id, id)
+	}
+	return cacheEbookURL(id)
+}
+
+// gutenbergFormats lists the formats the book page offers, in its order.
+func gutenbergFormats(ctx context.Context, ebookURL string) ([]string, error) {
+	data, err := getCachedURL(ctx, featureDownload, ebookURL)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := xhtml.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var formats []string
+	seen := map[string]bool{}
+	var walk func(*xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if n.Type == xhtml.ElementNode && n.Data == "a" {
+			href, _ := attr(n, "href")
+			if format := formatOfLink(href); format != "" && !seen[format] && (strings.Contains(href, "/ebooks/") || strings.Contains(href, "/cache/epub/")) {
+				seen[format] = true
+				formats = append(formats, format)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return formats, nil
+}
+
+// fetchGutenbergFormat downloads a book in the given format, as HTML.
+func fetchGutenbergFormat(ctx context.Context, id, title, format string, progress progressFunc) ([]byte, error) {
+	resp, err := getURL(ctx, featureDownload, gutenbergFormatURL(id, format))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(trackProgress(resp, progress))
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case formatText:
+		return plainTextToHTML(title, string(decodeText(data, resp.Header.Get("Content-Type")))), nil
+	case formatEPUB:
+		return epubToHTML(data)
+	}
+	return data, nil
+}
+
+// formatPicker offers the formats of a Gutenberg book before downloading it.
+type formatPicker struct {
+	item    bookItem
+	formats []string
+	cursor  int
+}
+
+type formatsMsg struct {
+	item    bookItem
+	formats []string
+	err     error
+}
+
+func formatsCmd(item bookItem) loadCmd {
+	return func(ctx context.Context) tea.Msg {
+		formats, err := gutenbergFormats(ctx, normalizeEbookURL(item.result.URL))
+		return formatsMsg{item: item, formats: formats, err: err}
+	}
+}
+
+// chooseFormat starts a Gutenberg download in the format of the config,
+// asking first when it is set to ask.
+func (m *model) chooseFormat(item bookItem) (tea.Cmd, bool) {
+	if !isGutenberg(item.source) || item.result.Format != "" {
+		return nil, false
+	}
+	if m.config.DownloadFormat != formatAsk {
+		item.result.Format = m.config.DownloadFormat
+		return m.startDownload(item), true
+	}
+	return m.startLoading(tr("Looking up formats"), formatsCmd(item)), true
+}
+
+func (m model) updateFormats(msg formatsMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil || len(msg.formats) < 2 {
+		if msg.err != nil {
+			debugLog.Warn("formats not found, downloading the default", "url", msg.item.result.URL, "err", msg.err)
+		}
+		msg.item.result.Format = formatHTML
+		if len(msg.formats) == 1 {
+			msg.item.result.Format = msg.formats[0]
+		}
+		cmd := m.startDownload(msg.item)
+		return m, cmd
+	}
+	m.formats = &formatPicker{item: msg.item, formats: msg.formats}
+	return m, nil
+}
+
+func (m model) updateFormatPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.formats
+	switch msg.String() {
+	case "up", "k":
+		p.cursor = max(p.cursor-1, 0)
+	case "down", "j", "tab":
+		p.cursor = min(p.cursor+1, len(p.formats)-1)
+	case "enter", "a":
+		m.formats = nil
+		item := p.item
+		item.result.Format = p.formats[p.cursor]
+		cmd := m.startDownload(item)
+		if msg.String() == "a" {
+			m.config.DownloadFormat = item.result.Format
+			m.status = trf("Downloading %s from now on; change it in settings", formatLabel(item.result.Format))
+			return m, tea.Batch(cmd, saveConfigCmd(m.config))
+		}
+		return m, cmd
+	case "esc", "q":
+		m.formats = nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) formatPickerView() string {
+	p := m.formats
+	lines := []string{titleStyle().Render(tr("Choose a format")), "", p.item.result.Title, ""}
+	cursorStyle := lipgloss.NewStyle().Bold(true).Reverse(true)
+	for i, f := range p.formats {
+		line := " " + formatLabel(f) + " "
+		if i == p.cursor {
+			line = cursorStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", helpLine(tr("enter: download  a: always this format  ↑/↓: choose  esc: cancel")))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+const minTokenScore = 10
+
+type authorKey struct {
+	folded string
+	tokens []string
+}
+
+type authorMatch struct {
+	index int
+	score int
+}
+
+func buildAuthorKeys(authors []string) []authorKey {
+	keys := make([]authorKey, len(authors))
+	for i, name := range authors {
+		folded := foldString(name)
+		keys[i] = authorKey{folded: folded, tokens: tokenize(folded)}
+	}
+	return keys
+}
+
+func filterAuthors(catalog *authorCatalog, query string, limit int) []list.Item {
+	authors, keys := catalog.Names(), catalog.Keys()
+	folded := foldString(strings.TrimSpace(query))
+	queryTokens := tokenize(folded)
+	if len(queryTokens) == 0 {
+		return nil
+	}
+
+	matches := []authorMatch{}
+	strong := false
+	for i, key := range keys {
+		if score := scoreAuthor(key, folded, queryTokens); score > 0 {
+			matches = append(matches, authorMatch{index: i, score: score})
+			strong = strong || score >= minTokenScore
+		}
+	}
+	if strong {
+		kept := matches[:0]
+		for _, match := range matches {
+			if match.score >= minTokenScore {
+				kept = append(kept, match)
+			}
+		}
+		matches = kept
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return len(authors[matches[i].index]) < len(authors[matches[j].index])
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	items := make([]list.Item, 0, len(matches))
+	for _, match := range matches {
+		items = append(items, authorItem{name: authors[match.index]})
+	}
+	return items
+}
+
+func scoreAuthor(key authorKey, query string, queryTokens []string) int {
+	score := 0
+	for _, qt := range queryTokens {
+		best := 0
+		for i, nt := range key.tokens {
+			s := 0
+			switch {
+			case nt == qt:
+				s = 30
+			case strings.HasPrefix(nt, qt):
+				s = 20
+			case strings.Contains(nt, qt):
+				s = minTokenScore
+			}
+			if s > 0 && i == 0 {
+				s += 5
+			}
+			if s > best {
+				best = s
+			}
+		}
+		if best == 0 {
+			return subsequenceScore(key.folded, strings.Join(queryTokens, ""))
+		}
+		score += best
+	}
+	if strings.HasPrefix(key.folded, query) {
+		score += 50
+	}
+	return score
+}
+
+func subsequenceScore(name, query string) int {
+	target := []rune(query)
+	if len(target) == 0 {
+		return 0
+	}
+	qi := 0
+	gaps := 0
+	started := false
+	for _, r := range name {
+		if qi < len(target) && r == target[qi] {
+			qi++
+			started = true
+			continue
+		}
+		if started && qi < len(target) {
+			gaps++
+		}
+	}
+	if qi < len(target) {
+		return 0
+	}
+	score := minTokenScore - 1 - gaps/4
+	if score < 1 {
+		score = 1
+	}
+	return score
+}
+
+func tokenize(input string) []string {
+	return strings.FieldsFunc(input, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
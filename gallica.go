@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	gallicaSRUURL      = "https://gallica.bnf.fr/SRU"
+	maxGallicaResults  = 50
+	poorOCRQuality     = 80
+	gallicaTextSuffix  = ".texteBrut"
+	gallicaArkPrefix   = "https://gallica.bnf.fr/ark:/"
+	gallicaTextQuality = `ocr.quality all "Texte disponible"`
+)
+
+// gallicaSource searches the Bibliothèque nationale de France through the
+// Gallica SRU API. Many scans have no text layer, so searches only return
+// books with OCR text, and the result shows the mean OCR confidence.
+type gallicaSource struct{}
+
+// gallicaLanguages maps the two letter codes used in searches to the
+// MARC codes Gallica indexes.
+var gallicaLanguages = map[string]string{
+	"fr": "fre", "en": "eng", "de": "ger", "it": "ita", "es": "spa",
+	"la": "lat", "pt": "por", "nl": "dut", "oc": "oci", "br": "bre",
+}
+
+type gallicaRecord struct {
+	Titles      []string `xml:"recordData>dc>title"`
+	Creators    []string `xml:"recordData>dc>creator"`
+	Dates       []string `xml:"recordData>dc>date"`
+	Identifiers []string `xml:"recordData>dc>identifier"`
+	Quality     string   `xml:"extraRecordData>nqamoyen"`
+}
+
+type gallicaResponse struct {
+	Records []gallicaRecord `xml:"records>record"`
+}
+
+func (gallicaSource) Name() string { return "Gallica (BnF)" }
+
+func (gallicaSource) Search(ctx context.Context, query string) ([]bookResult, error) {
+	searchURL := gallicaSRUURL + "?" + url.Values{
+		"operation":      {"searchRetrieve"},
+		"version":        {"1.2"},
+		"maximumRecords": {strconv.Itoa(maxGallicaResults)},
+		"query":          {gallicaQuery(parseSearchQuery(query))},
+	}.Encode()
+	resp, err := getURL(ctx, featureSearch, searchURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var doc gallicaResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf(tr("parse gallica results: %w"), err)
+	}
+	var books []bookResult
+	for _, record := range doc.Records {
+		ark := gallicaArk(record.Identifiers)
+		if ark == "" || len(record.Titles) == 0 {
+			continue
+		}
+		books = append(books, bookResult{
+			Title:    compactSpaces(record.Titles[0]),
+			Subtitle: strings.Join(record.Creators, ", "),
+			Extra:    gallicaExtra(record),
+			URL:      ark,
+		})
+	}
+	return books, nil
+}
+
+func (gallicaSource) Download(ctx context.Context, result bookResult, outDir string, progress progressFunc) (string, error) {
+	resp, err := getURL(ctx, featureDownload, result.URL+gallicaTextSuffix)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	fileName := buildBookFileName(result.Subtitle, result.Title, result.URL)
+	entry := LibraryEntry{Title: result.Title, Author: result.Subtitle, Source: result.URL}
+	return storeBook(outDir, fileName, trackProgress(resp, progress), entry)
+}
+
+// gallicaQuery turns a search into CQL, limited to printed books that have
+// a text layer.
+func gallicaQuery(q searchQuery) string {
+	clauses := []string{}
+	for _, field := range []struct{ index, value string }{
+		{"gallica", strings.Join(q.Terms, " ")},
+		{"dc.creator", q.Author},
+		{"dc.title", q.Title},
+		{"dc.subject", q.Subject},
+		{"dc.language", gallicaLanguage(q.Language)},
+	} {
+		if field.value != "" {
+			clauses = append(clauses, fmt.Sprintf("%s all %q", field.index, field.value))
+		}
+	}
+	clauses = append(clauses, `dc.type all "monographie"`, gallicaTextQuality)
+	return strings.Join(clauses, " and ")
+}
+
+func gallicaLanguage(code string) string {
+	if marc, ok := gallicaLanguages[code]; ok {
+		return marc
+	}
+	return code
+}
+
+func gallicaArk(identifiers []string) string {
+	for _, id := range identifiers {
+		if id = strings.TrimSpace(id); strings.HasPrefix(id, gallicaArkPrefix) {
+			return id
+		}
+	}
+	return ""
+}
+
+// gallicaExtra summarises a record for the result list: its date and how
+// reliable the OCR text is.
+func gallicaExtra(record gallicaRecord) string {
+	parts := []string{}
+	if len(record.Dates) > 0 {
+		parts = append(parts, record.Dates[0])
+	}
+	quality, err := strconv.ParseFloat(strings.TrimSpace(record.Quality), 64)
+	switch {
+	case err != nil:
+		parts = append(parts, "OCR text")
+	case quality < poorOCRQuality:
+		parts = append(parts, fmt.Sprintf("OCR %.0f%% (poor)", quality))
+	default:
+		parts = append(parts, fmt.Sprintf("OCR %.0f%%", quality))
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	defaultDictionary = "default"
+	maxGlossWidth     = 16
+)
+
+// dictionary is a word list loaded from a tab separated file with one
+// "word<TAB>gloss" entry per line; lines starting with # are comments.
+type dictionary struct {
+	path    string
+	entries map[string]string
+	folded  map[string]string
+}
+
+type dictionaryLoadedMsg struct {
+	dict *dictionary
+	err  error
+}
+
+var dictionaryCache struct {
+	mu    sync.Mutex
+	dicts map[string]*dictionary
+}
+
+func loadDictionary(path string) (*dictionary, error) {
+	dictionaryCache.mu.Lock()
+	defer dictionaryCache.mu.Unlock()
+	if d, ok := dictionaryCache.dicts[path]; ok {
+		return d, nil
+	}
+
+	file, err := os.Open(expandHome(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	d := &dictionary{path: path, entries: make(map[string]string), folded: make(map[string]string)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		word, gloss, ok := strings.Cut(line, "\t")
+		word, gloss = strings.ToLower(strings.TrimSpace(word)), strings.TrimSpace(gloss)
+		if !ok || word == "" || gloss == "" {
+			continue
+		}
+		if prev, ok := d.entries[word]; ok {
+			gloss = prev + "; " + gloss
+		}
+		d.entries[word] = gloss
+		d.folded[foldString(word)] = gloss
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if dictionaryCache.dicts == nil {
+		dictionaryCache.dicts = make(map[string]*dictionary)
+	}
+	dictionaryCache.dicts[path] = d
+	return d, nil
+}
+
+func loadDictionaryCmd(path string) loadCmd {
+	return func(context.Context) tea.Msg {
+		d, err := loadDictionary(path)
+		return dictionaryLoadedMsg{dict: d, err: err}
+	}
+}
+
+// dictionaryFor picks the dictionary configured for a language, falling back
+// to the default entry of the [dictionaries] table.
+func dictionaryFor(dicts map[string]string, language string) string {
+	if path := dicts[language]; path != "" && language != "" {
+		return path
+	}
+	return dicts[defaultDictionary]
+}
+
+// trimWord drops the punctuation around a word.
+func trimWord(word string) string {
+	return strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func (d *dictionary) lookup(word string) string {
+	word = strings.ToLower(trimWord(word))
+	if word == "" {
+		return ""
+	}
+	if gloss, ok := d.entries[word]; ok {
+		return gloss
+	}
+	return d.folded[foldString(word)]
+}
+
+// glossLanguage is the language of the chapter being read, or of the book.
+func (m model) glossLanguage() string {
+	if index := chapterForPage(m.currentBook, m.state.Page); index >= 0 && m.currentBook.Chapters[index].Language != "" {
+		return m.currentBook.Chapters[index].Language
+	}
+	return m.currentBook.Language
+}
+
+func (m *model) toggleGloss() tea.Cmd {
+	if m.glossing {
+		m.glossing = false
+		return nil
+	}
+	language := m.glossLanguage()
+	path := dictionaryFor(m.config.Dictionaries, language)
+	if path == "" {
+		m.status = trf("No dictionary for %s: add one to the [dictionaries] table of the config", languageName(language))
+		return nil
+	}
+	if m.dict != nil && m.dict.path == path {
+		m.glossing = true
+		m.glossIndex, m.glossWord = 0, 0
+		return nil
+	}
+	return m.startLoading(tr("Loading dictionary"), loadDictionaryCmd(path))
+}
+
+func (m model) updateDictionaryLoaded(msg dictionaryLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.status = msg.err.Error()
+		return m, nil
+	}
+	m.status = ""
+	m.dict = msg.dict
+	m.glossing = true
+	m.glossIndex, m.glossWord = 0, 0
+	return m, nil
+}
+
+func pageParagraphs(page string) []string {
+	var paras []string
+	for _, p := range strings.Split(stripStyles(page), paragraphBreak) {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			paras = append(paras, p)
+		}
+	}
+	return paras
+}
+
+func (m *model) moveGloss(delta int) {
+	paras := pageParagraphs(m.currentBook.Page(m.state.Page))
+	if len(paras) == 0 {
+		return
+	}
+	m.glossIndex = (m.glossIndex + delta + len(paras)) % len(paras)
+	m.glossWord = 0
+}
+
+// moveGlossWord picks the next or previous word of the glossed paragraph,
+// the one the look up action looks up.
+func (m *model) moveGlossWord(delta int) {
+	paras := pageParagraphs(m.currentBook.Page(m.state.Page))
+	if len(paras) == 0 {
+		return
+	}
+	words := len(strings.Fields(paras[min(m.glossIndex, len(paras)-1)]))
+	m.glossWord = (min(m.glossWord, words-1) + delta + words) % words
+}
+
+// interlinear lays out a paragraph word by word with each word's gloss
+// printed beneath it, wrapping both lines together at width. The word at
+// cursor is highlighted.
+func interlinear(para string, d *dictionary, width, cursor int) string {
+	var out []string
+	var words, glosses []string
+	used := 0
+	flush := func() {
+		if len(words) > 0 {
+			out = append(out, strings.Join(words, " "), metaStyle().Render(strings.Join(glosses, " ")), "")
+		}
+		words, glosses, used = nil, nil, 0
+	}
+	for i, word := range strings.Fields(para) {
+		gloss := truncateVisible(d.lookup(word), maxGlossWidth)
+		cell := max(runewidth.StringWidth(word), runewidth.StringWidth(gloss))
+		if used > 0 && used+1+cell > width {
+			flush()
+		}
+		padded := runewidth.FillRight(word, cell)
+		if i == cursor {
+			padded = lipgloss.NewStyle().Reverse(true).Render(word) + strings.Repeat(" ", cell-runewidth.StringWidth(word))
+		}
+		words = append(words, padded)
+		glosses = append(glosses, runewidth.FillRight(gloss, cell))
+		used += cell + 1
+	}
+	flush()
+	return strings.TrimSuffix(strings.Join(out, "\n"), "\n")
+}
+
+func (m model) glossPage(page string, width int) string {
+	paras := pageParagraphs(page)
+	if !m.glossing || m.dict == nil || len(paras) == 0 {
+		return page
+	}
+	index := min(m.glossIndex, len(paras)-1)
+	header := metaStyle().Render(trf("Paragraph %d/%d", index+1, len(paras)) + " · " + m.keys.hint(modeReader, actionNextParagraph, actionPrevParagraph, actionGloss))
+	words := metaStyle().Render(m.keys.hint(modeReader, actionPrevWord, actionNextWord, actionLookUp))
+	return header + "\n" + words + "\n\n" + interlinear(paras[index], m.dict, width, m.glossWord)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// maxPageTime caps the time counted for one page, so a book left open
+	// does not count as reading.
+	maxPageTime = 5 * time.Minute
+	// readingLogDays is how many days of the reading log are kept.
+	readingLogDays = 400
+)
+
+// ReadingDay is one day of the reading log: pages turned forward and the
+// time spent on them.
+type ReadingDay struct {
+	Pages   int `json:"pages"`
+	Seconds int `json:"seconds"`
+}
+
+func (d ReadingDay) minutes() int {
+	return d.Seconds / 60
+}
+
+func (cfg Config) hasGoal() bool {
+	return cfg.GoalPages > 0 || cfg.GoalMinutes > 0
+}
+
+// goalMet reports whether a day reached every goal that is set.
+func (cfg Config) goalMet(d ReadingDay) bool {
+	return cfg.hasGoal() && d.Pages >= cfg.GoalPages && d.minutes() >= cfg.GoalMinutes
+}
+
+// logReading adds a page read at now to the reading log, counting the time
+// since the previous page, and nudges once the daily goal is reached.
+func (m *model) logReading(now time.Time) tea.Cmd {
+	if m.state.Reading == nil {
+		m.state.Reading = make(map[string]ReadingDay)
+	}
+	today := now.Format(scheduleDateLayout)
+	day := m.state.Reading[today]
+	before := m.config.goalMet(day)
+	day.Pages++
+	seconds := 0
+	if !m.lastTurn.IsZero() {
+		seconds = int(min(now.Sub(m.lastTurn), maxPageTime).Seconds())
+	}
+	day.Seconds += seconds
+	m.lastTurn = now
+	m.state.Reading[today] = day
+	m.state.logBookTime(m.state.CurrentBook, today, seconds)
+	var cue tea.Cmd
+	if !before && m.config.goalMet(day) {
+		streak := readingStreak(m.config, m.state.Reading, now)
+		m.status = trf("Daily goal reached · %d-day streak", streak)
+		cue = m.notify()
+	}
+	if len(m.state.Reading) > readingLogDays {
+		oldest := now.AddDate(0, 0, -readingLogDays).Format(scheduleDateLayout)
+		for date := range m.state.Reading {
+			if date < oldest {
+				delete(m.state.Reading, date)
+			}
+		}
+	}
+	return cue
+}
+
+// readingStreak counts the days in a row that met the goal, up to today, or
+// up to yesterday while today's goal is still open.
+func readingStreak(cfg Config, log map[string]ReadingDay, now time.Time) int {
+	day := now
+	if !cfg.goalMet(log[day.Format(scheduleDateLayout)]) {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for cfg.goalMet(log[day.Format(scheduleDateLayout)]) {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// goalProgress describes today's progress toward the goal and the streak,
+// for the library title.
+func goalProgress(cfg Config, log map[string]ReadingDay, now time.Time) string {
+	if !cfg.hasGoal() {
+		return ""
+	}
+	today := log[now.Format(scheduleDateLayout)]
+	var parts []string
+	if cfg.GoalPages > 0 {
+		parts = append(parts, trf("%d/%d pages", min(today.Pages, cfg.GoalPages), cfg.GoalPages))
+	}
+	if cfg.GoalMinutes > 0 {
+		parts = append(parts, trf("%d/%d min", min(today.minutes(), cfg.GoalMinutes), cfg.GoalMinutes))
+	}
+	status := trf("today %s", strings.Join(parts, ", "))
+	if cfg.goalMet(today) {
+		status = tr("today's goal met ✓")
+	}
+	if streak := readingStreak(cfg, log, now); streak > 0 {
+		status += trf(" · %d-day streak", streak)
+	}
+	return status
+}
@@ -2,76 +2,148 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"html"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	xhtml "golang.org/x/net/html"
-
-	"github.com/mattn/go-runewidth"
 )
 
 const (
 	pageLineCount  = 25
 	pageLineWidth  = 80
 	paragraphBreak = "\n\n"
+	// pagePadding is the margin left of the reader's page and progress bar.
+	pagePadding = 2
+
+	gutenbergPageSize = 25
 )
 
 type Chapter struct {
 	Title     string
 	Text      string
 	StartPage int
+	Pages     int
+	Language  string
+	Words     int
+	offset    int64
+	size      int64
 }
 
 type Book struct {
 	Title    string
 	Chapters []Chapter
-	Pages    []string
+	Words    int
+	Language string
+	text     *bookText
 }
 
 type State struct {
-	CurrentBook string         `json:"current_book,omitempty"`
-	Pages       map[string]int `json:"pages,omitempty"`
-	Page        int            `json:"page"`
+	CurrentBook  string                `json:"current_book,omitempty"`
+	Pages        map[string]int        `json:"pages,omitempty"`
+	Page         int                   `json:"page"`
+	Translations map[string]string     `json:"translations,omitempty"`
+	ReadChapters map[string][]int      `json:"read_chapters,omitempty"`
+	Schedules    map[string]Schedule   `json:"schedules,omitempty"`
+	PrintPages   map[string]int        `json:"print_pages,omitempty"`
+	Reading      map[string]ReadingDay `json:"reading,omitempty"`
+	Books        map[string]BookRecord `json:"books,omitempty"`
 }
 
 type Config struct {
-	BooksDir  string
-	StateFile string
+	Path            string
+	Profile         string
+	BooksDir        string
+	ExportDir       string
+	StateFile       string
+	StateStore      string
+	StateURL        string
+	CacheDir        string
+	AuditFile       string
+	LogFile         string
+	LogLevel        string
+	AuthorsFile     string
+	PDFToText       string
+	SQLite          string
+	TTSCommand      string
+	Theme           string
+	Colors          string
+	Language        string
+	UILanguage      string
+	DownloadFormat  string
+	Keymap          string
+	WPM             int
+	AutoTurn        int
+	GoalPages       int
+	GoalMinutes     int
+	Header          string
+	StatusBar       string
+	Footer          string
+	PageTransition  string
+	LargePrint      bool
+	ProgressBar     bool
+	Mouse           bool
+	Notify          string
+	Quiet           bool
+	Offline         bool
+	HideAdult       bool
+	BlockedSubjects []string
+	Keys            map[string]string
+	OPDSFeeds       []OPDSFeed
+	SMTP            SMTPConfig
+	Privacy         map[string]bool
+	Filters         FilterConfig
+	Dictionaries    map[string]string
+}
+
+type OPDSFeed struct {
+	Name string
+	URL  string
 }
 
+var ebookIDRe = regexp.MustCompile(`/ebooks/(\d+)`)
+
 type bookResult struct {
 	Title    string
 	URL      string
 	Subtitle string
 	Extra    string
+	Format   string
+	Feed     bool
+	// Subjects are the subjects or categories the source lists, checked by
+	// the content filter.
+	Subjects []string
 }
 
-func fetchBooks(query string) ([]bookResult, error) {
+func fetchBooks(ctx context.Context, query string, page int) ([]bookResult, error) {
 	searchURL := "https://www.gutenberg.org/ebooks/search/?query=" + url.QueryEscape(query)
-	req, err := http.NewRequest(http.MethodGet, searchURL, nil)
-	if err != nil {
-		return nil, err
+	if page > 0 {
+		searchURL += fmt.Sprintf("&start_index=%d", page*gutenbergPageSize+1)
 	}
-	req.Header.Set("User-Agent", "gutberg-cli/1.0")
+	return fetchBookLinks(ctx, searchURL)
+}
 
-	resp, err := http.DefaultClient.Do(req)
+// fetchBookLinks reads the books listed on a Gutenberg results page, as
+// searches and bookshelves show them.
+func fetchBookLinks(ctx context.Context, pageURL string) ([]bookResult, error) {
+	data, err := getCachedURL(ctx, featureSearch, pageURL)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
-	}
 
-	root, err := xhtml.Parse(resp.Body)
+	root, err := xhtml.Parse(bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
@@ -121,69 +193,100 @@ func findSpanText(n *xhtml.Node, class string) string {
 	return out
 }
 
-func downloadBookHTML(idOrURL, author, title, outDir string) (string, error) {
+// downloadBookHTML downloads a Gutenberg book into the library. With no
+// format it takes the HTML edition, or the plain text one when that reads
+// better.
+func downloadBookHTML(ctx context.Context, idOrURL, author, title, format, outDir string, progress progressFunc) (string, error) {
 	ebookURL := normalizeEbookURL(idOrURL)
-	req, err := http.NewRequest(http.MethodGet, ebookURL, nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("User-Agent", "gutberg-cli/1.0")
+	id := ebookIDFromURL(ebookURL)
 
-	resp, err := http.DefaultClient.Do(req)
+	lib, err := loadLibrary(outDir)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	if id != "" {
+		if path, ok := lib.pathForID(outDir, id); ok {
+			return path, nil
+		}
 	}
 
-	root, err := xhtml.Parse(resp.Body)
-	if err != nil {
-		return "", err
+	if format != "" && id != "" {
+		data, err := fetchGutenbergFormat(ctx, id, title, format, progress)
+		if err != nil {
+			return "", err
+		}
+		edition := editionHTML
+		if format == formatText {
+			edition = editionText
+		}
+		href := gutenbergFormatURL(id, format)
+		entry := LibraryEntry{ID: id, Title: title, Author: author, Source: ebookURL, Edition: edition}
+		return storeBook(outDir, buildBookFileName(author, title, href), bytes.NewReader(data), entry)
 	}
 
-	readNowURL := findReadNowURL(root)
-	if readNowURL == "" {
-		return "", fmt.Errorf("read online link not found")
+	var resp *http.Response
+	href := ""
+	if id != "" {
+		href = cacheEbookURL(id)
+		resp, err = getURL(ctx, featureDownload, href)
 	}
-
-	fullURL := "https://www.gutenberg.org" + readNowURL
-	req, err = http.NewRequest(http.MethodGet, fullURL, nil)
-	if err != nil {
-		return "", err
+	if resp == nil {
+		href, err = scrapeReadNowURL(ctx, ebookURL)
+		if err != nil {
+			return "", err
+		}
+		resp, err = getURL(ctx, featureDownload, href)
+		if err != nil {
+			return "", err
+		}
 	}
-	req.Header.Set("User-Agent", "gutberg-cli/1.0")
+	defer resp.Body.Close()
 
-	resp, err = http.DefaultClient.Do(req)
+	data, err := io.ReadAll(trackProgress(resp, progress))
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	edition := editionHTML
+	if id != "" && len(extractChaptersFromHTML(data, presetGutenbergHTML)) == 0 {
+		if text, err := fetchPlainTextEdition(ctx, id, title); err == nil && betterExtraction(text, data) {
+			data = text
+			edition = editionText
+		}
 	}
 
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
+	fileName := buildBookFileName(author, title, href)
+	entry := LibraryEntry{ID: id, Title: title, Author: author, Source: ebookURL, Edition: edition}
+	return storeBook(outDir, fileName, bytes.NewReader(data), entry)
+}
+
+func scrapeReadNowURL(ctx context.Context, ebookURL string) (string, error) {
+	data, err := getCachedURL(ctx, featureDownload, ebookURL)
+	if err != nil {
 		return "", err
 	}
 
-	fileName := buildBookFileName(author, title, readNowURL)
-	if fileName == "" {
-		fileName = "book.html"
-	}
-	outPath := filepath.Join(outDir, fileName)
-	outFile, err := os.Create(outPath)
+	root, err := xhtml.Parse(bytes.NewReader(data))
 	if err != nil {
 		return "", err
 	}
-	defer outFile.Close()
 
-	if _, err := io.Copy(outFile, resp.Body); err != nil {
-		return "", err
+	readNowURL := findReadNowURL(root)
+	if readNowURL == "" {
+		return "", errors.New(tr("read online link not found"))
 	}
+	return "https://www.gutenberg.org" + readNowURL, nil
+}
 
-	return outPath, nil
+func ebookIDFromURL(ebookURL string) string {
+	m := ebookIDRe.FindStringSubmatch(ebookURL)
+	if len(m) < 2 {
+		return ""
+	}
+	return m[1]
+}
+
+func cacheEbookURL(id string) string {
+	return fmt.Sprintf("https://www.gutenberg.org/cache/epub/%s/pg%s-images.html", id, id)
 }
 
 func normalizeEbookURL(idOrURL string) string {
@@ -335,65 +438,109 @@ func loadBookFromHTML(path string, width, lines int) (Book, error) {
 	if err != nil {
 		return Book{}, err
 	}
+	preset := defaultPreset()
+	var entry LibraryEntry
+	if lib, err := loadLibrary(filepath.Dir(path)); err == nil {
+		entry = lib.Books[filepath.Base(path)]
+		preset = presetForEntry(entry)
+	}
+	// The library's language, then the one the file declares, win over
+	// the one detected from the text.
+	declared := entry.Language
+	if declared == "" {
+		declared = declaredLanguage(data)
+	}
+	key := parsedBookKey(data, preset)
+	if book, parsed, ok := loadParsedBook(path, key); ok {
+		if declared != "" {
+			book.Language = declared
+		}
+		if counts, ok := parsed.Layouts[layoutKey(width, lines)]; ok && len(counts) == len(book.Chapters) {
+			book.setLayout(width, lines, counts)
+		} else {
+			book.layoutPages(width, lines)
+			if err := storeParsedBook(path, key, book, parsed.Layouts); err != nil {
+				debugLog.Warn("parsed book not cached", "path", path, "err", err)
+			}
+		}
+		debugLog.Info("book loaded from cache", "path", path, "preset", preset, "chapters", len(book.Chapters), "pages", book.PageCount())
+		return book, nil
+	}
+	data = decodeText(data, "text/html")
 
 	title := extractTitle(data)
 	if title == "" {
 		title = "Untitled"
 	}
-
-	chapters := extractChaptersFromHTML(data)
+	chapters := extractChaptersFromHTML(data, preset)
 	if len(chapters) == 0 {
-		text := cleanHTMLToText(string(data))
+		debugLog.Debug("no chapter headings, reading as one chapter", "path", path)
+		text := cleanHTMLToText(string(data), preset)
 		chapters = []Chapter{{Title: title, Text: text, StartPage: 0}}
 	}
-	pages, chapters := buildBookPagesForSize(Book{Title: title, Chapters: chapters}, width, lines)
-
-	return Book{Title: title, Chapters: chapters, Pages: pages}, nil
+	forEachChapter(len(chapters), func(i int) {
+		chapters[i].Language = detectLanguage(chapters[i].Text)
+		chapters[i].Words = len(strings.Fields(chapters[i].Text))
+	})
+	words := 0
+	for _, ch := range chapters {
+		words += ch.Words
+	}
+	book := Book{Title: title, Chapters: chapters, Words: words, Language: bookLanguage(chapters)}
+	if declared != "" {
+		book.Language = declared
+	}
+	name, err := storeChapterText(path, chapters)
+	if err != nil {
+		return Book{}, fmt.Errorf(tr("store book text: %w"), err)
+	}
+	book.text = &bookText{path: name}
+	book.layoutPages(width, lines)
+	if err := storeParsedBook(path, key, book, nil); err != nil {
+		debugLog.Warn("parsed book not cached", "path", path, "err", err)
+	}
+	debugLog.Info("book loaded", "path", path, "preset", preset, "chapters", len(chapters), "words", words, "language", book.Language, "pages", book.PageCount())
+	return book, nil
 }
 
 func extractTitle(data []byte) string {
-	re := regexp.MustCompile(`(?is)<title>(.*?)</title>`)
-	m := re.FindSubmatch(data)
-	if len(m) < 2 {
-		return ""
-	}
-	return strings.TrimSpace(html.UnescapeString(stripTags(string(m[1]))))
+	return compactSpaces(textContent(findElement(parseHTML(data), "title")))
 }
 
-func extractChaptersFromHTML(data []byte) []Chapter {
-	re := regexp.MustCompile(`(?is)<h[1-3][^>]*>(.*?)</h[1-3]>`)
-	matches := re.FindAllSubmatchIndex(data, -1)
-	if len(matches) == 0 {
-		return nil
-	}
-
-	chapters := make([]Chapter, 0, len(matches))
-	for i, m := range matches {
-		title := cleanInlineText(string(data[m[2]:m[3]]))
-		start := m[1]
-		end := len(data)
-		if i+1 < len(matches) {
-			end = matches[i+1][0]
+func extractChaptersFromHTML(data []byte, preset string) []Chapter {
+	strip := stripsBoilerplate(preset)
+	sections := renderHTMLSections(parseHTML(data), true, strip)[1:]
+	// Sections are cleaned in parallel, the ones after the end of the text
+	// too, and then picked in order.
+	texts := make([]string, len(sections))
+	forEachChapter(len(sections), func(i int) {
+		texts[i] = cleanRenderedText(sections[i].text.String(), preset)
+	})
+	chapters := make([]Chapter, 0, len(sections))
+	for i, section := range sections {
+		if strip && transcriberNoteRe.MatchString(section.title) {
+			debugLog.Debug("skipped transcriber's note section", "title", section.title)
+			continue
 		}
-		chunk := string(data[start:end])
-		text := cleanHTMLToText(chunk)
+		raw := section.text.String()
+		text := texts[i]
 		if strings.TrimSpace(text) == "" {
+			debugLog.Debug("skipped empty section", "title", section.title)
 			continue
 		}
-		chapters = append(chapters, Chapter{Title: title, Text: text})
+		chapters = append(chapters, Chapter{Title: section.title, Text: text})
+		if strip && textEndRe.MatchString(raw) {
+			debugLog.Debug("end of text marker, ignoring later sections", "title", section.title)
+			break
+		}
 	}
 	if len(chapters) <= 1 {
+		debugLog.Debug("too few chapter sections", "sections", len(sections), "chapters", len(chapters))
 		return nil
 	}
 	return chapters
 }
 
-func cleanInlineText(input string) string {
-	text := stripTags(input)
-	text = html.UnescapeString(text)
-	return strings.TrimSpace(text)
-}
-
 func loadAuthorsFromEmbedded(data string) ([]string, error) {
 	var authors []string
 	scanner := bufio.NewScanner(strings.NewReader(data))
@@ -410,98 +557,70 @@ func loadAuthorsFromEmbedded(data string) ([]string, error) {
 	return authors, nil
 }
 
-func buildBookPagesForSize(book Book, width, lines int) ([]string, []Chapter) {
-	pages := []string{}
-	chapters := book.Chapters
-	if width < 20 {
-		width = 20
-	}
-	if lines < 5 {
-		lines = 5
-	}
-	for i := range chapters {
-		chapters[i].StartPage = len(pages)
-		header := fmt.Sprintf("%s\n\n", chapters[i].Title)
-		text := strings.TrimSpace(header + chapters[i].Text)
-		chapterPages := paginate(text, lines, width)
-		pages = append(pages, chapterPages...)
-	}
-	return pages, chapters
-}
-
-func cleanHTMLToText(input string) string {
-	normalized := strings.ReplaceAll(input, "\r\n", "\n")
-	normalized = strings.ReplaceAll(normalized, "\r", "\n")
-
-	normalized = stripHTMLSection(normalized, `(?is)<style[^>]*>.*?</style>`)
-	normalized = stripHTMLSection(normalized, `(?is)<div\\s+id=\"pg-header\".*?</div>`)
-	normalized = stripHTMLSection(normalized, `(?is)<div\\s+id=\"pg-footer\".*?</div>`)
-
-	normalized = replaceAllTag(normalized, "br", "\n")
-	normalized = replaceAllTag(normalized, "/p", paragraphBreak)
-	normalized = replaceAllTag(normalized, "p", "")
-	normalized = replaceAllTag(normalized, "hr", "\n")
-
-	text := stripTags(normalized)
-	text = html.UnescapeString(text)
-	text = normalizeWhitespace(text)
-	text = stripGutenbergBoilerplate(text)
-	return text
+func cleanHTMLToText(input, preset string) string {
+	sections := renderHTMLSections(parseHTML([]byte(input)), false, stripsBoilerplate(preset))
+	return cleanRenderedText(sections[0].text.String(), preset)
 }
 
-func stripHTMLSection(input, pattern string) string {
-	re := regexp.MustCompile(pattern)
-	return re.ReplaceAllString(input, "")
+func cleanRenderedText(text, preset string) string {
+	return applyFilters(normalizeWhitespace(text), preset)
 }
 
+// stripGutenbergBoilerplate cuts the text down to what lies between the
+// START and END markers, dropping the legal header, the license trailer and
+// any transcriber's notes.
 func stripGutenbergBoilerplate(text string) string {
-	if text == "" {
+	if text == "" || keepBoilerplate() {
 		return text
 	}
 
-	startRe := regexp.MustCompile(`(?i)\\*\\*\\*\\s*START OF THE PROJECT GUTENBERG.*?\\*\\*\\*`)
-	if loc := startRe.FindStringIndex(text); loc != nil {
-		text = text[loc[1]:]
+	if loc := textStartRe.FindStringIndex(text); loc != nil {
+		text = text[lineEnd(text, loc[1]):]
+	} else if loc := smallPrintEndRe.FindStringIndex(text); loc != nil {
+		debugLog.Debug("no START marker, cutting after the small print")
+		text = text[lineEnd(text, loc[1]):]
 	}
-
-	endRe := regexp.MustCompile(`(?i)\\*\\*\\*\\s*END OF THE PROJECT GUTENBERG.*?\\*\\*\\*`)
-	if loc := endRe.FindStringIndex(text); loc != nil {
-		text = text[:loc[0]]
+	if loc := textEndRe.FindStringIndex(text); loc != nil {
+		text = text[:strings.LastIndex(text[:loc[0]], "\n")+1]
 	}
 
-	headerRe := regexp.MustCompile(`(?m)^The Project Gutenberg eBook of.*$`)
-	text = headerRe.ReplaceAllString(text, "")
-	text = normalizeWhitespace(text)
-	return text
+	text = gutenbergLineRe.ReplaceAllString(text, "")
+	text = stripTranscriberNotes(text)
+	return normalizeWhitespace(text)
 }
 
-func replaceAllTag(input, tag, replacement string) string {
-	re := regexp.MustCompile(`(?i)<\s*` + regexp.QuoteMeta(tag) + `\b[^>]*>`)
-	return re.ReplaceAllString(input, replacement)
+func lineEnd(text string, i int) int {
+	if end := strings.IndexByte(text[i:], '\n'); end >= 0 {
+		return i + end
+	}
+	return len(text)
 }
 
-func stripTags(input string) string {
-	var b strings.Builder
-	b.Grow(len(input))
-	inTag := false
-	for _, r := range input {
-		switch r {
-		case '<':
-			inTag = true
-		case '>':
-			inTag = false
-		default:
-			if !inTag {
-				b.WriteRune(r)
-			}
+// stripTranscriberNotes drops paragraphs that open with "Transcriber's
+// Note"; a paragraph holding only that heading takes the next one with it.
+func stripTranscriberNotes(text string) string {
+	paras := strings.Split(text, paragraphBreak)
+	kept := paras[:0]
+	for i := 0; i < len(paras); i++ {
+		plain := strings.TrimSpace(strings.ReplaceAll(stripStyles(paras[i]), verseMark, ""))
+		if !transcriberNoteRe.MatchString(plain) {
+			kept = append(kept, paras[i])
+			continue
+		}
+		if len(transcriberNoteRe.ReplaceAllString(plain, "")) <= 2 {
+			i++
 		}
 	}
-	return b.String()
+	return strings.Join(kept, paragraphBreak)
 }
 
 func normalizeWhitespace(input string) string {
 	lines := strings.Split(input, "\n")
 	for i, line := range lines {
+		if isVerseLine(line) {
+			lines[i] = strings.TrimRight(line, " \t")
+			continue
+		}
 		lines[i] = strings.TrimSpace(compactSpaces(line))
 	}
 	output := strings.Join(lines, "\n")
@@ -542,42 +661,43 @@ func wrapText(text string, width int) string {
 	parts := strings.Split(text, paragraphBreak)
 	var out []string
 	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p == "" {
+		p = strings.Trim(p, "\n")
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		if strings.Contains(p, verseMark) {
+			out = append(out, balanceStyles(wrapVerse(p, width)))
 			continue
 		}
-		out = append(out, wrapParagraph(p, width))
+		out = append(out, balanceStyles(wrapParagraph(p, width)))
 	}
 	return strings.Join(out, paragraphBreak)
 }
 
 func wrapParagraph(text string, width int) string {
-	words := strings.Fields(text)
-	if len(words) == 0 {
+	tokens := wrapTokens(text)
+	if len(tokens) == 0 {
 		return ""
 	}
 
-	var b strings.Builder
-	lineLen := 0
-	for _, w := range words {
-		wordWidth := runewidth.StringWidth(w)
-		if lineLen == 0 {
-			b.WriteString(w)
-			lineLen = wordWidth
+	var lines []string
+	var line []wrapToken
+	for _, t := range tokens {
+		if len(line) == 0 || tokensWidth(append(line, t)) <= width {
+			line = append(line, t)
 			continue
 		}
-		if lineLen+1+wordWidth > width {
-			b.WriteByte('\n')
-			b.WriteString(w)
-			lineLen = wordWidth
-			continue
+		var carry []wrapToken
+		last := line[len(line)-1]
+		if len(line) > 1 && ((!t.space && kinsokuRune(t.text, noLineStart, false)) || kinsokuRune(last.text, noLineEnd, true)) {
+			carry = []wrapToken{last}
+			line = line[:len(line)-1]
 		}
-		b.WriteByte(' ')
-		b.WriteString(w)
-		lineLen += 1 + wordWidth
+		lines = append(lines, joinTokens(line))
+		line = append(carry, t)
 	}
-
-	return b.String()
+	lines = append(lines, joinTokens(line))
+	return strings.Join(lines, "\n")
 }
 
 func loadState(path string) (State, error) {
@@ -605,35 +725,62 @@ func loadState(path string) (State, error) {
 	return state, nil
 }
 
-func loadConfig() (Config, error) {
-	configDir, err := defaultConfigDir()
+func loadConfig(profile string) (Config, error) {
+	dirs, err := defaultDirs(profile)
 	if err != nil {
 		return Config{}, err
 	}
-	if err := os.MkdirAll(configDir, 0o755); err != nil {
-		return Config{}, err
+	for _, dir := range []string{dirs.config, dirs.data, dirs.cache} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return Config{}, err
+		}
 	}
 
+	configPath := filepath.Join(dirs.config, "gutberg.toml")
 	defaultCfg := Config{
-		BooksDir:  filepath.Join(configDir, "books"),
-		StateFile: filepath.Join(configDir, "state.json"),
+		Path:           configPath,
+		Profile:        profile,
+		BooksDir:       filepath.Join(dirs.data, "books"),
+		ExportDir:      defaultExportDir(),
+		StateFile:      filepath.Join(dirs.data, "state.json"),
+		StateStore:     storeFile,
+		CacheDir:       dirs.cache,
+		AuditFile:      filepath.Join(dirs.data, "requests.log"),
+		LogFile:        filepath.Join(dirs.data, "debug.log"),
+		LogLevel:       logLevelOff,
+		PDFToText:      defaultPDFToText,
+		SQLite:         defaultSQLite,
+		Theme:          defaultThemeName,
+		Colors:         "auto",
+		UILanguage:     uiAuto,
+		DownloadFormat: formatAsk,
+		Keymap:         defaultKeymapProfile,
+		WPM:            defaultWPM,
+		Header:         defaultHeader,
+		StatusBar:      defaultStatusBar,
+		Footer:         defaultFooter,
+		PageTransition: transitionNone,
+		ProgressBar:    true,
+		Notify:         notifyBell,
+		Privacy:        defaultPrivacy(),
+		Keys:           make(map[string]string),
+		Filters:        FilterConfig{Default: presetGutenbergHTML, Sources: map[string]string{"runeberg.org": presetOCRText, "gallica.bnf.fr": presetOCRText}},
+		Dictionaries:   make(map[string]string),
 	}
 
-	configPath := filepath.Join(configDir, "gutberg.toml")
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		if err := writeConfig(configPath, defaultCfg); err != nil {
 			return Config{}, err
 		}
 	} else if err == nil {
-		loaded, err := readConfig(configPath)
+		defaultCfg, err = readConfig(configPath, defaultCfg)
 		if err != nil {
 			return Config{}, err
 		}
-		if loaded.BooksDir != "" {
-			defaultCfg.BooksDir = loaded.BooksDir
-		}
-		if loaded.StateFile != "" {
-			defaultCfg.StateFile = loaded.StateFile
+		if migrateLegacyDirs(&defaultCfg, dirs) {
+			if err := writeConfig(configPath, defaultCfg); err != nil {
+				return Config{}, err
+			}
 		}
 	}
 
@@ -643,50 +790,445 @@ func loadConfig() (Config, error) {
 	return defaultCfg, nil
 }
 
-func defaultConfigDir() (string, error) {
-	base, err := os.UserConfigDir()
-	if err != nil {
-		return "", err
+// writeConfig saves the config over the file at path, changing only the
+// keys gutberg writes: comments, blank lines and keys it doesn't know stay
+// where they are. The file may hold the SMTP password, so only its owner
+// may read it.
+func writeConfig(path string, cfg Config) error {
+	old, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	data := mergeConfig(string(old), configSections(cfg))
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if err := os.WriteFile(tmp, []byte(data), 0o600); err != nil {
+		return err
 	}
-	return filepath.Join(base, "gutberg"), nil
+	return os.Rename(tmp, path)
 }
 
-func writeConfig(path string, cfg Config) error {
-	file, err := os.Create(path)
-	if err != nil {
-		return err
+// configSection is a table of the config file with its keys, in the order
+// they are written; array tables, such as [[opds]], come once per entry.
+// The keys of a complete section, filled from a map or a list, are all it
+// has: others found in the file were removed and are dropped.
+type configSection struct {
+	name     string
+	array    bool
+	complete bool
+	keys     []configKey
+}
+
+type configKey struct {
+	name  string
+	value string
+}
+
+func (s configSection) header() string {
+	if s.array {
+		return "[[" + s.name + "]]"
 	}
-	defer file.Close()
-	_, err = fmt.Fprintf(file, "books_dir = %q\nstate_file = %q\n", cfg.BooksDir, cfg.StateFile)
-	return err
+	return "[" + s.name + "]"
 }
 
-func readConfig(path string) (Config, error) {
+func configSections(cfg Config) []configSection {
+	q := strconv.Quote
+	top := configSection{keys: []configKey{
+		{"books_dir", q(cfg.BooksDir)},
+		{"export_dir", q(cfg.ExportDir)},
+		{"state_file", q(cfg.StateFile)},
+		{"state_store", q(cfg.StateStore)},
+		{"state_url", q(cfg.StateURL)},
+		{"cache_dir", q(cfg.CacheDir)},
+		{"audit_file", q(cfg.AuditFile)},
+		{"log_file", q(cfg.LogFile)},
+		{"log_level", q(cfg.LogLevel)},
+		{"authors_file", q(cfg.AuthorsFile)},
+		{"pdftotext", q(cfg.PDFToText)},
+		{"sqlite3", q(cfg.SQLite)},
+		{"tts_command", q(cfg.TTSCommand)},
+		{"theme", q(cfg.Theme)},
+		{"colors", q(cfg.Colors)},
+		{"language", q(cfg.Language)},
+		{"ui_language", q(cfg.UILanguage)},
+		{"download_format", q(cfg.DownloadFormat)},
+		{"keymap", q(cfg.Keymap)},
+		{"wpm", strconv.Itoa(cfg.WPM)},
+		{"auto_turn", strconv.Itoa(cfg.AutoTurn)},
+		{"goal_pages", strconv.Itoa(cfg.GoalPages)},
+		{"goal_minutes", strconv.Itoa(cfg.GoalMinutes)},
+		{"header", q(cfg.Header)},
+		{"status_bar", q(cfg.StatusBar)},
+		{"footer", q(cfg.Footer)},
+		{"page_transition", q(cfg.PageTransition)},
+		{"large_print", strconv.FormatBool(cfg.LargePrint)},
+		{"progress_bar", strconv.FormatBool(cfg.ProgressBar)},
+		{"mouse", strconv.FormatBool(cfg.Mouse)},
+		{"notify", q(cfg.Notify)},
+		{"quiet", strconv.FormatBool(cfg.Quiet)},
+		{"offline", strconv.FormatBool(cfg.Offline)},
+		{"hide_adult", strconv.FormatBool(cfg.HideAdult)},
+		{"blocked_subjects", q(strings.Join(cfg.BlockedSubjects, ", "))},
+	}}
+	sections := []configSection{top}
+
+	privacy := configSection{name: "privacy"}
+	for _, feature := range networkFeatures {
+		privacy.keys = append(privacy.keys, configKey{feature.Key, strconv.FormatBool(cfg.Privacy[feature.Key])})
+	}
+	sections = append(sections, privacy)
+
+	keys := configSection{name: "keys", complete: true}
+	for _, name := range sortedKeys(cfg.Keys) {
+		keys.keys = append(keys.keys, configKey{q(name), q(cfg.Keys[name])})
+	}
+	dictionaries := configSection{name: "dictionaries", complete: true}
+	for _, language := range sortedKeys(cfg.Dictionaries) {
+		dictionaries.keys = append(dictionaries.keys, configKey{language, q(cfg.Dictionaries[language])})
+	}
+	sections = append(sections, keys, dictionaries)
+
+	if smtp := cfg.SMTP; smtp.Host != "" {
+		sections = append(sections, configSection{name: "smtp", keys: []configKey{
+			{"host", q(smtp.Host)},
+			{"port", strconv.Itoa(smtp.Port)},
+			{"username", q(smtp.Username)},
+			{"password", q(smtp.Password)},
+			{"from", q(smtp.From)},
+			{"to", q(smtp.To)},
+		}})
+	}
+
+	filters := configSection{name: "filters", complete: true, keys: []configKey{
+		{"default", q(cfg.Filters.Default)},
+		{"keep_boilerplate", strconv.FormatBool(cfg.Filters.KeepBoilerplate)},
+	}}
+	for _, host := range sortedKeys(cfg.Filters.Sources) {
+		filters.keys = append(filters.keys, configKey{q(host), q(cfg.Filters.Sources[host])})
+	}
+	sections = append(sections, filters)
+	for _, rule := range cfg.Filters.Rules {
+		sections = append(sections, configSection{name: "rule", array: true, complete: true, keys: []configKey{
+			{"pattern", q(rule.Pattern)},
+			{"replace", q(rule.Replace)},
+		}})
+	}
+	for _, feed := range cfg.OPDSFeeds {
+		sections = append(sections, configSection{name: "opds", array: true, complete: true, keys: []configKey{
+			{"name", q(feed.Name)},
+			{"url", q(feed.URL)},
+		}})
+	}
+	return sections
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// arrayTables are the array tables gutberg writes in full: one in the file
+// past the entries of the config was removed.
+var arrayTables = map[string]bool{"rule": true, "opds": true}
+
+// mergeConfig writes the sections into the text of a config file, setting
+// the keys where they are and adding the missing ones at the end of their
+// section, or of the file.
+func mergeConfig(old string, sections []configSection) string {
+	index := make(map[string]int)
+	counts := make(map[string]int)
+	sectionID := func(name string, array bool) string {
+		id := fmt.Sprintf("%s/%t/%d", name, array, counts[name])
+		counts[name]++
+		return id
+	}
+	for i, s := range sections {
+		index[sectionID(s.name, s.array)] = i
+	}
+	clear(counts)
+
+	var out []string
+	found := make([]bool, len(sections))
+	written := make([]map[string]bool, len(sections))
+	for i := range written {
+		written[i] = make(map[string]bool)
+	}
+	// current is the section the lines read belong to: -1 for one gutberg
+	// doesn't write, kept as it is, and -2 for a removed one, dropped.
+	current := 0
+	found[0] = true
+	finish := func() {
+		if current < 0 {
+			return
+		}
+		var missing []string
+		for _, k := range sections[current].keys {
+			if !written[current][configKeyName(k.name)] {
+				missing = append(missing, k.name+" = "+k.value)
+			}
+		}
+		// Blank lines and comments closing a section go with the next one.
+		end := len(out)
+		for end > 0 && (strings.TrimSpace(out[end-1]) == "" || strings.HasPrefix(strings.TrimSpace(out[end-1]), "#")) {
+			end--
+		}
+		out = append(out[:end], append(missing, out[end:]...)...)
+	}
+
+	lines := strings.Split(strings.TrimRight(old, "\n"), "\n")
+	if old == "" {
+		lines = nil
+	}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			finish()
+			array := strings.HasPrefix(trimmed, "[[")
+			name := strings.Trim(trimmed, "[] ")
+			i, ok := index[sectionID(name, array)]
+			switch {
+			case ok:
+				current = i
+				found[i] = true
+			case array && arrayTables[name]:
+				current = -2
+				continue
+			default:
+				current = -1
+			}
+			out = append(out, line)
+			continue
+		}
+		if current == -2 {
+			continue
+		}
+		if current == -1 || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			out = append(out, line)
+			continue
+		}
+		name, _, _ := strings.Cut(trimmed, "=")
+		name = configKeyName(name)
+		section := sections[current]
+		if k := slices.IndexFunc(section.keys, func(k configKey) bool { return configKeyName(k.name) == name }); k >= 0 {
+			if !written[current][name] {
+				out = append(out, section.keys[k].name+" = "+section.keys[k].value)
+				written[current][name] = true
+			}
+			continue
+		}
+		if !section.complete {
+			out = append(out, line)
+		}
+	}
+	finish()
+
+	for i, s := range sections {
+		if found[i] || len(s.keys) == 0 {
+			continue
+		}
+		out = append(out, "", s.header())
+		for _, k := range s.keys {
+			out = append(out, k.name+" = "+k.value)
+		}
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// configKeyName is a key of the config file as read, without quotes.
+func configKeyName(key string) string {
+	return strings.Trim(strings.TrimSpace(key), "\"")
+}
+
+func readConfig(path string, cfg Config) (Config, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return Config{}, err
 	}
 	defer file.Close()
 
-	var cfg Config
+	section := ""
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			switch section {
+			case "opds":
+				cfg.OPDSFeeds = append(cfg.OPDSFeeds, OPDSFeed{})
+			case "rule":
+				cfg.Filters.Rules = append(cfg.Filters.Rules, RegexRule{})
+			}
+			continue
+		}
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
 			continue
 		}
 		key := strings.TrimSpace(parts[0])
-		val := strings.TrimSpace(parts[1])
-		val = strings.Trim(val, "\"")
+		val := configValue(strings.TrimSpace(parts[1]))
+		if section == "opds" {
+			feed := &cfg.OPDSFeeds[len(cfg.OPDSFeeds)-1]
+			switch key {
+			case "name":
+				feed.Name = val
+			case "url":
+				feed.URL = val
+			}
+			continue
+		}
+		if section == "rule" {
+			rule := &cfg.Filters.Rules[len(cfg.Filters.Rules)-1]
+			switch key {
+			case "pattern":
+				rule.Pattern = val
+			case "replace":
+				rule.Replace = val
+			}
+			continue
+		}
+		if section == "filters" {
+			switch key {
+			case "default":
+				cfg.Filters.Default = val
+			case "keep_boilerplate":
+				cfg.Filters.KeepBoilerplate = val == "true"
+			default:
+				cfg.Filters.Sources[strings.Trim(key, "\"")] = val
+			}
+			continue
+		}
+		if section == "dictionaries" {
+			cfg.Dictionaries[strings.Trim(key, "\"")] = val
+			continue
+		}
+		if section == "smtp" {
+			switch key {
+			case "host":
+				cfg.SMTP.Host = val
+			case "port":
+				if port, err := strconv.Atoi(val); err == nil {
+					cfg.SMTP.Port = port
+				}
+			case "username":
+				cfg.SMTP.Username = val
+			case "password":
+				cfg.SMTP.Password = val
+			case "from":
+				cfg.SMTP.From = val
+			case "to":
+				cfg.SMTP.To = val
+			}
+			continue
+		}
+		if section == "keys" {
+			cfg.Keys[strings.Trim(key, "\"")] = val
+			continue
+		}
+		if section == "privacy" {
+			cfg.Privacy[key] = val == "true"
+			continue
+		}
+		if section != "" {
+			debugLog.Warn("config key in an unknown section ignored", "section", section, "key", key)
+			continue
+		}
+		if val == "" && key != "header" && key != "status_bar" && key != "footer" {
+			continue
+		}
 		switch key {
 		case "books_dir":
 			cfg.BooksDir = val
+		case "export_dir":
+			cfg.ExportDir = val
 		case "state_file":
 			cfg.StateFile = val
+		case "state_store":
+			cfg.StateStore = val
+		case "state_url":
+			cfg.StateURL = val
+		case "cache_dir":
+			cfg.CacheDir = val
+		case "audit_file":
+			cfg.AuditFile = val
+		case "log_file":
+			cfg.LogFile = val
+		case "log_level":
+			cfg.LogLevel = val
+		case "authors_file":
+			cfg.AuthorsFile = val
+		case "pdftotext":
+			cfg.PDFToText = val
+		case "sqlite3":
+			cfg.SQLite = val
+		case "tts_command":
+			cfg.TTSCommand = val
+		case "theme":
+			cfg.Theme = val
+		case "colors":
+			cfg.Colors = val
+		case "language":
+			cfg.Language = val
+		case "ui_language":
+			cfg.UILanguage = val
+		case "download_format":
+			if validDownloadFormat(val) {
+				cfg.DownloadFormat = val
+			}
+		case "keymap":
+			cfg.Keymap = val
+		case "header":
+			cfg.Header = val
+		case "status_bar":
+			cfg.StatusBar = val
+			if val == legacyStatusBar {
+				cfg.StatusBar = defaultStatusBar
+			}
+		case "footer":
+			cfg.Footer = val
+			if slices.Contains(legacyFooters, val) {
+				cfg.Footer = defaultFooter
+			}
+		case "page_transition":
+			cfg.PageTransition = val
+		case "large_print":
+			cfg.LargePrint = val == "true"
+		case "progress_bar":
+			cfg.ProgressBar = val == "true"
+		case "mouse":
+			cfg.Mouse = val == "true"
+		case "notify":
+			cfg.Notify = val
+		case "quiet":
+			cfg.Quiet = val == "true"
+		case "offline":
+			cfg.Offline = val == "true"
+		case "hide_adult":
+			cfg.HideAdult = val == "true"
+		case "blocked_subjects":
+			cfg.BlockedSubjects = splitSubjects(val)
+		case "wpm":
+			if wpm, err := strconv.Atoi(val); err == nil {
+				cfg.WPM = wpm
+			}
+		case "auto_turn":
+			if seconds, err := strconv.Atoi(val); err == nil {
+				cfg.AutoTurn = seconds
+			}
+		case "goal_pages":
+			if pages, err := strconv.Atoi(val); err == nil {
+				cfg.GoalPages = pages
+			}
+		case "goal_minutes":
+			if minutes, err := strconv.Atoi(val); err == nil {
+				cfg.GoalMinutes = minutes
+			}
 		}
 	}
 	if err := scanner.Err(); err != nil {
@@ -695,10 +1237,24 @@ func readConfig(path string) (Config, error) {
 	return cfg, nil
 }
 
+func configValue(raw string) string {
+	if strings.HasPrefix(raw, "\"") {
+		if val, err := strconv.Unquote(raw); err == nil {
+			return val
+		}
+	}
+	return strings.Trim(raw, "\"")
+}
+
 func saveState(path string, state State) error {
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0o644)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		debugLog.Error("state save failed", "path", path, "err", err)
+		return err
+	}
+	debugLog.Debug("state saved", "path", path, "book", state.CurrentBook, "page", state.Page)
+	return nil
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadConfigIgnoresUnknownSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gutberg.toml")
+	config := "books_dir = \"/books\"\n[plugins]\nbooks_dir = \"/elsewhere\"\ntheme = \"dark\"\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := readConfig(path, Config{Theme: defaultThemeName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BooksDir != "/books" || cfg.Theme != defaultThemeName {
+		t.Errorf("readConfig = books_dir %q, theme %q, want %q, %q", cfg.BooksDir, cfg.Theme, "/books", defaultThemeName)
+	}
+}
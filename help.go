@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// actionName turns an action into the words shown in help and hints.
+func actionName(a action) string {
+	return tr(strings.ReplaceAll(string(a), "_", " "))
+}
+
+func bindingKeys(keys []string) string {
+	labels := make([]string, len(keys))
+	for i, key := range keys {
+		labels[i] = keyLabel(key)
+	}
+	return strings.Join(labels, "/")
+}
+
+// hint renders a footer for the given actions of a mode from their current
+// bindings, skipping actions left unbound and, offline, those that need the
+// network.
+func (k keymap) hint(m mode, acts ...action) string {
+	var parts []string
+	for _, act := range acts {
+		if needsNetwork(act) {
+			continue
+		}
+		for _, b := range k.bindings[m] {
+			if b.action == act && len(b.keys) > 0 {
+				parts = append(parts, bindingKeys(b.keys[:min(len(b.keys), 2)])+": "+actionName(act))
+				break
+			}
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// keysFor names the first keys bound to an action of a mode.
+func (k keymap) keysFor(m mode, act action) string {
+	for _, b := range k.bindings[m] {
+		if b.action == act && len(b.keys) > 0 {
+			return bindingKeys(b.keys[:min(len(b.keys), 2)])
+		}
+	}
+	return ""
+}
+
+// helpText lists every binding, starting with the mode help was opened in.
+func (k keymap) helpText(current mode) string {
+	modes := append([]mode{current}, slices.DeleteFunc(slices.Clone(testableModes), func(m mode) bool { return m == current })...)
+	var b strings.Builder
+	for i, m := range modes {
+		if len(k.bindings[m]) == 0 {
+			continue
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(titleStyle().Render(capitalize(tr(modeNames[m]))) + "\n")
+		for _, binding := range k.bindings[m] {
+			name := actionName(binding.action)
+			if needsNetwork(binding.action) {
+				name += " " + tr("(needs the network)")
+			}
+			fmt.Fprintf(&b, "  %-24s %s\n", bindingKeys(binding.keys), name)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// typing reports whether keys go to a text box or a list filter, where ?
+// is text rather than a request for help.
+func (m model) typing() bool {
+	switch m.mode {
+	case modeAuthorSearch:
+		return m.authorInput.Value() != ""
+	case modeSettings:
+		return m.settingsEditing
+	case modeKeyTester, modeAdvancedSearch:
+		return true
+	case modeLibrary:
+		return m.libraryList.FilterState() == list.Filtering
+	case modeBooks:
+		return m.bookList.FilterState() == list.Filtering
+	case modeChapters:
+		return m.chapterList.FilterState() == list.Filtering
+	case modeFeeds:
+		return m.feedList.FilterState() == list.Filtering
+	case modeAuthorIndex:
+		return m.indexList.FilterState() == list.Filtering
+	case modeFinished:
+		return m.finish.editing
+	case modeQuotes:
+		return m.quoteList.FilterState() == list.Filtering
+	case modeBookshelves:
+		return m.shelfList.FilterState() == list.Filtering
+	}
+	return false
+}
+
+func (m model) openHelp() model {
+	m.help = viewport.New(m.width, max(m.height-2, 5))
+	m.help.SetContent(m.keys.helpText(m.mode))
+	m.helpOpen = true
+	return m
+}
+
+func (m model) updateHelp(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "?", "esc", "q":
+			m.helpOpen = false
+			return m, nil
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.help, cmd = m.help.Update(msg)
+	return m, cmd
+}
+
+func (m model) helpView() string {
+	return m.help.View() + "\n" + helpLine(trf("↑/↓ pgup/pgdn: scroll  %3.f%%  ?/esc: close", m.help.ScrollPercent()*100))
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode"
+
+	xhtml "golang.org/x/net/html"
+)
+
+var skippedElements = map[string]bool{"head": true, "script": true, "style": true, "noscript": true, "template": true}
+
+var skippedIDs = map[string]bool{"pg-header": true, "pg-footer": true}
+
+var transcriberNoteClasses = map[string]bool{"transnote": true, "tnote": true, "transcriber": true, "transcribers-note": true}
+
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "blockquote": true, "body": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"figure": true, "figcaption": true, "header": true, "footer": true, "aside": true,
+	"center": true, "address": true, "caption": true,
+}
+
+// htmlSection is the text rendered between two chapter headings; the first
+// one holds whatever comes before the first heading.
+type htmlSection struct {
+	title string
+	text  strings.Builder
+}
+
+// textRenderer walks a parsed book and writes the text the reader shows:
+// paragraphs separated by paragraphBreak, style codes for italics and bold,
+// and verseMark lines for poems, <pre> blocks and tables. With split set,
+// h1-h3 headings start a new section instead of being rendered inline.
+// With notes set, transcriber's notes and the Gutenberg header and footer
+// are left out.
+type textRenderer struct {
+	split    bool
+	notes    bool
+	sections []*htmlSection
+}
+
+func parseHTML(data []byte) *xhtml.Node {
+	root, err := xhtml.Parse(bytes.NewReader(data))
+	if err != nil {
+		return &xhtml.Node{Type: xhtml.DocumentNode}
+	}
+	return root
+}
+
+// hyphenSpaceRe finds a run of white space, with the hyphenated word part
+// before it if there is one.
+var hyphenSpaceRe = regexp.MustCompile(`(\p{L}-)?\s+`)
+
+// collapseSpace turns each run of white space into one space, but keeps a
+// line break after a word hyphenated at the end of a line for the
+// dehyphenate filter.
+func collapseSpace(text string) string {
+	return hyphenSpaceRe.ReplaceAllStringFunc(text, func(run string) string {
+		space := strings.TrimLeftFunc(run, func(r rune) bool { return !unicode.IsSpace(r) })
+		hyphen := strings.TrimSuffix(run, space)
+		if hyphen != "" && strings.Contains(space, "\n") {
+			return hyphen + "\n"
+		}
+		return hyphen + " "
+	})
+}
+
+func renderHTMLSections(root *xhtml.Node, split, notes bool) []*htmlSection {
+	r := &textRenderer{split: split, notes: notes, sections: []*htmlSection{{}}}
+	r.render(root)
+	return r.sections
+}
+
+func (r *textRenderer) out() *strings.Builder {
+	return &r.sections[len(r.sections)-1].text
+}
+
+func (r *textRenderer) block() {
+	r.out().WriteString(paragraphBreak)
+}
+
+func (r *textRenderer) children(n *xhtml.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+}
+
+func (r *textRenderer) styled(n *xhtml.Node, on, off string) {
+	r.out().WriteString(on)
+	r.children(n)
+	r.out().WriteString(off)
+}
+
+func (r *textRenderer) verse(lines []string) {
+	r.block()
+	out := r.out()
+	for _, line := range lines {
+		if strings.TrimSpace(stripStyles(line)) == "" {
+			out.WriteString("\n")
+			continue
+		}
+		out.WriteString(verseMark + strings.TrimRight(line, " ") + "\n")
+	}
+	r.block()
+}
+
+func (r *textRenderer) render(n *xhtml.Node) {
+	switch n.Type {
+	case xhtml.DocumentNode:
+		r.children(n)
+		return
+	case xhtml.TextNode:
+		r.out().WriteString(collapseSpace(n.Data))
+		return
+	case xhtml.ElementNode:
+	default:
+		return
+	}
+
+	if skippedElements[n.Data] || (r.notes && isBoilerplateElement(n)) {
+		return
+	}
+	switch n.Data {
+	case "h1", "h2", "h3":
+		if r.split {
+			r.sections = append(r.sections, &htmlSection{title: headingText(n)})
+			return
+		}
+		r.block()
+		r.styled(n, styleBoldOn, styleBoldOff)
+		r.block()
+	case "h4", "h5", "h6":
+		r.block()
+		r.styled(n, styleBoldOn, styleBoldOff)
+		r.block()
+	case "i", "em", "cite":
+		r.styled(n, styleItalicOn, styleItalicOff)
+	case "b", "strong":
+		r.styled(n, styleBoldOn, styleBoldOff)
+	case "br":
+		r.out().WriteString("\n")
+	case "hr":
+		r.block()
+	case "pre":
+		r.verse(verseLines(n, true))
+	case "table":
+		if rows := tableRows(n); len(rows) > 0 {
+			r.verse(strings.Split(drawTable(rows), "\n"))
+		}
+	default:
+		if hasVerseClass(n) || isStanza(n) {
+			r.verse(verseLines(n, false))
+			return
+		}
+		block := blockElements[n.Data]
+		if block {
+			r.block()
+		}
+		r.children(n)
+		if block {
+			r.block()
+		}
+	}
+}
+
+// verseLines collects the lines of a poem or <pre> block with their inline
+// styles. Lines end at <br> and block elements; a nested stanza starts after
+// a blank line. Inside <pre> the text keeps its own spacing and newlines.
+func verseLines(n *xhtml.Node, pre bool) []string {
+	var lines []string
+	var line strings.Builder
+	end := func() {
+		lines = append(lines, line.String())
+		line.Reset()
+	}
+	var walk func(*xhtml.Node)
+	walk = func(c *xhtml.Node) {
+		switch c.Type {
+		case xhtml.TextNode:
+			if pre {
+				parts := strings.Split(strings.ReplaceAll(c.Data, "\t", "    "), "\n")
+				for i, part := range parts {
+					if i > 0 {
+						end()
+					}
+					line.WriteString(part)
+				}
+				return
+			}
+			text := spaceRunRe.ReplaceAllString(c.Data, " ")
+			if strings.TrimSpace(stripStyles(line.String())) == "" {
+				text = strings.TrimLeft(text, " ")
+			}
+			line.WriteString(text)
+			return
+		case xhtml.ElementNode:
+		default:
+			return
+		}
+		if skippedElements[c.Data] {
+			return
+		}
+		switch c.Data {
+		case "br":
+			end()
+			return
+		case "i", "em", "cite":
+			line.WriteString(styleItalicOn)
+			defer line.WriteString(styleItalicOff)
+		case "b", "strong":
+			line.WriteString(styleBoldOn)
+			defer line.WriteString(styleBoldOff)
+		}
+		nested := c != n && hasVerseClass(c)
+		block := c != n && (blockElements[c.Data] || nested)
+		if block && strings.TrimSpace(stripStyles(line.String())) != "" {
+			end()
+		}
+		if nested {
+			lines = append(lines, "")
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if block && strings.TrimSpace(stripStyles(line.String())) != "" {
+			end()
+		}
+	}
+	walk(n)
+	if strings.TrimSpace(stripStyles(line.String())) != "" {
+		end()
+	}
+	for len(lines) > 0 && strings.TrimSpace(stripStyles(lines[0])) == "" {
+		lines = lines[1:]
+	}
+	return lines
+}
+
+func isBoilerplateElement(n *xhtml.Node) bool {
+	if id, _ := attr(n, "id"); skippedIDs[id] {
+		return true
+	}
+	class, _ := attr(n, "class")
+	for _, c := range strings.Fields(class) {
+		if transcriberNoteClasses[strings.ToLower(c)] {
+			return true
+		}
+	}
+	return false
+}
+
+// headingText flattens a heading to one line, turning <br> into a space.
+func headingText(n *xhtml.Node) string {
+	var b strings.Builder
+	var walk func(*xhtml.Node)
+	walk = func(c *xhtml.Node) {
+		switch {
+		case c.Type == xhtml.TextNode:
+			b.WriteString(c.Data)
+		case c.Type == xhtml.ElementNode && c.Data == "br":
+			b.WriteString(" ")
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return compactSpaces(b.String())
+}
+
+func findElement(n *xhtml.Node, name string) *xhtml.Node {
+	if n.Type == xhtml.ElementNode && n.Data == name {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+const (
+	uiAuto    = "auto"
+	uiEnglish = "en"
+	uiSpanish = "es"
+)
+
+var uiLanguages = []string{uiAuto, uiEnglish, uiSpanish}
+
+// catalogs translate the English messages of the interface, keyed by the
+// English text itself, so a missing translation falls back to English.
+var catalogs = map[string]map[string]string{
+	uiSpanish: messagesES,
+}
+
+var uiLanguage = uiEnglish
+
+func validUILanguage(name string) bool {
+	for _, l := range uiLanguages {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setUILanguage picks the interface language; auto follows LC_ALL,
+// LC_MESSAGES or LANG.
+func setUILanguage(name string) bool {
+	if !validUILanguage(name) {
+		return false
+	}
+	if name == uiAuto {
+		name = localeLanguage()
+	}
+	uiLanguage = name
+	return true
+}
+
+func localeLanguage() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(env)
+		if locale == "" {
+			continue
+		}
+		lang, _, _ := strings.Cut(locale, "_")
+		if _, ok := catalogs[lang]; ok {
+			return lang
+		}
+		return uiEnglish
+	}
+	return uiEnglish
+}
+
+// tr translates a message.
+func tr(msg string) string {
+	if translated, ok := catalogs[uiLanguage][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// trf translates a format and fills it in.
+func trf(format string, args ...any) string {
+	return fmt.Sprintf(tr(format), args...)
+}
+
+// localizeList translates the key help, filter prompt and item names the
+// list component draws itself.
+func localizeList(l *list.Model) {
+	for _, h := range []struct {
+		binding *key.Binding
+		desc    string
+	}{
+		{&l.KeyMap.CursorUp, "up"},
+		{&l.KeyMap.CursorDown, "down"},
+		{&l.KeyMap.PrevPage, "prev page"},
+		{&l.KeyMap.NextPage, "next page"},
+		{&l.KeyMap.GoToStart, "go to start"},
+		{&l.KeyMap.GoToEnd, "go to end"},
+		{&l.KeyMap.Filter, "filter"},
+		{&l.KeyMap.ClearFilter, "clear filter"},
+		{&l.KeyMap.CancelWhileFiltering, "cancel"},
+		{&l.KeyMap.AcceptWhileFiltering, "apply filter"},
+		{&l.KeyMap.ShowFullHelp, "more"},
+		{&l.KeyMap.CloseFullHelp, "close help"},
+		{&l.KeyMap.Quit, "quit"},
+	} {
+		h.binding.SetHelp(h.binding.Help().Key, tr(h.desc))
+	}
+	l.FilterInput.Prompt = tr("Filter: ")
+	l.SetStatusBarItemName(tr("item"), tr("items"))
+}
+
+// localize applies the interface language to the parts of the screen that
+// are built once, in newModel.
+func (m *model) localize() {
+	for _, l := range []*list.Model{&m.authorList, &m.libraryList, &m.bookList, &m.chapterList, &m.feedList, &m.indexList, &m.shelfList} {
+		localizeList(l)
+	}
+	m.authorList.Title = tr("Authors")
+	m.libraryList.Title = tr("Library")
+	m.chapterList.Title = tr("Chapters")
+	m.feedList.Title = tr("OPDS Feeds")
+	m.shelfList.Title = tr("Gutenberg bookshelves")
+	m.authorInput.Placeholder = tr("Author name (e.g. lorca)")
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type action string
+
+const (
+	actionNone            action = ""
+	actionQuit            action = "quit"
+	actionBack            action = "back"
+	actionOpen            action = "open"
+	actionSearch          action = "search"
+	actionLibrary         action = "library"
+	actionReader          action = "reader"
+	actionChapters        action = "chapters"
+	actionFeeds           action = "feeds"
+	actionPrivacy         action = "privacy"
+	actionSettings        action = "settings"
+	actionNextSource      action = "next_source"
+	actionNextPage        action = "next_page"
+	actionPrevPage        action = "prev_page"
+	actionFirstPage       action = "first_page"
+	actionLastPage        action = "last_page"
+	actionBiggerText      action = "bigger_text"
+	actionSmallerText     action = "smaller_text"
+	actionUp              action = "up"
+	actionDown            action = "down"
+	actionToggle          action = "toggle"
+	actionKeyTester       action = "key_tester"
+	actionAuthorIndex     action = "author_index"
+	actionLeft            action = "left"
+	actionRight           action = "right"
+	actionSendBook        action = "send_book"
+	actionDiscover        action = "discover"
+	actionFeatured        action = "featured"
+	actionPopular         action = "popular"
+	actionLargePrint      action = "large_print"
+	actionFilters         action = "filters"
+	actionParallel        action = "parallel"
+	actionPairTranslation action = "pair_translation"
+	actionGloss           action = "gloss"
+	actionNextParagraph   action = "next_paragraph"
+	actionPrevParagraph   action = "prev_paragraph"
+	actionFirstUnread     action = "first_unread"
+	actionCollection      action = "collection"
+	actionSchedule        action = "schedule"
+	actionHelp            action = "help"
+	actionPalette         action = "palette"
+	actionNextChapter     action = "next_chapter"
+	actionPrevChapter     action = "prev_chapter"
+	actionRefresh         action = "refresh"
+	actionAutoTurn        action = "auto_turn"
+	actionSleepTimer      action = "sleep_timer"
+	actionQuiet           action = "quiet"
+	actionFollow          action = "follow"
+	actionPresent         action = "present"
+	actionTutorial        action = "tutorial"
+	actionExport          action = "export"
+	actionDetectColors    action = "detect_colors"
+	actionCopy            action = "copy"
+	actionRate            action = "rate"
+	actionNote            action = "note"
+	actionMarkFinished    action = "mark_finished"
+	actionNextVolume      action = "next_volume"
+	actionQuote           action = "capture_quote"
+	actionQuotes          action = "quotes"
+	actionDelete          action = "delete"
+	actionWebPage         action = "web_page"
+	actionScrub           action = "scrub"
+	actionNextWord        action = "next_word"
+	actionPrevWord        action = "prev_word"
+	actionLookUp          action = "look_up"
+	actionVocabulary      action = "vocabulary"
+	actionReveal          action = "reveal"
+	actionKnown           action = "known"
+	actionForgot          action = "forgot"
+	actionReadAloud       action = "read_aloud"
+	actionLinkPanes       action = "link_panes"
+	actionSwitchPane      action = "switch_pane"
+	actionBookshelves     action = "bookshelves"
+	actionAdvancedSearch  action = "advanced_search"
+	actionNextField       action = "next_field"
+	actionPrevField       action = "prev_field"
+)
+
+const defaultKeymapProfile = "default"
+
+var keymapProfiles = []string{"default", "vim"}
+
+var modeNames = map[mode]string{
+	modeAuthorSearch:   "search",
+	modeLibrary:        "library",
+	modeBooks:          "books",
+	modeReader:         "reader",
+	modeChapters:       "chapters",
+	modeFeeds:          "feeds",
+	modePrivacy:        "privacy",
+	modeSettings:       "settings",
+	modeAuthorIndex:    "index",
+	modeDiscover:       "discover",
+	modeFinished:       "finished",
+	modeQuotes:         "quotes",
+	modeVocabulary:     "vocabulary",
+	modeBookshelves:    "bookshelves",
+	modeAdvancedSearch: "advanced",
+}
+
+type binding struct {
+	action action
+	keys   []string
+}
+
+type keymap struct {
+	profile  string
+	bindings map[mode][]binding
+}
+
+func newKeymap(profile string, overrides map[string]string) keymap {
+	bindings := map[mode][]binding{
+		modeAuthorSearch: {
+			{actionOpen, []string{"enter"}},
+			{actionNextSource, []string{"tab"}},
+			{actionDiscover, []string{"ctrl+d"}},
+			{actionAdvancedSearch, []string{"ctrl+s"}},
+			{actionLibrary, []string{"esc"}},
+			{actionRefresh, []string{"ctrl+r"}},
+			{actionTutorial, []string{"ctrl+t"}},
+			{actionDetectColors, []string{"ctrl+l"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"ctrl+c"}},
+		},
+		modeLibrary: {
+			{actionOpen, []string{"enter"}},
+			{actionSearch, []string{"s"}},
+			{actionAdvancedSearch, []string{"S"}},
+			{actionReader, []string{"b"}},
+			{actionChapters, []string{"c"}},
+			{actionFeeds, []string{"o"}},
+			{actionPrivacy, []string{"p"}},
+			{actionSettings, []string{","}},
+			{actionAuthorIndex, []string{"a"}},
+			{actionSendBook, []string{"m"}},
+			{actionExport, []string{"w"}},
+			{actionPopular, []string{"t"}},
+			{actionBookshelves, []string{"B"}},
+			{actionFilters, []string{"f"}},
+			{actionCollection, []string{"e"}},
+			{actionPairTranslation, []string{"x"}},
+			{actionQuiet, []string{"Q"}},
+			{actionQuotes, []string{"\""}},
+			{actionVocabulary, []string{"V"}},
+			{actionWebPage, []string{"O"}},
+			{actionRefresh, []string{"ctrl+r"}},
+			{actionTutorial, []string{"ctrl+t"}},
+			{actionDetectColors, []string{"ctrl+l"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"esc", "q", "ctrl+c"}},
+		},
+		modeBooks: {
+			{actionOpen, []string{"enter"}},
+			{actionLibrary, []string{"b"}},
+			{actionSearch, []string{"s"}},
+			{actionPopular, []string{"t"}},
+			{actionBookshelves, []string{"B"}},
+			{actionDetectColors, []string{"ctrl+l"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"esc", "q", "ctrl+c"}},
+		},
+		modeReader: {
+			{actionQuit, []string{"q", "ctrl+c"}},
+			{actionLibrary, []string{"b"}},
+			{actionSearch, []string{"s"}},
+			{actionChapters, []string{"c"}},
+			{actionBiggerText, []string{"+", "="}},
+			{actionSmallerText, []string{"-"}},
+			{actionLargePrint, []string{"L"}},
+			{actionParallel, []string{"v"}},
+			{actionLinkPanes, []string{"X"}},
+			{actionSwitchPane, []string{"ctrl+w"}},
+			{actionGloss, []string{"i"}},
+			{actionNextParagraph, []string{"tab"}},
+			{actionPrevParagraph, []string{"shift+tab"}},
+			{actionNextWord, []string{"."}},
+			{actionPrevWord, []string{","}},
+			{actionLookUp, []string{"w"}},
+			{actionNextPage, []string{"enter", " ", "right", "down", "pgdown"}},
+			{actionPrevPage, []string{"left", "up", "pgup"}},
+			{actionFirstPage, []string{"home"}},
+			{actionLastPage, []string{"end"}},
+			{actionNextChapter, []string{"]"}},
+			{actionPrevChapter, []string{"["}},
+			{actionFirstUnread, []string{"u"}},
+			{actionScrub, []string{"m"}},
+			{actionSchedule, []string{"d"}},
+			{actionReadAloud, []string{"r"}},
+			{actionAutoTurn, []string{"A"}},
+			{actionSleepTimer, []string{"z"}},
+			{actionQuiet, []string{"Q"}},
+			{actionFollow, []string{"F"}},
+			{actionPresent, []string{"P"}},
+			{actionCopy, []string{"y"}},
+			{actionQuote, []string{"Y"}},
+			{actionQuotes, []string{"\""}},
+			{actionVocabulary, []string{"V"}},
+			{actionWebPage, []string{"O"}},
+			{actionDetectColors, []string{"ctrl+l"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+		},
+		modeChapters: {
+			{actionOpen, []string{"enter"}},
+			{actionFirstUnread, []string{"u"}},
+			{actionBack, []string{"b", "esc"}},
+			{actionDetectColors, []string{"ctrl+l"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"q", "ctrl+c"}},
+		},
+		modeFeeds: {
+			{actionOpen, []string{"enter"}},
+			{actionBack, []string{"b", "esc"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"q", "ctrl+c"}},
+		},
+		modePrivacy: {
+			{actionUp, []string{"up", "k"}},
+			{actionDown, []string{"down", "j"}},
+			{actionToggle, []string{"enter", " "}},
+			{actionBack, []string{"b", "esc"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"q", "ctrl+c"}},
+		},
+		modeSettings: {
+			{actionUp, []string{"up", "k"}},
+			{actionDown, []string{"down", "j"}},
+			{actionToggle, []string{"enter", " "}},
+			{actionKeyTester, []string{"t"}},
+			{actionBack, []string{"b", "esc"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"q", "ctrl+c"}},
+		},
+		modeDiscover: {
+			{actionOpen, []string{"enter"}},
+			{actionDiscover, []string{"r"}},
+			{actionFeatured, []string{"f"}},
+			{actionBack, []string{"b", "esc"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"q", "ctrl+c"}},
+		},
+		modeFinished: {
+			{actionRate, []string{"1", "2", "3", "4", "5"}},
+			{actionNote, []string{"n"}},
+			{actionMarkFinished, []string{"f"}},
+			{actionNextVolume, []string{"c"}},
+			{actionUp, []string{"up", "k"}},
+			{actionDown, []string{"down", "j"}},
+			{actionOpen, []string{"enter"}},
+			{actionBack, []string{"b", "esc"}},
+			{actionLibrary, []string{"l"}},
+			{actionDetectColors, []string{"ctrl+l"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"q", "ctrl+c"}},
+		},
+		modeQuotes: {
+			{actionOpen, []string{"enter"}},
+			{actionCopy, []string{"y"}},
+			{actionDelete, []string{"d"}},
+			{actionBack, []string{"b", "esc"}},
+			{actionDetectColors, []string{"ctrl+l"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"q", "ctrl+c"}},
+		},
+		modeAdvancedSearch: {
+			{actionNextField, []string{"tab", "down"}},
+			{actionPrevField, []string{"shift+tab", "up"}},
+			{actionSearch, []string{"enter"}},
+			{actionBack, []string{"esc"}},
+			{actionQuit, []string{"ctrl+c"}},
+		},
+		modeBookshelves: {
+			{actionOpen, []string{"enter"}},
+			{actionBack, []string{"b", "esc"}},
+			{actionDetectColors, []string{"ctrl+l"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"q", "ctrl+c"}},
+		},
+		modeVocabulary: {
+			{actionReveal, []string{"enter", " "}},
+			{actionKnown, []string{"y"}},
+			{actionForgot, []string{"n"}},
+			{actionDelete, []string{"d"}},
+			{actionBack, []string{"b", "esc"}},
+			{actionDetectColors, []string{"ctrl+l"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"q", "ctrl+c"}},
+		},
+		modeAuthorIndex: {
+			{actionLeft, []string{"left", "h"}},
+			{actionRight, []string{"right", "l"}},
+			{actionUp, []string{"up", "k"}},
+			{actionDown, []string{"down", "j"}},
+			{actionOpen, []string{"enter"}},
+			{actionBack, []string{"b", "esc"}},
+			{actionRefresh, []string{"ctrl+r"}},
+			{actionPalette, []string{":"}},
+			{actionHelp, []string{"?"}},
+			{actionQuit, []string{"q", "ctrl+c"}},
+		},
+	}
+
+	if profile == "vim" {
+		bindings[modeReader] = append(bindings[modeReader],
+			binding{actionNextPage, []string{"j", "l", "ctrl+f"}},
+			binding{actionPrevPage, []string{"k", "h", "ctrl+b"}},
+			binding{actionFirstPage, []string{"g"}},
+			binding{actionLastPage, []string{"G"}},
+		)
+	} else {
+		profile = defaultKeymapProfile
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		keys := overrides[name]
+		m, act, ok := parseBindingName(name)
+		if !ok {
+			continue
+		}
+		kept := bindings[m][:0]
+		for _, b := range bindings[m] {
+			if b.action != act {
+				kept = append(kept, b)
+			}
+		}
+		bindings[m] = append(kept, binding{act, splitKeys(keys)})
+	}
+	return keymap{profile: profile, bindings: bindings}
+}
+
+func (k keymap) conflicts() []string {
+	var out []string
+	for m, name := range modeNames {
+		owners := make(map[string][]string)
+		for _, b := range k.bindings[m] {
+			for _, key := range b.keys {
+				owners[key] = append(owners[key], string(b.action))
+			}
+		}
+		for key, actions := range owners {
+			if len(actions) > 1 {
+				out = append(out, fmt.Sprintf("%s: %q is bound to %s", name, keyLabel(key), strings.Join(actions, ", ")))
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func parseBindingName(name string) (mode, action, bool) {
+	modeName, act, ok := strings.Cut(name, ".")
+	if !ok {
+		return 0, actionNone, false
+	}
+	for m, n := range modeNames {
+		if n == modeName {
+			return m, action(act), true
+		}
+	}
+	return 0, actionNone, false
+}
+
+func splitKeys(keys string) []string {
+	var out []string
+	for _, key := range strings.Split(keys, ",") {
+		if key == " " {
+			out = append(out, key)
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if key == "space" {
+			key = " "
+		}
+		if key != "" {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+func keyLabel(key string) string {
+	if key == " " {
+		return "space"
+	}
+	return key
+}
+
+func (k keymap) lookup(m mode, key string) action {
+	for _, b := range k.bindings[m] {
+		for _, candidate := range b.keys {
+			if candidate == key {
+				return b.action
+			}
+		}
+	}
+	return actionNone
+}
+
+func validKeymapProfile(profile string) bool {
+	for _, p := range keymapProfiles {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
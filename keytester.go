@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var testableModes = []mode{
+	modeAuthorSearch,
+	modeLibrary,
+	modeBooks,
+	modeReader,
+	modeChapters,
+	modeFeeds,
+	modePrivacy,
+	modeSettings,
+	modeAuthorIndex,
+	modeDiscover,
+	modeFinished,
+	modeQuotes,
+	modeVocabulary,
+	modeBookshelves,
+	modeAdvancedSearch,
+}
+
+func (m model) updateKeyTester(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "esc":
+			m.mode = modeSettings
+			return m, nil
+		case "tab":
+			for i, candidate := range testableModes {
+				if candidate == m.testerMode {
+					m.testerMode = testableModes[(i+1)%len(testableModes)]
+					break
+				}
+			}
+			m.testerKey = ""
+			return m, nil
+		}
+		m.testerKey = key.String()
+	}
+	return m, nil
+}
+
+func (m model) keyTesterView() string {
+	lines := []string{
+		titleStyle().Render(tr("Key tester")),
+		"",
+		trf("Mode: %s", tr(modeNames[m.testerMode])),
+		"",
+	}
+	if m.testerKey == "" {
+		lines = append(lines, tr("Press a key to see what it does in this mode."))
+	} else {
+		result := tr("not bound")
+		if act := m.keys.lookup(m.testerMode, m.testerKey); act != actionNone {
+			result = actionName(act)
+		}
+		lines = append(lines, fmt.Sprintf("%s -> %s", keyLabel(m.testerKey), result))
+	}
+	lines = append(lines, "", helpLine(tr("tab: next mode  esc: back to settings")))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	languageSampleWords = 2000
+	minLanguageHits     = 5
+)
+
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	code  string
+}{
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+}
+
+// htmlLangRe finds the language an HTML file declares on its root element,
+// keeping the language of a tag such as en-US.
+var htmlLangRe = regexp.MustCompile(`(?is)<html\b[^>]*?\s(?:xml:)?lang\s*=\s*["']\s*([a-z]{2,3})(?:[-_][^"']*)?["']`)
+
+var rtlLanguages = map[string]bool{"ar": true, "he": true, "fa": true, "ur": true, "yi": true}
+
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "that", "was", "he", "it", "with", "is", "for", "his", "had", "you", "not", "but", "which", "she", "her"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "que", "qui", "dans", "pas", "pour", "sur", "au", "il", "elle", "avec", "ce", "mais", "du"},
+	"de": {"der", "die", "und", "das", "nicht", "ist", "ich", "sie", "zu", "den", "mit", "sich", "des", "auf", "ein", "dem", "eine", "auch", "er", "war"},
+	"es": {"de", "la", "que", "el", "en", "y", "a", "los", "se", "del", "las", "un", "por", "con", "no", "una", "su", "para", "como", "más"},
+	"it": {"il", "di", "che", "e", "la", "per", "non", "una", "con", "gli", "del", "della", "sono", "si", "era", "ma", "come", "anche", "alla", "più"},
+	"pt": {"o", "os", "as", "que", "de", "não", "uma", "com", "para", "se", "do", "da", "em", "mas", "ao", "ele", "ela", "seu", "sua", "foi"},
+	"nl": {"de", "het", "een", "en", "van", "ik", "te", "dat", "niet", "zijn", "op", "hij", "met", "voor", "was", "maar", "ze", "aan", "er", "om"},
+	"la": {"et", "est", "in", "non", "ad", "cum", "quod", "sed", "ut", "qui", "quae", "enim", "esse", "atque", "nec", "sunt", "autem", "eius", "etiam", "hoc"},
+	"sv": {"och", "att", "det", "som", "en", "på", "är", "av", "för", "med", "till", "den", "har", "inte", "om", "ett", "han", "var", "jag", "hon"},
+	"da": {"og", "at", "det", "som", "en", "på", "er", "af", "for", "med", "til", "den", "har", "ikke", "om", "et", "han", "var", "jeg", "hun"},
+	"fi": {"ja", "on", "ei", "se", "että", "hän", "oli", "ole", "mutta", "kun", "niin", "kuin", "joka", "mitä", "sen", "hänen", "minä", "myös", "vain", "nyt"},
+	"pl": {"i", "w", "nie", "na", "się", "z", "że", "do", "to", "jest", "jak", "ale", "o", "po", "co", "tak", "już", "od", "jego", "za"},
+	"eo": {"la", "kaj", "de", "estas", "en", "al", "ne", "mi", "li", "ŝi", "ke", "kun", "por", "sed", "tiu", "kiu", "estis", "ĉe", "pri", "ili"},
+}
+
+var languageNames = map[string]string{
+	"en": "English", "fr": "French", "de": "German", "es": "Spanish", "it": "Italian",
+	"pt": "Portuguese", "nl": "Dutch", "la": "Latin", "sv": "Swedish", "da": "Danish",
+	"fi": "Finnish", "pl": "Polish", "eo": "Esperanto", "ar": "Arabic", "he": "Hebrew",
+	"el": "Greek", "ru": "Russian", "ja": "Japanese", "ko": "Korean", "zh": "Chinese",
+}
+
+var stopwordIndex = func() map[string][]string {
+	index := make(map[string][]string)
+	for code, words := range stopwords {
+		for _, w := range words {
+			index[w] = append(index[w], code)
+		}
+	}
+	return index
+}()
+
+// detectLanguage guesses the language of text, first from its script and
+// for Latin text from stopword counts. It returns "" when unsure.
+func detectLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(stripStyles(text)), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) > languageSampleWords {
+		words = words[:languageSampleWords]
+	}
+
+	letters, latin := 0, 0
+	scripts := make(map[string]int)
+	for _, w := range words {
+		for _, r := range w {
+			letters++
+			if unicode.Is(unicode.Latin, r) {
+				latin++
+				continue
+			}
+			for _, s := range scriptLanguages {
+				if unicode.Is(s.table, r) {
+					scripts[s.code]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	if latin*2 < letters {
+		best := ""
+		for code, n := range scripts {
+			if best == "" || n > scripts[best] || (n == scripts[best] && code < best) {
+				best = code
+			}
+		}
+		return best
+	}
+
+	hits := make(map[string]int)
+	for _, w := range words {
+		for _, code := range stopwordIndex[w] {
+			hits[code]++
+		}
+	}
+	best := ""
+	for code, n := range hits {
+		if best == "" || n > hits[best] || (n == hits[best] && code < best) {
+			best = code
+		}
+	}
+	if hits[best] < minLanguageHits || hits[best]*20 < len(words) {
+		return ""
+	}
+	return best
+}
+
+// bookLanguage is the language covering most of the book's words; chapters
+// keep their own when an anthology mixes languages.
+func bookLanguage(chapters []Chapter) string {
+	words := make(map[string]int)
+	best := ""
+	for _, ch := range chapters {
+		if ch.Language == "" {
+			continue
+		}
+		words[ch.Language] += len(strings.Fields(ch.Text))
+		if best == "" || words[ch.Language] > words[best] {
+			best = ch.Language
+		}
+	}
+	return best
+}
+
+// declaredLanguage is the language an HTML file declares, or "" when it
+// declares none or an undetermined one.
+func declaredLanguage(data []byte) string {
+	match := htmlLangRe.FindSubmatch(data[:min(len(data), 8192)])
+	if match == nil {
+		return ""
+	}
+	switch code := strings.ToLower(string(match[1])); code {
+	case "und", "mul", "zxx":
+		return ""
+	default:
+		return code
+	}
+}
+
+// loadAddedBook loads a book just added to the library and stores its
+// language in its entry, for the next loads and for scripts reading
+// library.json.
+func loadAddedBook(path string, width, lines int) (Book, error) {
+	book, err := loadBookFromHTML(path, width, lines)
+	if err == nil {
+		rememberLanguage(path, book.Language)
+	}
+	return book, err
+}
+
+func rememberLanguage(path, language string) {
+	dir, name := filepath.Split(path)
+	lib, err := loadLibrary(dir)
+	if err != nil {
+		return
+	}
+	entry, ok := lib.Books[name]
+	if !ok || entry.Language == language {
+		return
+	}
+	entry.Language = language
+	lib.Books[name] = entry
+	if err := saveLibrary(dir, lib); err != nil {
+		debugLog.Warn("book language not saved", "path", path, "err", err)
+	}
+}
+
+func languageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+func isRTL(code string) bool {
+	return rtlLanguages[code]
+}
+
+// alignRight pads every line of a page so right-to-left text hugs the right
+// margin, the way it would be set on paper.
+func alignRight(page string, width int) string {
+	lines := strings.Split(page, "\n")
+	for i, line := range lines {
+		if pad := width - visibleWidth(line); pad > 0 && line != "" {
+			lines[i] = strings.Repeat(" ", pad) + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
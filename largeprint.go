@@ -0,0 +1,26 @@
+package main
+
+import "strings"
+
+const (
+	lineDoubleTop    = "\x1b#3"
+	lineDoubleBottom = "\x1b#4"
+	lineSingleWidth  = "\x1b#5"
+)
+
+func largePrintLines(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines)*2)
+	for _, line := range lines {
+		out = append(out, lineDoubleTop+line, lineDoubleBottom+line)
+	}
+	return strings.Join(out, "\n")
+}
+
+func singleWidthLines(view string) string {
+	lines := strings.Split(view, "\n")
+	for i, line := range lines {
+		lines[i] = lineSingleWidth + line
+	}
+	return strings.Join(lines, "\n")
+}
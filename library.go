@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const libraryFileName = "library.json"
+
+type LibraryEntry struct {
+	ID      string `json:"id,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Author  string `json:"author,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Edition string `json:"edition,omitempty"`
+	Preset  string `json:"preset,omitempty"`
+	// Language is the book's language code, from its metadata or detected
+	// from its text; edit it to correct either.
+	Language string `json:"language,omitempty"`
+	// Series and Volume place the book in a series, from its metadata or
+	// set by hand; titles such as "Volume 2" place it without them.
+	Series string `json:"series,omitempty"`
+	Volume int    `json:"volume,omitempty"`
+	// Collection lists each of Stories in the library as a book of its own.
+	Collection bool           `json:"collection,omitempty"`
+	Stories    []LibraryStory `json:"stories,omitempty"`
+}
+
+type Library struct {
+	Books map[string]LibraryEntry `json:"books"`
+}
+
+// loadLibrary reads the library file of a books directory. A file that
+// can't be read as a library is moved aside, to library.json.corrupt, and
+// the library starts again empty: the books are still in the directory.
+func loadLibrary(dir string) (Library, error) {
+	path := filepath.Join(dir, libraryFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Library{Books: make(map[string]LibraryEntry)}, nil
+		}
+		return Library{}, err
+	}
+
+	var lib Library
+	if err := json.Unmarshal(data, &lib); err != nil {
+		debugLog.Warn("library file corrupt, starting an empty one", "path", path, "err", err)
+		if err := os.Rename(path, path+".corrupt"); err != nil {
+			return Library{}, err
+		}
+		return Library{Books: make(map[string]LibraryEntry)}, nil
+	}
+	if lib.Books == nil {
+		lib.Books = make(map[string]LibraryEntry)
+	}
+	return lib, nil
+}
+
+func saveLibrary(dir string, lib Library) error {
+	data, err := json.MarshalIndent(lib, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, libraryFileName), data)
+}
+
+func (l Library) pathForID(dir, id string) (string, bool) {
+	for name, entry := range l.Books {
+		if entry.ID != id {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
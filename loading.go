@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// loading is the network or disk operation the screen is waiting for.
+type loading struct {
+	id     int
+	label  string
+	since  time.Time
+	active bool
+	cancel context.CancelFunc
+}
+
+// loadCmd is an operation run by startLoading. Its context is cancelled
+// when the screen stops waiting for it.
+type loadCmd func(ctx context.Context) tea.Msg
+
+// loadedMsg carries the result of a loading operation, tagged with its id
+// so a result that arrives after esc is dropped.
+type loadedMsg struct {
+	id  int
+	msg tea.Msg
+}
+
+// startLoading runs cmd with a spinner, the time spent and an esc hint in
+// the status line until its result arrives.
+func (m *model) startLoading(label string, cmd loadCmd) tea.Cmd {
+	if m.loading.cancel != nil {
+		m.loading.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.loading = loading{id: m.loading.id + 1, label: label, since: time.Now(), active: true, cancel: cancel}
+	m.status = ""
+	id := m.loading.id
+	return tea.Batch(func() tea.Msg { return loadedMsg{id: id, msg: cmd(ctx)} }, m.spinner.Tick)
+}
+
+func (m model) updateLoaded(msg loadedMsg) (tea.Model, tea.Cmd) {
+	if !m.loading.active || msg.id != m.loading.id {
+		debugLog.Debug("dropped result of cancelled operation", "id", msg.id)
+		return m, nil
+	}
+	m.loading.active = false
+	m.loading.cancel()
+	return m.Update(msg.msg)
+}
+
+// cancelLoading stops waiting for the current operation and cancels its
+// requests, including one waiting on a rate limited host.
+func (m *model) cancelLoading() {
+	m.loading.active = false
+	m.loading.cancel()
+	m.status = trf("Cancelled: %s", m.loading.label)
+}
+
+func (m model) loadingLine() string {
+	if note := m.rateLimitNote(); note != "" {
+		return trf("%s%s… %s · esc cancels", m.spinner.View(), note, time.Since(m.loading.since).Truncate(time.Second))
+	}
+	return trf("%s%s… %s · esc cancels", m.spinner.View(), m.loading.label, time.Since(m.loading.since).Truncate(time.Second))
+}
+
+// statusText is the status line of screens that show one: the loading
+// indicator while an operation is running, the last status otherwise.
+func (m model) statusText() string {
+	if m.loading.active {
+		return m.loadingLine()
+	}
+	return m.status
+}
+
+// footerLine is the key hint at the bottom of list screens, replaced by the
+// loading indicator while an operation is running.
+func (m model) footerLine(hint string) string {
+	if m.loading.active {
+		return metaStyle().Render(m.loadingLine())
+	}
+	return helpLine(hint)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// locationBytes is the size of a location: the book's cleaned text cut in
+// fixed chunks, so a location names the same text whatever the page size,
+// font scale or device, as long as the text filters are the same.
+const locationBytes = 150
+
+var textWordRe = regexp.MustCompile(`[^\s` + verseMark + `]+`)
+
+var goToLocationRe = regexp.MustCompile(`^(?:(?:go\s*to|ir\s*a)\s*)?(?:loc(?:ation)?|pos(?:ici[oó]n)?)\.?\s*(\d+)$`)
+
+// textWords counts the words of text as the pages show them, without
+// styles or verse marks.
+func textWords(text string) int {
+	return len(strings.Fields(strings.ReplaceAll(stripStyles(text), verseMark, " ")))
+}
+
+// wordOffset is the byte offset in text where word n (from 0) starts, or
+// the end of the text when it has fewer words.
+func wordOffset(text string, n int) int {
+	for _, span := range textWordRe.FindAllStringIndex(text, -1) {
+		if stripStyles(text[span[0]:span[1]]) == "" {
+			continue
+		}
+		if n == 0 {
+			return span[0]
+		}
+		n--
+	}
+	return len(text)
+}
+
+// pageWordsBefore counts the chapter's words on the pages before page,
+// leaving out the chapter title that heads its first page.
+func pageWordsBefore(book Book, index, page int) int {
+	start, _ := chapterPageRange(book, index)
+	words := 0
+	for _, p := range book.chapterPages(index)[:max(page-start, 0)] {
+		words += textWords(p)
+	}
+	return max(words-textWords(book.Chapters[index].Title), 0)
+}
+
+// pageLocation is the location where a page starts, from 1. Finding it
+// reads the chapter, so it is kept until the page size changes: the status
+// bar and the presence file ask for it all the time.
+func pageLocation(book Book, page int) int {
+	index := chapterForPage(book, page)
+	if index < 0 || book.text == nil {
+		return 0
+	}
+	t := book.text
+	t.mu.Lock()
+	location, ok := t.locations[page]
+	width, lines := t.width, t.lines
+	t.mu.Unlock()
+	if ok {
+		return location
+	}
+
+	ch := book.Chapters[index]
+	offset := wordOffset(book.ChapterText(index), pageWordsBefore(book, index, page))
+	location = 1 + int((ch.offset+int64(offset))/locationBytes)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.locations != nil && t.width == width && t.lines == lines {
+		t.locations[page] = location
+	}
+	return location
+}
+
+// bookLocations is the number of locations in the book.
+func bookLocations(book Book) int {
+	if len(book.Chapters) == 0 {
+		return 0
+	}
+	last := book.Chapters[len(book.Chapters)-1]
+	return 1 + int(max(last.offset+last.size-1, 0)/locationBytes)
+}
+
+// locationPage is the page showing the start of a location.
+func locationPage(book Book, location int) int {
+	target := int64(max(location-1, 0)) * locationBytes
+	index := len(book.Chapters) - 1
+	for i, ch := range book.Chapters {
+		if target < ch.offset+ch.size {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return 0
+	}
+	ch := book.Chapters[index]
+	text := book.ChapterText(index)
+	words := textWords(text[:min(max(target-ch.offset, 0), int64(len(text)))]) + textWords(ch.Title)
+	start, end := chapterPageRange(book, index)
+	for i, p := range book.chapterPages(index) {
+		if words -= textWords(p); words < 0 {
+			return start + i
+		}
+	}
+	return max(end-1, start)
+}
+
+// locationCommands are the palette commands for "loc 1234": going to a
+// location cited from another device or layout.
+func (m model) locationCommands(query string) []paletteCommand {
+	match := goToLocationRe.FindStringSubmatch(query)
+	total := bookLocations(m.currentBook)
+	if match == nil || m.currentBook.PageCount() == 0 || total == 0 {
+		return nil
+	}
+	location, _ := strconv.Atoi(match[1])
+	location = min(max(location, 1), total)
+	return []paletteCommand{{
+		label: trf("go to location %d of %d", location, total),
+		run: func(m model) (tea.Model, tea.Cmd) {
+			m.mode = modeReader
+			cmd := m.turnPage(locationPage(m.currentBook, location))
+			return m, cmd
+		},
+	}}
+}
+
+// pageAnchor is where a page starts in the text, to find it again after
+// the book is paginated at another size: its chapter, the chapter's words
+// before it and its first line.
+type pageAnchor struct {
+	chapter int
+	words   int
+	line    string
+}
+
+func anchorAt(book Book, page int) (pageAnchor, bool) {
+	index := chapterForPage(book, page)
+	if index < 0 || book.text == nil || page >= book.PageCount() {
+		return pageAnchor{}, false
+	}
+	a := pageAnchor{chapter: index, words: pageWordsBefore(book, index, page)}
+	for _, line := range strings.Split(book.Page(page), "\n") {
+		if line = normalizedText(line); line != "" {
+			a.line = line
+			break
+		}
+	}
+	return a, true
+}
+
+// anchorPage is the page of the new layout the anchor's words start on,
+// found by binary search over the words before each page of its chapter.
+// A first line that wraps differently can still fall on the page before
+// or after; then the page that holds the line wins.
+func anchorPage(book Book, a pageAnchor) (int, bool) {
+	if a.chapter < 0 || a.chapter >= len(book.Chapters) || book.text == nil {
+		return 0, false
+	}
+	pages := book.chapterPages(a.chapter)
+	if len(pages) == 0 {
+		return 0, false
+	}
+	title := textWords(book.Chapters[a.chapter].Title)
+	before := make([]int, len(pages))
+	for i := 1; i < len(pages); i++ {
+		before[i] = before[i-1] + textWords(pages[i-1])
+	}
+	found := sort.Search(len(pages), func(i int) bool { return max(before[i]-title, 0) > a.words }) - 1
+	found = max(found, 0)
+	if a.line != "" && !strings.Contains(normalizedText(pages[found]), a.line) {
+		for _, near := range []int{found + 1, found - 1} {
+			if near >= 0 && near < len(pages) && strings.Contains(normalizedText(pages[near]), a.line) {
+				found = near
+				break
+			}
+		}
+	}
+	start, _ := chapterPageRange(book, a.chapter)
+	return start + found, true
+}
+
+// normalizedText is text without styles and with its spaces collapsed,
+// for comparing text wrapped at different widths.
+func normalizedText(text string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(stripStyles(text), verseMark, " ")), " ")
+}
@@ -1,38 +1,106 @@
 package main
 
 import (
-	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-//go:embed all.txt
-var authorsData string
+// subcommands run without the interface, with the config of the profile in
+// GUTBERG_PROFILE.
+var subcommands = map[string]func(cfg Config, args []string) error{
+	"serialize":      runSerialize,
+	"remote":         func(cfg Config, _ []string) error { return runRemote(cfg) },
+	"cat":            runCat,
+	"list":           runList,
+	"search":         runSearch,
+	"status":         runStatus,
+	"stats":          runStats,
+	"download":       runDownload,
+	"export":         runExport,
+	"update-authors": runUpdateAuthors,
+}
 
 func main() {
+	setUILanguage(uiAuto)
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			runSubcommand(run, os.Args[2:])
+			return
+		}
+	}
+
+	pdfPath := flag.String("pdf", "", tr("import a PDF into the library (needs pdftotext)"))
+	debug := flag.Bool("debug", false, tr("write a detailed log to log_file"))
+	record := flag.String("record", "", tr("record every HTTP request to this file (cassette)"))
+	replay := flag.String("replay", "", tr("answer HTTP requests from a recorded cassette, without network"))
+	offlineFlag := flag.Bool("offline", false, tr("work without the network: no searches, downloads or sync"))
+	profileFlag := flag.String("profile", "", tr("use a profile with its own progress, library and settings (or GUTBERG_PROFILE)"))
 	if len(os.Args) > 1 {
 		flag.Usage = func() {
-			fmt.Println("Uso: gutberg (sin argumentos)")
+			fmt.Println(tr("Usage: gutberg [-profile name] [-pdf book.pdf] [-debug] [-offline] [-record|-replay cassette.json]\n       gutberg serialize -book 2600 [-chunk 10min] (see gutberg serialize -h)\n       gutberg remote (controls a gutberg in presentation mode)\n       gutberg cat <book> [-chapter N] (prints the text)\n       gutberg list|search|status|stats [-json] (library, books, reading)\n       gutberg download [-events] <book>... (fills the library in a batch)\n       gutberg export <book> [-format md|txt] [-o file] (Markdown or plain text)\n       gutberg update-authors (fetches the author list from the Gutenberg catalog)"))
 		}
 		flag.Parse()
 	}
 
-	cfg, err := loadConfig()
+	profile, err := resolveProfile(*profileFlag)
 	if err != nil {
-		exitErr(fmt.Errorf("load config: %w", err))
+		exitErr(err)
+	}
+	cfg, err := loadConfig(profile)
+	if err != nil {
+		exitErr(fmt.Errorf(tr("load config: %w"), err))
 	}
+	setUILanguage(cfg.UILanguage)
+	catalogLanguage = cfg.Language
+	if *debug {
+		cfg.LogLevel = "debug"
+	}
+	closeLog, err := configureLogging(cfg.LogFile, cfg.LogLevel)
+	if err != nil {
+		exitErr(err)
+	}
+	defer closeLog()
+	if err := configureCassette(*record, *replay); err != nil {
+		exitErr(err)
+	}
+	configureNetwork(cfg)
+	if *offlineFlag {
+		netAudit.setOffline(true)
+	}
+	configureContentFilter(cfg)
+	if err := configureFilters(cfg.Filters); err != nil {
+		exitErr(err)
+	}
+	bookTextDir = filepath.Join(cfg.CacheDir, "books")
+	// A cached page would keep its request out of a recording, or out of
+	// the one replayed.
+	if *record == "" && *replay == "" {
+		pageCacheDir = filepath.Join(cfg.CacheDir, "pages")
+		go prunePageCache()
+	}
+	setColorMode(cfg.Colors)
+	setTheme(cfg.Theme)
 
-	authors, err := loadAuthorsFromEmbedded(authorsData)
+	authors, err := loadAuthors(cfg)
 	if err != nil {
-		exitErr(fmt.Errorf("load authors: %w", err))
+		exitErr(fmt.Errorf(tr("load authors: %w"), err))
 	}
 
-	state, err := loadState(cfg.StateFile)
+	state, err := loadStateFrom(cfg)
 	if err != nil {
-		exitErr(fmt.Errorf("load state: %w", err))
+		exitErr(fmt.Errorf(tr("load state: %w"), err))
+	}
+	if *pdfPath != "" {
+		path, err := importPDF(cfg, *pdfPath)
+		if err != nil {
+			exitErr(fmt.Errorf(tr("import pdf: %w"), err))
+		}
+		state.CurrentBook = path
 	}
 
 	m, err := newModel(cfg, state, authors)
@@ -40,8 +108,51 @@ func main() {
 		exitErr(err)
 	}
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	guard := newCrashGuard(m)
+	options := []tea.ProgramOption{tea.WithAltScreen()}
+	if cfg.Mouse {
+		options = append(options, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(guard, options...)
+	fmt.Print(colorSchemeReportsOn)
+	_, err = p.Run()
+	stopSpeech(-1)
+	fmt.Print(colorSchemeReportsOff)
+	removePresence(cfg.CacheDir)
+	if err != nil {
+		exitErr(err)
+	}
+	if *guard.report != "" {
+		exitErr(errors.New(crashNotice(*guard.report)))
+	}
+}
+
+func runSubcommand(run func(cfg Config, args []string) error, args []string) {
+	profile, err := resolveProfile("")
+	if err != nil {
+		exitErr(err)
+	}
+	cfg, err := loadConfig(profile)
+	if err != nil {
+		exitErr(fmt.Errorf(tr("load config: %w"), err))
+	}
+	setUILanguage(cfg.UILanguage)
+	catalogLanguage = cfg.Language
+	closeLog, err := configureLogging(cfg.LogFile, cfg.LogLevel)
+	if err != nil {
+		exitErr(err)
+	}
+	defer closeLog()
+	bookTextDir = filepath.Join(cfg.CacheDir, "books")
+	pageCacheDir = filepath.Join(cfg.CacheDir, "pages")
+	configureNetwork(cfg)
+	configureContentFilter(cfg)
+	if err := configureFilters(cfg.Filters); err != nil {
+		exitErr(err)
+	}
+	if err := run(cfg, args); err != nil {
+		debugLog.Error("command failed", "err", err)
+		closeLog()
 		exitErr(err)
 	}
 }
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	marginWidth = 24
+	marginGap   = 3
+	// marginMinWidth is the terminal width from which the reader keeps a
+	// column for the margin, leaving pages of about 80 columns.
+	marginMinWidth = 110
+	// marginQuoteLine is the length a page line needs to tell which quotes
+	// are on the page; shorter lines, as "the end.", match too many.
+	marginQuoteLine = 20
+)
+
+// marginFits reports whether pages are laid out leaving room for the margin
+// column: on wide terminals, unless a translation is shown side by side or
+// the print is large.
+func (m model) marginFits() bool {
+	return m.width >= marginMinWidth && !m.parallel && !m.largePrint()
+}
+
+// showMargin reports whether the margin column is shown next to the page,
+// which glosses replace.
+func (m model) showMargin() bool {
+	return m.marginFits() && !(m.glossing && m.dict != nil)
+}
+
+// marginNotes are the notes beside each line of the page: where a chapter
+// starts and ends, and the lines of quotes saved from the book.
+func (m model) marginNotes(page string) []string {
+	lines := strings.Split(page, "\n")
+	notes := make([]string, len(lines))
+	book := m.currentBook
+	if index := chapterForPage(book, m.state.Page); index >= 0 {
+		ch := book.Chapters[index]
+		if m.state.Page == ch.StartPage {
+			title := ch.Title
+			if title == "" {
+				title = trf("Chapter %d", index+1)
+			}
+			notes[0] = "§ " + title
+		}
+		if m.state.Page == ch.StartPage+ch.Pages-1 && index+1 < len(book.Chapters) {
+			last := len(lines) - 1
+			for last > 0 && strings.TrimSpace(lines[last]) == "" {
+				last--
+			}
+			if notes[last] == "" {
+				notes[last] = "§ " + tr("end of chapter")
+			}
+		}
+	}
+
+	quotes := m.pageQuotes(lines)
+	quoted := false
+	for i, line := range lines {
+		text := strings.Join(strings.Fields(line), " ")
+		inQuote := false
+		for _, q := range quotes {
+			if text != "" && strings.Contains(q, text) {
+				inQuote = true
+				break
+			}
+		}
+		if inQuote && notes[i] == "" {
+			notes[i] = "│"
+			if !quoted {
+				notes[i] = "│ " + tr("quote")
+			}
+		}
+		quoted = inQuote
+	}
+	return notes
+}
+
+// pageQuotes are the texts, with spaces collapsed, of the book's quotes
+// that a long line of the page is part of.
+func (m model) pageQuotes(lines []string) []string {
+	var found []string
+	for _, q := range m.bookQuotes {
+		text := strings.Join(strings.Fields(q.Text), " ")
+		for _, line := range lines {
+			line = strings.Join(strings.Fields(line), " ")
+			if len([]rune(line)) >= marginQuoteLine && strings.Contains(text, line) {
+				found = append(found, text)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// withMargin puts the margin notes to the right of the rendered page.
+func (m model) withMargin(content, page string) string {
+	notes := m.marginNotes(page)
+	for i, note := range notes {
+		if note != "" {
+			notes[i] = metaStyle().Render(truncateVisible(note, marginWidth))
+		}
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, content, strings.Repeat(" ", marginGap), strings.Join(notes, "\n"))
+}
+
+// loadBookQuotes keeps the quotes saved from the open book, for the margin.
+func (m *model) loadBookQuotes() {
+	m.bookQuotes = nil
+	quotes, err := loadQuotes(m.store)
+	if err != nil {
+		return
+	}
+	for _, q := range quotes {
+		if q.Book == m.state.CurrentBook {
+			m.bookQuotes = append(m.bookQuotes, q)
+		}
+	}
+}
@@ -0,0 +1,556 @@
+package main
+
+var messagesES = map[string]string{
+	// Reader
+	"Page {page}/{pages} · loc {location} · {chapter} {chapter_page}/{chapter_pages} · {percent}% · ~{minutes_left} min left": "Página {page}/{pages} · pos. {location} · {chapter} {chapter_page}/{chapter_pages} · {percent}% · ~{minutes_left} min restantes",
+	"Enter/Space: next  pgup: prev  +/-: size  c: chapters  b: library  ?: help  q: quit":                                     "Enter/Espacio: siguiente  pgup: anterior  +/-: tamaño  c: capítulos  b: biblioteca  ?: ayuda  q: salir",
+	"No pages available.": "No hay páginas.",
+	"Chapter %d":          "Capítulo %d",
+	"No dictionary for %s: add one to the [dictionaries] table of the config": "No hay diccionario para %s: añade uno a la tabla [dictionaries] de la configuración",
+	"Loading dictionary":          "Cargando diccionario",
+	"Every chapter has been read": "Ya se han leído todos los capítulos",
+	"No translation paired: open the original, then press x on the translation in the library": "No hay traducción emparejada: abre el original y pulsa x sobre la traducción en la biblioteca",
+	"Paired %s with %s (v in the reader shows both)":                                           "%s emparejado con %s (v en el lector muestra ambos)",
+	"Removed the translation of %s":                                                            "Quitada la traducción de %s",
+	"Filters for %s: %s (applies when the book is opened)":                                     "Filtros de %s: %s (se aplican al abrir el libro)",
+	"Sending %s...": "Enviando %s...",
+	"Sent %s to %s": "%s enviado a %s",
+
+	// Auto page turn and sleep timer
+	"Turning pages automatically (%s for this page)":             "Pasando páginas automáticamente (%s para esta página)",
+	"Automatic page turns off":                                   "Paso automático de páginas desactivado",
+	"End of the book, automatic page turns off":                  "Fin del libro, paso automático de páginas desactivado",
+	"gutberg will save and quit in %d minutes":                   "gutberg guardará y se cerrará en %d minutos",
+	"Sleep timer off":                                            "Temporizador desactivado",
+	"Sleep timer: saving and quitting in a minute (z to extend)": "Temporizador: se guarda y se cierra en un minuto (z para alargarlo)",
+	"sleep in %d min":                                            "dormir en %d min",
+
+	// Goals and schedule
+	"Daily goal reached · %d-day streak": "Objetivo diario cumplido · racha de %d días",
+	" · %d-day streak":                   " · racha de %d días",
+	"%d/%d pages":                        "%d/%d páginas",
+	"%d/%d min":                          "%d/%d min",
+	"today %s":                           "hoy %s",
+	"today's goal met ✓":                 "objetivo de hoy cumplido ✓",
+	"Finish in %d days: %s":              "Terminar en %d días: %s",
+	"Reading schedule removed":           "Plan de lectura eliminado",
+	"Behind schedule: %s":                "Vas con retraso: %s",
+	"day %d/%d":                          "día %d/%d",
+	": read to page %d (%d to go)":       ": lee hasta la página %d (faltan %d)",
+	": today's pages read":               ": páginas de hoy leídas",
+	", %d %s behind":                     ", %d %s de retraso",
+	"day":                                "día",
+	"days":                               "días",
+	"schedule done, book finished":       "plan cumplido, libro terminado",
+	"schedule over %d days ago, %d pages left": "el plan acabó hace %d días, quedan %d páginas",
+
+	// Duplicate downloads
+	"Already downloaded":                                           "Ya descargado",
+	"%s is already in the library as %s.":                          "%s ya está en la biblioteca como %s.",
+	"The library has a book with the same title: %s.":              "La biblioteca tiene un libro con el mismo título: %s.",
+	"o/enter: open it  r: replace it  n/esc: cancel":               "o/enter: abrirlo  r: reemplazarlo  n/esc: cancelar",
+	"o/enter: open it  r: replace it  k: keep both  n/esc: cancel": "o/enter: abrirlo  r: reemplazarlo  k: conservar ambos  n/esc: cancelar",
+
+	// Presentation mode
+	"Presentation mode off": "Modo presentación desactivado",
+	"Presenting: →/space next sentence, ← previous, P to leave; gutberg remote controls it from another pane": "Presentando: →/espacio frase siguiente, ← anterior, P para salir; gutberg remote lo controla desde otro panel",
+	"%s · page %d/%d · sentence %d/%d":                      "%s · página %d/%d · frase %d/%d",
+	"no gutberg is presenting: press P in the reader first": "ningún gutberg está presentando: pulsa P en el lector primero",
+	"sent %s":                "enviado %s",
+	"Remote for %s (pid %d)": "Mando de %s (pid %d)",
+	"→/space: next sentence  ←: previous  pgdn/pgup: page  q: quit": "→/espacio: frase siguiente  ←: anterior  pgdn/pgup: página  q: salir",
+	"present": "presentar",
+
+	// Notifications
+	"Quiet mode off":                     "Modo silencioso desactivado",
+	"Quiet mode on: no bells or flashes": "Modo silencioso activado: sin campanas ni destellos",
+
+	// Following other instances
+	"This book is also open in another gutberg (pid %d) · F to follow its page turns": "Este libro también está abierto en otro gutberg (pid %d) · F para seguir sus páginas",
+	"Stopped following: the other gutberg has closed":                                 "Se dejó de seguir: el otro gutberg se ha cerrado",
+	"Stopped following: the other gutberg opened %s":                                  "Se dejó de seguir: el otro gutberg abrió %s",
+	"Stopped following":                                              "Se dejó de seguir",
+	"No other gutberg has this book open":                            "Ningún otro gutberg tiene este libro abierto",
+	"Following gutberg pid %d: its page turns show here (F to stop)": "Siguiendo a gutberg pid %d: sus páginas se muestran aquí (F para parar)",
+
+	// Library and lists
+	"Library":                        "Biblioteca",
+	"Books":                          "Libros",
+	"Chapters":                       "Capítulos",
+	"Authors":                        "Autores",
+	"Authors: %s":                    "Autores: %s",
+	"OPDS Feeds":                     "Catálogos OPDS",
+	"Author index":                   "Índice de autores",
+	"Author name (e.g. lorca)":       "Nombre del autor (p. ej. lorca)",
+	"Gutenberg Reader":               "Lector de Gutenberg",
+	"Enter an author name to search": "Escribe el nombre de un autor para buscar",
+	"Search authors, or use author: title: subject: lang: century: work: fields": "Busca autores, o usa los campos author: title: subject: lang: century: work:",
+	"Source: %s (%d/%d)":    "Fuente: %s (%d/%d)",
+	"%d authors wrote %q":   "%d autores escribieron %q",
+	"%d books":              "%d libros",
+	" · ~%d min":            " · ~%d min",
+	" · started":            " · empezado",
+	" · finished %s":        " · terminado %s",
+	" · filters: %s":        " · filtros: %s",
+	" (plain text edition)": " (edición en texto plano)",
+	"/: filter  ":           "/: filtrar  ",
+	"More...":               "Más...",
+	"next page":             "página siguiente",
+	"Deleted %s":            "%s borrado",
+	"Reloaded %d authors and %d library books": "Recargados %d autores y %d libros de la biblioteca",
+	"Reloading authors and library":            "Recargando autores y biblioteca",
+	"No OPDS feeds configured":                 "No hay catálogos OPDS configurados",
+
+	// Stories and collections
+	"%s has no chapters to read as stories":      "%s no tiene capítulos que leer como relatos",
+	"%s has no story %d":                         "%s no tiene relato %d",
+	"%s is one book again":                       "%s vuelve a ser un solo libro",
+	"%s: %d stories, each with its own progress": "%s: %d relatos, cada uno con su propio progreso",
+	"story %d/%d · %d words":                     "relato %d/%d · %d palabras",
+
+	// Discover, featured and popular
+	"Discover · %s":                  "Descubrir · %s",
+	"Featured today":                 "Destacado de hoy",
+	"Fetching today's featured book": "Buscando el libro destacado de hoy",
+	"Random pick":                    "Elección al azar",
+	"Picking a random book":          "Eligiendo un libro al azar",
+	"Popular · ":                     "Populares · ",
+	"yesterday":                      "ayer",
+	"last 7 days":                    "últimos 7 días",
+	"last 30 days":                   "últimos 30 días",
+	"%s downloads":                   "%s descargas",
+	"%d downloads":                   "%d descargas",
+
+	// Loading
+	"%s%s… %s · esc cancels":  "%s%s… %s · esc cancela",
+	"Cancelled: %s":           "Cancelado: %s",
+	"Loading %s":              "Cargando %s",
+	"Loading book":            "Cargando libro",
+	"Loading feed":            "Cargando catálogo",
+	"Loading feed...":         "Cargando catálogo...",
+	"Loading more results...": "Cargando más resultados...",
+	"Loading popular books":   "Cargando libros populares",
+	"Looking up authors":      "Buscando autores",
+	"Searching books":         "Buscando libros",
+	"Downloading book...":     "Descargando libro...",
+
+	// Command palette
+	"Commands":                                  "Órdenes",
+	"No matching command":                       "Ninguna orden coincide",
+	"command, or a page number":                 "orden, o un número de página",
+	"enter: run  ↑/↓: choose  esc: close":       "enter: ejecutar  ↑/↓: elegir  esc: cerrar",
+	"toggle %s (%s)":                            "cambiar %s (%s)",
+	"delete book ":                              "borrar libro ",
+	"Delete %s and its reading progress? (y/n)": "¿Borrar %s y su progreso de lectura? (y/n)",
+	"go to page %d of %d":                       "ir a la página %d de %d",
+
+	// Help and key tester
+	"↑/↓ pgup/pgdn: scroll  %3.f%%  ?/esc: close": "↑/↓ pgup/pgdn: desplazar  %3.f%%  ?/esc: cerrar",
+	"Key tester": "Probador de teclas",
+	"Mode: %s":   "Modo: %s",
+	"Press a key to see what it does in this mode.": "Pulsa una tecla para ver qué hace en este modo.",
+	"not bound":                             "sin asignar",
+	"tab: next mode  esc: back to settings": "tab: modo siguiente  esc: volver a los ajustes",
+
+	// Modes
+	"search":   "búsqueda",
+	"library":  "biblioteca",
+	"books":    "libros",
+	"reader":   "lector",
+	"chapters": "capítulos",
+	"feeds":    "catálogos",
+	"privacy":  "privacidad",
+	"settings": "ajustes",
+	"index":    "índice",
+	"discover": "descubrir",
+
+	// Actions
+	"quit":             "salir",
+	"back":             "volver",
+	"open":             "abrir",
+	"next source":      "fuente siguiente",
+	"prev page":        "página anterior",
+	"first page":       "primera página",
+	"last page":        "última página",
+	"bigger text":      "texto más grande",
+	"smaller text":     "texto más pequeño",
+	"up":               "arriba",
+	"down":             "abajo",
+	"toggle":           "cambiar",
+	"key tester":       "probador de teclas",
+	"author index":     "índice de autores",
+	"left":             "izquierda",
+	"right":            "derecha",
+	"send book":        "enviar libro",
+	"export":           "exportar",
+	"detect colors":    "detectar colores",
+	"copy":             "copiar",
+	"featured":         "destacado",
+	"popular":          "populares",
+	"large print":      "letra grande",
+	"filters":          "filtros",
+	"parallel":         "texto paralelo",
+	"pair translation": "emparejar traducción",
+	"gloss":            "glosario",
+	"next paragraph":   "párrafo siguiente",
+	"prev paragraph":   "párrafo anterior",
+	"first unread":     "primer capítulo sin leer",
+	"collection":       "colección",
+	"schedule":         "plan de lectura",
+	"help":             "ayuda",
+	"palette":          "paleta de órdenes",
+	"next chapter":     "capítulo siguiente",
+	"prev chapter":     "capítulo anterior",
+	"refresh":          "recargar",
+	"auto turn":        "paso automático",
+	"sleep timer":      "temporizador",
+	"quiet":            "silencio",
+	"follow":           "seguir",
+
+	// List component
+	"go to start":  "ir al principio",
+	"go to end":    "ir al final",
+	"filter":       "filtrar",
+	"clear filter": "quitar filtro",
+	"cancel":       "cancelar",
+	"apply filter": "aplicar filtro",
+	"more":         "más",
+	"close help":   "cerrar ayuda",
+	"Filter: ":     "Filtro: ",
+	"item":         "elemento",
+	"items":        "elementos",
+
+	// Settings
+	"Settings":            "Ajustes",
+	"Interface language":  "Idioma de la interfaz",
+	"Theme":               "Tema",
+	"Colors":              "Colores",
+	"Search language":     "Idioma de búsqueda",
+	"Books directory":     "Carpeta de libros",
+	"Export directory":    "Carpeta de exportación",
+	"Keymap profile":      "Perfil de teclas",
+	"Reading speed (WPM)": "Velocidad de lectura (PPM)",
+	"Auto page turn (seconds, 0: by reading speed)": "Paso automático (segundos, 0: según la velocidad)",
+	"Daily goal (pages, 0: none)":                   "Objetivo diario (páginas, 0: ninguno)",
+	"Daily goal (minutes, 0: none)":                 "Objetivo diario (minutos, 0: ninguno)",
+	"Reader header":                                 "Cabecera del lector",
+	"Reader status bar":                             "Barra de estado del lector",
+	"Reader footer":                                 "Pie del lector",
+	"Large print":                                   "Letra grande",
+	"Page transition":                               "Transición de página",
+	"Notifications":                                 "Avisos",
+	"Quiet mode":                                    "Modo silencioso",
+	"Text filters":                                  "Filtros de texto",
+	"Keep license and notes":                        "Conservar licencia y notas",
+	"(none)":                                        "(ninguno)",
+	"(any)":                                         "(cualquiera)",
+	"on":                                            "sí",
+	"off":                                           "no",
+	"Print edition removed":                         "Edición impresa olvidada",
+	"Print edition of %d pages: this is print page ~%d":                    "Edición impresa de %d páginas: esta es la página impresa ~%d",
+	"go to print page ~%d of %d":                                           "ir a la página impresa ~%d de %d",
+	"print edition has %d pages":                                           "la edición impresa tiene %d páginas",
+	"forget the print edition":                                             "olvidar la edición impresa",
+	"press %s to turn the page":                                            "pulsa %s para pasar la página",
+	"press %s to go back a page":                                           "pulsa %s para volver una página",
+	"press %s and pick a chapter with enter":                               "pulsa %s y elige un capítulo con enter",
+	"press %s to make the text bigger":                                     "pulsa %s para agrandar el texto",
+	"press %s to list every key":                                           "pulsa %s para ver todas las teclas",
+	"press %s to open the command palette":                                 "pulsa %s para abrir la paleta de órdenes",
+	"press %s, type theme and press enter":                                 "pulsa %s, escribe tema y pulsa enter",
+	"press %s, type loc 1 and press enter":                                 "pulsa %s, escribe pos 1 y pulsa enter",
+	"press %s to go to your library":                                       "pulsa %s para ir a tu biblioteca",
+	"Tutorial complete! %s searches Project Gutenberg for your first book": "¡Tutorial terminado! %s busca en el Proyecto Gutenberg tu primer libro",
+	"Opening the tutorial":                                                 "Abriendo el tutorial",
+	"start the tutorial":                                                   "empezar el tutorial",
+	"go to location %d of %d":                                              "ir a la posición %d de %d",
+	"print page %s":                                                        "página impresa %s",
+	"ask":                                                                  "preguntar",
+	"HTML with images":                                                     "HTML con imágenes",
+	"HTML without images":                                                  "HTML sin imágenes",
+	"Plain text (UTF-8)":                                                   "Texto plano (UTF-8)",
+	"Looking up formats":                                                   "Buscando formatos",
+	"Choose a format":                                                      "Elige un formato",
+	"Downloading %s from now on; change it in settings":                    "A partir de ahora se descarga %s; cámbialo en los ajustes",
+	"enter: download  a: always this format  ↑/↓: choose  esc: cancel": "enter: descargar  a: siempre este formato  ↑/↓: elegir  esc: cancelar",
+	"unknown download format %q": "formato de descarga desconocido %q",
+	"Download format":            "Formato de descarga",
+	"auto":                       "automático",
+	"bell":                       "campana",
+	"flash":                      "destello",
+	"none":                       "ninguno",
+	"slide":                      "deslizar",
+	"fade":                       "fundido",
+	"en":                         "inglés",
+	"es":                         "español",
+	"enter: save  esc: cancel":   "enter: guardar  esc: cancelar",
+	"Key conflicts":              "Conflictos de teclas",
+	"Saved to ":                  "Guardado en ",
+
+	// Privacy
+	"Privacy":                                    "Privacidad",
+	"Book search":                                "Búsqueda de libros",
+	"Book downloads":                             "Descarga de libros",
+	"OPDS catalog browsing":                      "Exploración de catálogos OPDS",
+	"Cover thumbnails":                           "Miniaturas de portadas",
+	"Send books by email (SMTP)":                 "Envío de libros por correo (SMTP)",
+	"Reading state sync server":                  "Servidor de sincronización del progreso",
+	"state_store = \"remote\" needs a state_url": "state_store = \"remote\" necesita un state_url",
+	"unknown state_store %q: use file, sqlite or remote": "state_store desconocido %q: usa file, sqlite o remote",
+	"sync server: %w (saved locally)":                    "servidor de sincronización: %w (guardado en local)",
+	"Recent requests":                                    "Peticiones recientes",
+	"No requests this session.":                          "No hay peticiones en esta sesión.",
+	"Full log: ":                                         "Registro completo: ",
+	"blocked":                                            "bloqueada",
+	"error":                                              "error",
+
+	// Crashes
+	"gutberg closed unexpectedly":               "gutberg se cerró inesperadamente",
+	"Reopen %s at chapter %d, %d%% through it?": "¿Reabrir %s en el capítulo %d, al %d%%?",
+	"Crash report: ":                            "Informe del fallo: ",
+	"y/enter: reopen  n/esc: continue":          "y/enter: reabrir  n/esc: continuar",
+	"Press any key to quit.":                    "Pulsa cualquier tecla para salir.",
+	"(not saved: %s)":                           "(no guardado: %s)",
+	"gutberg hit an internal error. Your reading position was saved and will be offered next time.\nCrash report: %s\n": "gutberg sufrió un error interno. Tu posición de lectura se guardó y se ofrecerá la próxima vez.\nInforme del fallo: %s\n",
+
+	// Errors
+	"%s %s is not in cassette %s":                                                  "%s %s no está en el cassette %s",
+	"-daily wants a time such as 07:30":                                            "-daily necesita una hora como 07:30",
+	"auto page turn must be a number of seconds, or 0 to follow the reading speed": "el paso automático debe ser un número de segundos, o 0 para seguir la velocidad de lectura",
+	"bad SMTP port %q":                                                             "puerto SMTP incorrecto %q",
+	"bad profile name %q":                                                          "nombre de perfil incorrecto %q",
+	"export directory cannot be empty":                                             "la carpeta de exportación no puede estar vacía",
+	"books directory cannot be empty":                                              "la carpeta de libros no puede estar vacía",
+	"cassette %s: %w":                                                              "cassette %s: %w",
+	"chunk must be minutes (10min) or words (1500w), not %q":                       "la entrega debe ser de minutos (10min) o palabras (1500w), no %q",
+	"chunk must be more than zero":                                                 "la entrega debe ser mayor que cero",
+	"create cassette: %w":                                                          "crear cassette: %w",
+	"daily page goal must be a number of pages, or 0 for none":                     "el objetivo diario debe ser un número de páginas, o 0 para ninguno",
+	"daily reading goal must be a number of minutes, or 0 for none":                "el objetivo diario debe ser un número de minutos, o 0 para ninguno",
+	"epub entry not found: %s":                                                     "entrada del epub no encontrada: %s",
+	"epub has no rootfile":                                                         "el epub no tiene rootfile",
+	"feed does not support search":                                                 "el catálogo no permite buscar",
+	"filter rule %q: %w":                                                           "regla de filtro %q: %w",
+	"import pdf: %w":                                                               "importar pdf: %w",
+	"language must be a 2 or 3 letter code, e.g. en":                               "el idioma debe ser un código de 2 o 3 letras, p. ej. es",
+	"load authors: %w":                                                             "cargar autores: %w",
+	"load config: %w":                                                              "cargar configuración: %w",
+	"load state: %w":                                                               "cargar estado: %w",
+	"network access for %s is disabled in privacy settings":                        "el acceso a la red para %s está desactivado en los ajustes de privacidad",
+	"no authors found for %q":                                                      "no se encontraron autores para %q",
+	"no book found":                                                                "no se encontró ningún libro",
+	"no popular books found":                                                       "no se encontraron libros populares",
+	"no text edition of %s on Project Runeberg":                                    "no hay edición de texto de %s en Project Runeberg",
+	"no works found in the Project Runeberg catalog":                               "no se encontraron obras en el catálogo de Project Runeberg",
+	"not a Project Runeberg work: %s":                                              "no es una obra de Project Runeberg: %s",
+	"open epub: %w":                                                                "abrir epub: %w",
+	"open log file: %w":                                                            "abrir registro: %w",
+	"parse %s: %w":                                                                 "analizar %s: %w",
+	"parse gallica results: %w":                                                    "analizar resultados de gallica: %w",
+	"parse opds feed: %w":                                                          "analizar catálogo opds: %w",
+	"parse opensearch description: %w":                                             "analizar descripción opensearch: %w",
+	"popular list %s not found":                                                    "lista de populares %s no encontrada",
+	"read cassette %s: %w":                                                         "leer cassette %s: %w",
+	"read cassette: %w":                                                            "leer cassette: %w",
+	"read online link not found":                                                   "enlace de lectura en línea no encontrado",
+	"reading speed must be between %d and %d":                                      "la velocidad de lectura debe estar entre %d y %d",
+	"run %s (set pdftotext in the config): %w":                                     "ejecutar %s (configura pdftotext): %w",
+	"serialize needs -book":                                                        "serialize necesita -book",
+	"set host and to in the [smtp] section of the config to send books":            "configura host y to en la sección [smtp] para enviar libros",
+	"set host and to in the [smtp] section of the config, or pass -smtp and -to": "configura host y to en la sección [smtp], o usa -smtp y -to",
+	"store book text: %w":                                        "guardar texto del libro: %w",
+	"unexpected status: %s":                                      "estado inesperado: %s",
+	"unknown color mode %q":                                      "modo de color desconocido %q",
+	"unknown filter preset %q":                                   "filtro desconocido %q",
+	"unknown interface language %q":                              "idioma de interfaz desconocido %q",
+	"unknown keymap profile %q":                                  "perfil de teclas desconocido %q",
+	"unknown log level %q (use off, error, warn, info or debug)": "nivel de registro desconocido %q (usa off, error, warn, info o debug)",
+	"unknown notification %q":                                    "aviso desconocido %q",
+	"unknown page transition %q":                                 "transición de página desconocida %q",
+	"unknown theme %q":                                           "tema desconocido %q",
+	"use either -record or -replay, not both":                    "usa -record o -replay, no los dos",
+
+	// Command line
+	"Usage: gutberg [-profile name] [-pdf book.pdf] [-debug] [-offline] [-record|-replay cassette.json]\n       gutberg serialize -book 2600 [-chunk 10min] (see gutberg serialize -h)\n       gutberg remote (controls a gutberg in presentation mode)\n       gutberg cat <book> [-chapter N] (prints the text)\n       gutberg list|search|status|stats [-json] (library, books, reading)\n       gutberg download [-events] <book>... (fills the library in a batch)\n       gutberg export <book> [-format md|txt] [-o file] (Markdown or plain text)\n       gutberg update-authors (fetches the author list from the Gutenberg catalog)": "Uso: gutberg [-profile nombre] [-pdf libro.pdf] [-debug] [-offline] [-record|-replay cassette.json]\n     gutberg serialize -book 2600 [-chunk 10min] (ver gutberg serialize -h)\n     gutberg remote (controla un gutberg en modo presentación)\n     gutberg cat <libro> [-chapter N] (imprime el texto)\n     gutberg list|search|status|stats [-json] (biblioteca, libros, lectura)\n     gutberg download [-events] <libro>... (llena la biblioteca de una vez)\n     gutberg export <libro> [-format md|txt] [-o archivo] (Markdown o texto plano)\n       gutberg update-authors (descarga la lista de autores del catálogo de Gutenberg)",
+	"[%d/%d] %s: already in the library (%s)":                                    "[%d/%d] %s: ya está en la biblioteca (%s)",
+	"Usage: gutberg download [-events] <book>... (- reads the books from stdin)": "Uso: gutberg download [-events] <libro>... (- lee los libros de la entrada estándar)",
+	"write JSON progress events to stderr, one per line":                         "escribe eventos de progreso JSON en stderr, uno por línea",
+	"download needs at least one book":                                           "download necesita al menos un libro",
+	"%d of %d books failed":                                                      "fallaron %d de %d libros",
+	"Usage: gutberg serialize -book 2600 [-chunk 10min] [-smtp host:port] [-to address] [-daily 07:00] [-force]": "Uso: gutberg serialize -book 2600 [-chunk 10min] [-smtp host:puerto] [-to correo] [-daily 07:00] [-force]",
+	"import a PDF into the library (needs pdftotext)":                                                            "importa un PDF a la biblioteca (requiere pdftotext)",
+	"write a detailed log to log_file":                                                                           "escribe un registro detallado en log_file",
+	"record every HTTP request to this file (cassette)":                                                          "graba todas las peticiones HTTP en este archivo (cassette)",
+	"answer HTTP requests from a recorded cassette, without network":                                             "responde a las peticiones HTTP desde un cassette grabado, sin red",
+	"use a profile with its own progress, library and settings (or GUTBERG_PROFILE)":                             "usa un perfil con su propio progreso, biblioteca y ajustes (o GUTBERG_PROFILE)",
+	"book to send: Gutenberg number, URL or library file":                                                        "libro a enviar: número de Gutenberg, URL o archivo de la biblioteca",
+	"size of each installment: reading minutes (10min) or words (1500w)":                                         "tamaño de cada entrega: minutos de lectura (10min) o palabras (1500w)",
+	"SMTP server host[:port], instead of the configured one":                                                     "servidor SMTP host[:puerto], en lugar del de la configuración",
+	"recipient, instead of the configured one":                                                                   "destinatario, en lugar del de la configuración",
+	"keep running and send every day at this time (HH:MM)":                                                       "sigue en marcha y envía cada día a esta hora (HH:MM)",
+	"send even if an installment already went out today":                                                         "envía aunque hoy ya se haya enviado una entrega",
+	"print only this chapter (from 1)":                                                                           "imprime solo este capítulo (desde 1)",
+	"Usage: gutberg cat <book> [-chapter N] [-json]":                                                             "Uso: gutberg cat <libro> [-chapter N] [-json]",
+	"print JSON instead of a table":                                                                              "imprime JSON en lugar de una tabla",
+	"Usage: gutberg list [-json]":                                                                                "Uso: gutberg list [-json]",
+	"Usage: gutberg status [-json]":                                                                              "Uso: gutberg status [-json]",
+	"Usage: gutberg stats [-json]":                                                                               "Uso: gutberg stats [-json]",
+	"Usage: gutberg search [-source name] [-json] <query>":                                                       "Uso: gutberg search [-source nombre] [-json] <búsqueda>",
+	"source to search, e.g. gutenberg, standard, runeberg, gallica or an OPDS feed name":                         "fuente en la que buscar, p. ej. gutenberg, standard, runeberg, gallica o el nombre de un catálogo OPDS",
+	"search needs a query":                                                                                       "search necesita una búsqueda",
+	"unknown source %q":                                                                                          "fuente desconocida %q",
+	"TITLE\tPAGE\tPATH":                                                                                          "TÍTULO\tPÁGINA\tRUTA",
+	"TITLE\tBY\tURL":                                                                                             "TÍTULO\tAUTOR\tURL",
+	"Reading: %s, page %d":                                                                                       "Leyendo: %s, página %d",
+	"No book open":                                                                                               "Ningún libro abierto",
+	"Today: %d pages, %d min":                                                                                    "Hoy: %d páginas, %d min",
+	"Goal: %s":                                                                                                   "Objetivo: %s",
+	"\tDAYS\tPAGES\tMIN\t":                                                                                       "\tDÍAS\tPÁGINAS\tMIN\t",
+	"today":                                                                                                      "hoy",
+	"all time":                                                                                                   "siempre",
+	"Library: %d books, %d started, %d finished":                                                                 "Biblioteca: %d libros, %d empezados, %d terminados",
+	"Streak: %d days":                                                                                            "Racha: %d días",
+	"Usage: gutberg export <book> [-format md|txt] [-o file]":                                                    "Uso: gutberg export <libro> [-format md|txt] [-o archivo]",
+	"md (Markdown) or txt (plain text)":                                                                          "md (Markdown) o txt (texto plano)",
+	"write to this file instead of stdout":                                                                       "escribe en este archivo en lugar de la salida estándar",
+	"export needs one book: a Gutenberg number, URL or library file":                                             "export necesita un libro: número de Gutenberg, URL o archivo de la biblioteca",
+	"unknown export format %q: use md or txt":                                                                    "formato de exportación desconocido %q: usa md o txt",
+	"export %s as Markdown":                                                                                      "exportar %s como Markdown",
+	"export %s as plain text":                                                                                    "exportar %s como texto plano",
+	"Exporting %s...":                                                                                            "Exportando %s...",
+	"The terminal background is light":                                                                           "El fondo del terminal es claro",
+	"The terminal background is dark":                                                                            "El fondo del terminal es oscuro",
+	"%s; the theme stays %s (auto follows the terminal)":                                                         "%s; el tema sigue siendo %s (auto sigue al terminal)",
+	"Copied the page to the clipboard (%d words)":                                                                "Página copiada al portapapeles (%d palabras)",
+	"Copied the paragraph to the clipboard (%d words)":                                                           "Párrafo copiado al portapapeles (%d palabras)",
+	"loc %d":         "pos. %d",
+	"Exported to %s": "Exportado a %s",
+	"cat needs one book: a Gutenberg number, URL or library file":         "cat necesita un libro: número de Gutenberg, URL o archivo de la biblioteca",
+	"%s has %d chapters, not %d":                                          "%s tiene %d capítulos, no %d",
+	"Next installment at %s":                                              "Próxima entrega a las %s",
+	"Installment %d already sent today (use -force to send the next one)": "La entrega %d ya se envió hoy (usa -force para enviar la siguiente)",
+	"%s: every installment has been sent":                                 "%s: ya se han enviado todas las entregas",
+	"Sent installment %d of %s to %s":                                     "Enviada la entrega %d de %s a %s",
+	"finished":                                                            "terminado",
+	"finished %s":                                                         "terminado %s",
+	"rate":                                                                "valorar",
+	"note":                                                                "nota",
+	"mark finished":                                                       "marcar como terminado",
+	"next in the series":                                                  "siguiente de la serie",
+	"later in the series":                                                 "más adelante en la serie",
+	"also by %s":                                                          "también de %s",
+	"already started":                                                     "ya empezado",
+	"Find more by %s":                                                     "Buscar más de %s",
+	"A few words to remember it by":                                       "Unas palabras para recordarlo",
+	"No longer marked as finished":                                        "Ya no está marcado como terminado",
+	"%d pages · %d words · %d chapters":                                   "%d páginas · %d palabras · %d capítulos",
+	"Read in %s over %d days, from %s to %s":                              "Leído en %s a lo largo de %d días, del %s al %s",
+	"Read in %s":                                                          "Leído en %s",
+	"About %d words a minute":                                             "Unas %d palabras por minuto",
+	"Finished · %s":                                                       "Terminado · %s",
+	"by %s":                                                               "de %s",
+	"(not rated)":                                                         "(sin valorar)",
+	"not marked as finished":                                              "no marcado como terminado",
+	"finished on %s":                                                      "terminado el %s",
+	"Rating":                                                              "Valoración",
+	"Note":                                                                "Nota",
+	"Status":                                                              "Estado",
+	"Read next":                                                           "Para leer después",
+	"not started yet":                                                     "sin empezar",
+	"capture quote":                                                       "guardar cita",
+	"quotes":                                                              "citas",
+	"delete":                                                              "borrar",
+	"Quotes":                                                              "Citas",
+	"Quotes · %d":                                                         "Citas · %d",
+	"p. %d":                                                               "pág. %d",
+	"Saved the quote (%d words)":                                          "Cita guardada (%d palabras)",
+	"Copied the citation to the clipboard":                                "Cita copiada al portapapeles",
+	"Deleted the quote":                                                   "Cita borrada",
+	"%s is no longer in the library":                                      "%s ya no está en la biblioteca",
+	"web page":                                                            "página web",
+	"open the web page of %s":                                             "abrir la página web de %s",
+	"copy the web address of %s":                                          "copiar la dirección web de %s",
+	"%s has no web page: it was not downloaded by gutberg": "%s no tiene página web: no se descargó con gutberg",
+	"Opened %s":                         "Abierto %s",
+	"Copied %s to the clipboard":        "%s copiado al portapapeles",
+	"Hide adult books":                  "Ocultar libros para adultos",
+	"Hidden subjects (comma separated)": "Temas ocultos (separados por comas)",
+	"content filter: %w":                "filtro de contenido: %w",
+	"vol. %d":                           "vol. %d",
+	"Continue with %s":                  "Continuar con %s",
+	"next volume":                       "siguiente volumen",
+	"You might also like":               "También te puede gustar",
+	"also on %s":                        "también sobre %s",
+	"Author list updates":               "Actualización de la lista de autores",
+	"the catalog has no Authors column": "el catálogo no tiene columna Authors",
+	"read the catalog: %w":              "leer el catálogo: %w",
+	"the catalog lists no authors":      "el catálogo no incluye autores",
+	"Usage: gutberg update-authors":     "Uso: gutberg update-authors",
+	"authors_file is set, so %s is used instead of the updated list": "authors_file está definido, así que se usa %s en lugar de la lista actualizada",
+	"Updated the author list: %d authors in %s":                      "Lista de autores actualizada: %d autores en %s",
+	"%s is rate limiting requests, try again in a few minutes":       "%s está limitando las peticiones, vuelve a intentarlo en unos minutos",
+	"%s is rate limiting, retrying in %ds":                           "%s está limitando las peticiones, reintento en %ds",
+	"%s needs the network, and offline mode is on":                   "%s necesita la red, y el modo sin conexión está activado",
+	"(needs the network)":                                            "(necesita la red)",
+	"Offline mode":                                                   "Modo sin conexión",
+	"Offline mode is on: no feature uses the network.":               "El modo sin conexión está activado: ninguna función usa la red.",
+	"work without the network: no searches, downloads or sync":       "trabajar sin red: sin búsquedas, descargas ni sincronización",
+	"end of chapter":                                                 "fin del capítulo",
+	"quote":                                                          "cita",
+	"Go to page %d of %d":                                            "Ir a la página %d de %d",
+	"Progress bar":                                                   "Barra de progreso",
+	"scrub":                                                          "recorrer la barra de progreso",
+	"next word":                                                      "palabra siguiente",
+	"prev word":                                                      "palabra anterior",
+	"look up":                                                        "buscar en el diccionario",
+	"vocabulary":                                                     "vocabulario",
+	"reveal":                                                         "mostrar",
+	"known":                                                          "la sabía",
+	"forgot":                                                         "no la sabía",
+	"%s is not in the dictionary":                                    "%s no está en el diccionario",
+	"Vocabulary · %d due":                                            "Vocabulario · %d pendientes",
+	"Nothing to review: %d words collected, the next due on %s.": "Nada que repasar: %d palabras guardadas, la próxima pendiente el %s.",
+	"(not in the dictionary)":                                    "(no está en el diccionario)",
+	"%s: show the meaning":                                       "%s: mostrar el significado",
+	"read aloud":                                                 "leer en voz alta",
+	"no text to speech command found: install espeak-ng or set tts_command in the config": "no se encontró un programa de síntesis de voz: instala espeak-ng o configura tts_command",
+	"Reading aloud off":                              "Lectura en voz alta desactivada",
+	"Reading aloud stopped: %v":                      "Lectura en voz alta detenida: %v",
+	"End of the book, reading aloud off":             "Fin del libro, lectura en voz alta desactivada",
+	"link panes":                                     "enlazar paneles",
+	"switch pane":                                    "cambiar de panel",
+	"Panes linked: the translation follows the book": "Paneles enlazados: la traducción sigue al libro",
+	"Panes paged independently: %s switches the pane the keys turn": "Paneles independientes: %s cambia el panel que pasan las teclas",
+	"Turning the pages of %s":     "Pasando las páginas de %s",
+	"%s · page %d/%d":             "%s · página %d/%d",
+	"bookshelves":                 "estanterías",
+	"Gutenberg bookshelves":       "Estanterías de Gutenberg",
+	"no bookshelves found on %s":  "no se encontraron estanterías en %s",
+	"Bookshelf · ":                "Estantería · ",
+	"Loading bookshelves":         "Cargando estanterías",
+	"advanced search":             "búsqueda avanzada",
+	"advanced":                    "búsqueda avanzada",
+	"next field":                  "campo siguiente",
+	"prev field":                  "campo anterior",
+	"Title":                       "Título",
+	"Author":                      "Autor",
+	"Subject":                     "Tema",
+	"Language":                    "Idioma",
+	"Century":                     "Siglo",
+	"e.g. ghost stories or sea":   "p. ej. ghost stories o sea",
+	"a code such as en, fr or de": "un código como en, fr o de",
+	"authors alive then, e.g. 19; Gutenberg only":  "autores que vivieron entonces, p. ej. 19; solo Gutenberg",
+	"Fill in at least one field to search":         "Rellena al menos un campo para buscar",
+	"Advanced search":                              "Búsqueda avanzada",
+	"Query: %s":                                    "Consulta: %s",
+	"%q is not a century: use a number such as 19": "%q no es un siglo: usa un número como 19",
+	"state_url: %w":                                "state_url: %w",
+	"sync server: %s was changed on another device, its copy is kept in %s": "servidor de sincronización: %s cambió en otro dispositivo, su copia se guarda en %s",
+	"%s/%s move  %s/%s: chapters  enter: go  esc: cancel":                   "%s/%s mover  %s/%s: capítulos  enter: ir  esc: cancelar",
+	"Mouse":                              "Ratón",
+	"Paragraph %d/%d":                    "Párrafo %d/%d",
+	"Type to filter":                     "Escribe para filtrar",
+	"Reading aloud with %s (%s to stop)": "Leyendo en voz alta con %s (%s para parar)",
+	"No words yet: in the reader, %s shows glosses, %s/%s pick a word and %s looks it up.": "Aún no hay palabras: en el lector, %s muestra las glosas, %s/%s eligen una palabra y %s la busca.",
+	"run %s (set sqlite3 in the config): %w":                                               "ejecutar %s (configura sqlite3): %w",
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// screenList is the list the screen shows and the row it starts at, if the
+// screen is a list.
+func (m *model) screenList() (*list.Model, int) {
+	switch m.mode {
+	case modeAuthorSearch:
+		return &m.authorList, screenRows(m.authorSearchTop(), m.width)
+	case modeLibrary:
+		return &m.libraryList, 0
+	case modeBooks:
+		return &m.bookList, 0
+	case modeChapters:
+		return &m.chapterList, 0
+	case modeFeeds:
+		return &m.feedList, 0
+	case modeQuotes:
+		return &m.quoteList, 0
+	case modeBookshelves:
+		return &m.shelfList, 0
+	case modeAuthorIndex:
+		if m.indexLetter != "" {
+			return &m.indexList, 0
+		}
+	}
+	return nil, 0
+}
+
+// screenRows is the rows lines take on a screen width wide, where the
+// terminal wraps the longer ones.
+func screenRows(lines []string, width int) int {
+	rows := 0
+	for _, line := range lines {
+		rows++
+		if width > 0 {
+			rows += max(lipgloss.Width(line)-1, 0) / width
+		}
+	}
+	return rows
+}
+
+// listItemAt is the index of the item drawn at row y of a list, counted
+// from the top of the list, by the delegate that draws them.
+func listItemAt(l list.Model, delegate list.ItemDelegate, y int) (int, bool) {
+	if l.ShowTitle() || (l.ShowFilter() && l.FilteringEnabled()) {
+		y -= lipgloss.Height(l.Styles.TitleBar.Render(" "))
+	}
+	if l.ShowStatusBar() {
+		y -= lipgloss.Height(l.Styles.StatusBar.Render(" "))
+	}
+	rows := delegate.Height() + delegate.Spacing()
+	if y < 0 || y%rows >= delegate.Height() {
+		return 0, false
+	}
+	index := l.Paginator.Page*l.Paginator.PerPage + y/rows
+	if y/rows >= l.Paginator.PerPage || index >= len(l.VisibleItems()) {
+		return 0, false
+	}
+	return index, true
+}
+
+// firstKey is the first key bound to one of acts in the current mode.
+func (m model) firstKey(acts ...action) (string, bool) {
+	for _, act := range acts {
+		for _, b := range m.keys.bindings[m.mode] {
+			if b.action == act && len(b.keys) > 0 {
+				return b.keys[0], true
+			}
+		}
+	}
+	return "", false
+}
+
+// updateMouse scrolls with the wheel and picks list items with a click: a
+// click selects an item and a click on the selected one opens it. Outside
+// lists the wheel moves as up and down do, or turns pages.
+func (m model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if msg.Action != tea.MouseActionPress {
+		return m, nil
+	}
+	// The progress bar is the line under the reader's header and status
+	// bar, past the page's left padding.
+	if m.mode == modeReader && msg.Button == tea.MouseButtonLeft {
+		if m.config.ProgressBar && !m.presenting && msg.Y == len(m.readerTop(m.statusValues())) {
+			cmd := m.clickBar(msg.X - pagePadding)
+			return m, cmd
+		}
+		return m, nil
+	}
+	l, top := m.screenList()
+	delegate, drawn := m.delegates[m.mode]
+	if l != nil && drawn && l.FilterState() != list.Filtering {
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			l.CursorUp()
+			return m, nil
+		case tea.MouseButtonWheelDown:
+			l.CursorDown()
+			return m, nil
+		case tea.MouseButtonLeft:
+			index, ok := listItemAt(*l, delegate, msg.Y-top)
+			if !ok {
+				return m, nil
+			}
+			if index != l.Index() {
+				l.Select(index)
+				return m, nil
+			}
+			if key, ok := m.firstKey(actionOpen); ok {
+				return m.Update(keyMsg(key))
+			}
+		}
+		return m, nil
+	}
+	var key string
+	var ok bool
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		key, ok = m.firstKey(actionUp, actionPrevPage)
+	case tea.MouseButtonWheelDown:
+		key, ok = m.firstKey(actionDown, actionNextPage)
+	}
+	if !ok {
+		return m, nil
+	}
+	return m.Update(keyMsg(key))
+}
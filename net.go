@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	featureSearch   = "search"
+	featureDownload = "download"
+	featureCatalog  = "catalog"
+	featureCovers   = "covers"
+	featureEmail    = "email"
+	featureSync     = "sync"
+	featureAuthors  = "authors"
+)
+
+const auditMemoryLimit = 200
+
+// requestTimeouts bound the requests of features the reader waits on, such
+// as loading the state from the sync server on start.
+var requestTimeouts = map[string]time.Duration{
+	featureSync: 15 * time.Second,
+}
+
+type networkFeature struct {
+	Key   string
+	Label string
+}
+
+var networkFeatures = []networkFeature{
+	{Key: featureSearch, Label: "Book search"},
+	{Key: featureDownload, Label: "Book downloads"},
+	{Key: featureCatalog, Label: "OPDS catalog browsing"},
+	{Key: featureCovers, Label: "Cover thumbnails"},
+	{Key: featureEmail, Label: "Send books by email (SMTP)"},
+	{Key: featureSync, Label: "Reading state sync server"},
+	{Key: featureAuthors, Label: "Author list updates"},
+}
+
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Feature string    `json:"feature"`
+	Host    string    `json:"host"`
+	URL     string    `json:"url"`
+	Status  int       `json:"status,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Blocked bool      `json:"blocked,omitempty"`
+}
+
+type requestAuditor struct {
+	mu      sync.Mutex
+	enabled map[string]bool
+	offline bool
+	entries []auditEntry
+	path    string
+}
+
+var netAudit = &requestAuditor{enabled: defaultPrivacy()}
+
+func defaultPrivacy() map[string]bool {
+	privacy := make(map[string]bool, len(networkFeatures))
+	for _, feature := range networkFeatures {
+		privacy[feature.Key] = true
+	}
+	return privacy
+}
+
+func configureNetwork(cfg Config) {
+	netAudit.mu.Lock()
+	defer netAudit.mu.Unlock()
+	netAudit.path = cfg.AuditFile
+	netAudit.offline = cfg.Offline
+	netAudit.enabled = make(map[string]bool, len(cfg.Privacy))
+	for key, enabled := range cfg.Privacy {
+		netAudit.enabled[key] = enabled
+	}
+}
+
+func getURL(ctx context.Context, feature, rawURL string) (*http.Response, error) {
+	return sendRequest(ctx, feature, http.MethodGet, rawURL, nil, nil)
+}
+
+// sendRequest makes a request on behalf of a feature, if the privacy
+// settings allow it, and records it in the audit log. Passwords in the URL
+// are left out of the log. A conditional request, with If-None-Match or
+// If-Modified-Since in header, may also be answered 304 Not Modified.
+// Cancelling ctx stops the request, and any wait for a rate limited host.
+func sendRequest(ctx context.Context, feature, method, rawURL string, header http.Header, body io.Reader) (*http.Response, error) {
+	entry := auditEntry{Time: time.Now(), Feature: feature, URL: rawURL}
+	if u, err := url.Parse(rawURL); err == nil {
+		entry.Host = u.Host
+		entry.URL = u.Redacted()
+	}
+	logURL := entry.URL
+	if !netAudit.allowed(feature) {
+		entry.Blocked = true
+		netAudit.record(entry)
+		debugLog.Info("request blocked", "feature", feature, "url", logURL)
+		return nil, blockedError(feature)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := waitForHost(ctx, entry.Host); err != nil {
+			return nil, err
+		}
+		entry.Time = time.Now()
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("User-Agent", "gutberg-cli/1.0")
+
+		client := http.DefaultClient
+		if timeout := requestTimeouts[feature]; timeout > 0 {
+			client = &http.Client{Transport: http.DefaultClient.Transport, Timeout: timeout}
+		}
+		resp, err := client.Do(req)
+		elapsed := time.Since(entry.Time)
+		if err != nil {
+			entry.Error = err.Error()
+			netAudit.record(entry)
+			debugLog.Warn("request failed", "feature", feature, "url", logURL, "method", method, "elapsed", elapsed, "err", err)
+			return nil, err
+		}
+		entry.Status = resp.StatusCode
+		netAudit.record(entry)
+		debugLog.Info("request", "feature", feature, "url", logURL, "method", method, "status", resp.StatusCode, "elapsed", elapsed, "length", resp.ContentLength, "type", resp.Header.Get("Content-Type"))
+		if rateLimited(entry.Host, resp) {
+			resp.Body.Close()
+			// A body is read by the first try, so only requests without
+			// one are tried again.
+			if body != nil || attempt == rateLimitRetries {
+				return nil, rateLimitError(entry.Host)
+			}
+			wait := retryWait(resp, attempt)
+			debugLog.Warn("rate limited", "feature", feature, "url", logURL, "status", resp.StatusCode, "attempt", attempt+1, "wait", wait)
+			backOff(entry.Host, wait)
+			continue
+		}
+		conditional := header.Get("If-None-Match") != "" || header.Get("If-Modified-Since") != ""
+		if (resp.StatusCode < 200 || resp.StatusCode > 299) && !(conditional && resp.StatusCode == http.StatusNotModified) {
+			resp.Body.Close()
+			return nil, &statusError{code: resp.StatusCode, status: resp.Status}
+		}
+		return resp, nil
+	}
+}
+
+// statusError is the error of a request answered with a status that is not
+// a success.
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf(tr("unexpected status: %s"), e.status)
+}
+
+func (a *requestAuditor) allowed(feature string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.offline {
+		return false
+	}
+	enabled, ok := a.enabled[feature]
+	return !ok || enabled
+}
+
+func (a *requestAuditor) setOffline(offline bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.offline = offline
+}
+
+// offline reports whether offline mode is on, from the config or -offline:
+// no feature may use the network.
+func offline() bool {
+	netAudit.mu.Lock()
+	defer netAudit.mu.Unlock()
+	return netAudit.offline
+}
+
+// blockedError is the error of a request allowed by neither offline mode
+// nor the privacy settings.
+func blockedError(feature string) error {
+	if offline() {
+		return fmt.Errorf(tr("%s needs the network, and offline mode is on"), feature)
+	}
+	return fmt.Errorf(tr("network access for %s is disabled in privacy settings"), feature)
+}
+
+func (a *requestAuditor) setEnabled(feature string, enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.enabled[feature] = enabled
+}
+
+func (a *requestAuditor) record(entry auditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+	if len(a.entries) > auditMemoryLimit {
+		a.entries = a.entries[len(a.entries)-auditMemoryLimit:]
+	}
+	if a.path == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	file, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	file.Write(append(data, '\n'))
+}
+
+func (a *requestAuditor) recent(n int) []auditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if n > len(a.entries) {
+		n = len(a.entries)
+	}
+	out := make([]auditEntry, n)
+	copy(out, a.entries[len(a.entries)-n:])
+	return out
+}
+
+func (a *requestAuditor) hosts(feature string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	seen := make(map[string]bool)
+	for _, entry := range a.entries {
+		if entry.Feature == feature && entry.Host != "" {
+			seen[entry.Host] = true
+		}
+	}
+	hosts := make([]string, 0, len(seen))
+	for host := range seen {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	notifyBell  = "bell"
+	notifyFlash = "flash"
+	notifyNone  = "none"
+
+	// flashOn and flashOff switch the terminal to reverse video and back
+	// (DECSCNM), a visual bell.
+	flashOn  = "\x1b[?5h"
+	flashOff = "\x1b[?5l"
+	bell     = "\a"
+
+	cueDuration = 150 * time.Millisecond
+)
+
+var notifyModes = []string{notifyBell, notifyFlash, notifyNone}
+
+func validNotify(name string) bool {
+	for _, n := range notifyModes {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type cueDoneMsg struct{ id int }
+
+// cueCmd writes the escape sequence of a cue straight to the terminal, the
+// way copyToClipboard writes OSC 52.
+func cueCmd(seq string) tea.Cmd {
+	return func() tea.Msg {
+		os.Stdout.WriteString(seq)
+		return nil
+	}
+}
+
+// notify gives the configured cue for a finished download, a reached goal or
+// an expiring sleep timer; quiet mode silences it. A flash is switched off
+// after cueDuration.
+func (m *model) notify() tea.Cmd {
+	if m.config.Quiet {
+		return nil
+	}
+	switch m.config.Notify {
+	case notifyBell:
+		return cueCmd(bell)
+	case notifyFlash:
+		m.cueID++
+		id := m.cueID
+		return tea.Batch(cueCmd(flashOn), tea.Tick(cueDuration, func(time.Time) tea.Msg { return cueDoneMsg{id: id} }))
+	default:
+		return nil
+	}
+}
+
+// updateCueDone ends a flash, unless a newer one is still showing.
+func (m model) updateCueDone(msg cueDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.id != m.cueID {
+		return m, nil
+	}
+	return m, cueCmd(flashOff)
+}
+
+func (m *model) toggleQuiet() tea.Cmd {
+	m.config.Quiet = !m.config.Quiet
+	m.status = tr("Quiet mode off")
+	if m.config.Quiet {
+		m.status = tr("Quiet mode on: no bells or flashes")
+	}
+	return saveConfigCmd(m.config)
+}
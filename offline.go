@@ -0,0 +1,29 @@
+package main
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// onlineActions are the actions that need the network. In offline mode
+// they are left out of key hints, marked in help and refused with a note.
+var onlineActions = map[action]bool{
+	actionSearch:         true,
+	actionPopular:        true,
+	actionFeeds:          true,
+	actionSendBook:       true,
+	actionDiscover:       true,
+	actionFeatured:       true,
+	actionBookshelves:    true,
+	actionAdvancedSearch: true,
+}
+
+func needsNetwork(act action) bool {
+	return onlineActions[act] && offline()
+}
+
+// refuseOffline explains why an action that needs the network does nothing.
+func (m model) refuseOffline(act action) (tea.Model, tea.Cmd) {
+	m.status = trf("%s needs the network, and offline mode is on", capitalize(actionName(act)))
+	if m.mode == modeLibrary {
+		return m, m.libraryList.NewStatusMessage(m.status)
+	}
+	return m, nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+type opdsLink struct {
+	Rel   string `xml:"rel,attr"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr"`
+}
+
+type opdsEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Authors []string   `xml:"author>name"`
+	Summary string     `xml:"summary"`
+	Content string     `xml:"content"`
+	Links   []opdsLink `xml:"link"`
+	// Categories are the entry's subjects; labels are optional.
+	Categories []struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr"`
+	} `xml:"category"`
+}
+
+type opdsDocument struct {
+	Title   string      `xml:"title"`
+	Links   []opdsLink  `xml:"link"`
+	Entries []opdsEntry `xml:"entry"`
+}
+
+type openSearchDescription struct {
+	URLs []struct {
+		Type     string `xml:"type,attr"`
+		Template string `xml:"template,attr"`
+	} `xml:"Url"`
+}
+
+func fetchOPDS(ctx context.Context, feature, feedURL string) (opdsDocument, error) {
+	resp, err := getURL(ctx, feature, feedURL)
+	if err != nil {
+		return opdsDocument{}, err
+	}
+	defer resp.Body.Close()
+
+	var doc opdsDocument
+	if err := xml.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return opdsDocument{}, fmt.Errorf(tr("parse opds feed: %w"), err)
+	}
+	for i := range doc.Links {
+		doc.Links[i].Href = resolveURL(feedURL, doc.Links[i].Href)
+	}
+	for i := range doc.Entries {
+		for j := range doc.Entries[i].Links {
+			doc.Entries[i].Links[j].Href = resolveURL(feedURL, doc.Entries[i].Links[j].Href)
+		}
+	}
+	return doc, nil
+}
+
+func fetchOPDSItems(ctx context.Context, feature, feedURL string) ([]bookResult, error) {
+	doc, err := fetchOPDS(ctx, feature, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	return opdsResults(doc), nil
+}
+
+func searchOPDS(ctx context.Context, feedURL, query string) ([]bookResult, error) {
+	doc, err := fetchOPDS(ctx, featureSearch, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	template, err := opdsSearchTemplate(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	searchURL := strings.ReplaceAll(template, "{searchTerms}", url.QueryEscape(query))
+	return fetchOPDSItems(ctx, featureSearch, searchURL)
+}
+
+func opdsSearchTemplate(ctx context.Context, doc opdsDocument) (string, error) {
+	for _, link := range doc.Links {
+		if link.Rel != "search" {
+			continue
+		}
+		if strings.Contains(link.Href, "{searchTerms}") {
+			return link.Href, nil
+		}
+		if !strings.Contains(link.Type, "opensearchdescription") {
+			continue
+		}
+		resp, err := getURL(ctx, featureSearch, link.Href)
+		if err != nil {
+			return "", err
+		}
+		var desc openSearchDescription
+		err = xml.NewDecoder(resp.Body).Decode(&desc)
+		resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf(tr("parse opensearch description: %w"), err)
+		}
+		for _, u := range desc.URLs {
+			if strings.Contains(u.Type, "atom") {
+				return resolveURL(link.Href, u.Template), nil
+			}
+		}
+	}
+	return "", errors.New(tr("feed does not support search"))
+}
+
+func opdsResults(doc opdsDocument) []bookResult {
+	results := make([]bookResult, 0, len(doc.Entries)+1)
+	for _, entry := range doc.Entries {
+		result := bookResult{
+			Title:    strings.TrimSpace(entry.Title),
+			Subtitle: strings.Join(entry.Authors, ", "),
+		}
+		for _, category := range entry.Categories {
+			result.Subjects = append(result.Subjects, category.Term)
+			if category.Label != "" {
+				result.Subjects = append(result.Subjects, category.Label)
+			}
+		}
+		if link, ok := opdsAcquisitionLink(entry); ok {
+			result.URL = link.Href
+			result.Format = link.Type
+			result.Extra = opdsFormatLabel(link.Type)
+		} else if link, ok := opdsNavigationLink(entry.Links); ok {
+			result.URL = link.Href
+			result.Feed = true
+			result.Extra = "catalog"
+		} else {
+			continue
+		}
+		results = append(results, result)
+	}
+	for _, link := range doc.Links {
+		if link.Rel == "next" {
+			results = append(results, bookResult{Title: tr("More..."), URL: link.Href, Feed: true, Extra: tr("next page")})
+			break
+		}
+	}
+	return results
+}
+
+func opdsAcquisitionLink(entry opdsEntry) (opdsLink, bool) {
+	var best opdsLink
+	bestRank := 0
+	for _, link := range entry.Links {
+		if !strings.HasPrefix(link.Rel, "http://opds-spec.org/acquisition") {
+			continue
+		}
+		rank := opdsFormatRank(link.Type)
+		if rank > bestRank {
+			best = link
+			bestRank = rank
+		}
+	}
+	return best, bestRank > 0
+}
+
+func opdsNavigationLink(links []opdsLink) (opdsLink, bool) {
+	for _, link := range links {
+		if strings.Contains(link.Type, "application/atom+xml") && link.Rel != "self" && link.Rel != "alternate" {
+			return link, true
+		}
+	}
+	for _, link := range links {
+		if strings.Contains(link.Type, "application/atom+xml") {
+			return link, true
+		}
+	}
+	return opdsLink{}, false
+}
+
+func opdsFormatRank(mimeType string) int {
+	mimeType = strings.ToLower(mimeType)
+	switch {
+	case strings.HasPrefix(mimeType, "text/html"), strings.HasPrefix(mimeType, "application/xhtml+xml"):
+		return 3
+	case strings.HasPrefix(mimeType, "application/epub+zip"):
+		return 2
+	default:
+		return 0
+	}
+}
+
+func opdsFormatLabel(mimeType string) string {
+	if strings.HasPrefix(strings.ToLower(mimeType), "application/epub+zip") {
+		return "epub"
+	}
+	return "html"
+}
+
+func downloadOPDSBook(ctx context.Context, href, mimeType, author, title, outDir string, progress progressFunc) (string, error) {
+	resp, err := getURL(ctx, featureDownload, href)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body := trackProgress(resp, progress)
+	if opdsFormatLabel(mimeType) == "epub" {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return "", err
+		}
+		data, err = epubToHTML(data)
+		if err != nil {
+			return "", err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	fileName := buildBookFileName(author, title, href)
+	entry := LibraryEntry{Title: title, Author: author, Source: href}
+	return storeBook(outDir, fileName, body, entry)
+}
+
+func resolveURL(base, href string) string {
+	if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
+		return href
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return baseURL.ResolveReference(ref).String()
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	pageCacheFresh  = time.Hour
+	pageCacheMaxAge = 30 * 24 * time.Hour
+)
+
+// pageCacheDir holds search results and ebook pages fetched before, with
+// the validators the server sent, so a repeated search is answered from
+// disk and only checked with the server once it is an hour old. When
+// empty, nothing is cached.
+var pageCacheDir string
+
+// cachedPage is what the page cache keeps about a page besides its body.
+type cachedPage struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
+func pageCachePath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(pageCacheDir, hex.EncodeToString(sum[:12]))
+}
+
+func readCachedPage(path string) (cachedPage, []byte, bool) {
+	var page cachedPage
+	meta, err := os.ReadFile(path + ".json")
+	if err != nil || json.Unmarshal(meta, &page) != nil {
+		return cachedPage{}, nil, false
+	}
+	data, err := os.ReadFile(path + ".body")
+	if err != nil {
+		return cachedPage{}, nil, false
+	}
+	return page, data, true
+}
+
+func writeCachedPage(path string, page cachedPage, data []byte) {
+	if err := os.MkdirAll(pageCacheDir, 0o755); err != nil {
+		return
+	}
+	meta, err := json.Marshal(page)
+	if err != nil {
+		return
+	}
+	if data == nil {
+		now := time.Now()
+		os.Chtimes(path+".body", now, now)
+	} else {
+		if err := os.WriteFile(path+".body.tmp", data, 0o644); err != nil {
+			return
+		}
+		if err := os.Rename(path+".body.tmp", path+".body"); err != nil {
+			return
+		}
+	}
+	os.WriteFile(path+".json", meta, 0o644)
+}
+
+// getCachedURL fetches a page through the page cache. A page fetched in the
+// last hour is read from disk; an older one is asked for again with its
+// ETag and Last-Modified, and kept when the server answers it has not
+// changed. When the request fails, as it does offline, the cached page is
+// used however old it is.
+func getCachedURL(ctx context.Context, feature, rawURL string) ([]byte, error) {
+	if pageCacheDir == "" {
+		return readURL(ctx, feature, rawURL)
+	}
+	path := pageCachePath(rawURL)
+	page, cached, ok := readCachedPage(path)
+	ok = ok && page.URL == rawURL
+	if ok && time.Since(page.Fetched) < pageCacheFresh {
+		debugLog.Debug("page from cache", "url", rawURL, "age", time.Since(page.Fetched).Truncate(time.Second))
+		return cached, nil
+	}
+	header := http.Header{}
+	if ok && page.ETag != "" {
+		header.Set("If-None-Match", page.ETag)
+	}
+	if ok && page.LastModified != "" {
+		header.Set("If-Modified-Since", page.LastModified)
+	}
+	resp, err := sendRequest(ctx, feature, http.MethodGet, rawURL, header, nil)
+	if err != nil {
+		if ok {
+			debugLog.Warn("request failed, using the cached page", "url", rawURL, "err", err)
+			return cached, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && ok {
+		page.Fetched = time.Now()
+		writeCachedPage(path, page, nil)
+		return cached, nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	writeCachedPage(path, cachedPage{
+		URL:          rawURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}, data)
+	return data, nil
+}
+
+func readURL(ctx context.Context, feature, rawURL string) ([]byte, error) {
+	resp, err := getURL(ctx, feature, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// prunePageCache removes the pages not fetched or checked for a month.
+func prunePageCache() {
+	if pageCacheDir == "" {
+		return
+	}
+	entries, err := os.ReadDir(pageCacheDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil && time.Since(info.ModTime()) > pageCacheMaxAge {
+			os.Remove(filepath.Join(pageCacheDir, entry.Name()))
+		}
+	}
+}
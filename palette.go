@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var goToPageRe = regexp.MustCompile(`^(?:(?:go\s*to|ir\s*a)\s*)?(?:(?:page|(?:la\s*)?p[aá]gina)\s*)?(\d+)$`)
+
+// paletteCommand is one entry of the command palette. Key is the binding
+// that runs it directly, if any; confirm asks before running it.
+type paletteCommand struct {
+	label   string
+	key     string
+	confirm string
+	run     func(m model) (tea.Model, tea.Cmd)
+}
+
+type commandPalette struct {
+	open     bool
+	input    textinput.Model
+	commands []paletteCommand
+	matches  []paletteCommand
+	cursor   int
+	pending  *paletteCommand
+}
+
+// paletteCommands lists what can be run from the palette in the current
+// mode: the mode's key bindings, the choice settings and actions that have
+// no key of their own.
+func (m model) paletteCommands() []paletteCommand {
+	var commands []paletteCommand
+	for _, b := range m.keys.bindings[m.mode] {
+		if b.action == actionPalette || len(b.keys) == 0 {
+			continue
+		}
+		key := b.keys[0]
+		label := actionName(b.action)
+		if needsNetwork(b.action) {
+			label += " " + tr("(needs the network)")
+		}
+		commands = append(commands, paletteCommand{
+			label: label,
+			key:   bindingKeys(b.keys[:min(len(b.keys), 2)]),
+			run:   func(m model) (tea.Model, tea.Cmd) { return m.Update(keyMsg(key)) },
+		})
+	}
+	for _, field := range settingFields {
+		if field.kind != settingChoice {
+			continue
+		}
+		commands = append(commands, paletteCommand{
+			label: trf("toggle %s (%s)", strings.ToLower(tr(field.label)), tr(m.settingValue(field.key))),
+			run: func(m model) (tea.Model, tea.Cmd) {
+				next := nextChoice(field.choices(), m.settingValue(field.key))
+				if err := m.applySetting(field.key, next); err != nil {
+					m.status = err.Error()
+					return m, nil
+				}
+				m.status = field.label + ": " + next
+				return m, tea.Batch(saveConfigCmd(m.config), m.settingCmd(field.key))
+			},
+		})
+	}
+	commands = append(commands, paletteCommand{
+		label: tr("start the tutorial"),
+		run: func(m model) (tea.Model, tea.Cmd) {
+			cmd := m.startTutorial()
+			return m, cmd
+		},
+	})
+	commands = append(commands, m.exportCommands()...)
+	commands = append(commands, m.webPageCommands()...)
+	if path, title, ok := m.paletteBook(); ok {
+		commands = append(commands, paletteCommand{
+			label:   tr("delete book ") + title,
+			confirm: trf("Delete %s and its reading progress? (y/n)", title),
+			run:     func(m model) (tea.Model, tea.Cmd) { return m.deleteBook(path, title) },
+		})
+	}
+	return commands
+}
+
+// paletteBook is the book a palette command acts on: the library selection
+// in the library, the open book elsewhere.
+func (m model) paletteBook() (path, title string, ok bool) {
+	if m.mode == modeLibrary {
+		if item, ok := m.libraryList.SelectedItem().(libraryItem); ok {
+			return item.path, strings.SplitN(item.title, " · ", 2)[0], true
+		}
+		return "", "", false
+	}
+	if m.state.CurrentBook == "" {
+		return "", "", false
+	}
+	path, _ = splitStoryKey(m.state.CurrentBook)
+	return path, m.currentBook.Title, true
+}
+
+// matchCommands ranks the commands against the query: every word of the
+// query must appear in the label, whole or as scattered letters.
+func (p *commandPalette) matchCommands(m model) {
+	query := foldString(strings.TrimSpace(p.input.Value()))
+	p.matches = p.matches[:0]
+	if match := goToPageRe.FindStringSubmatch(query); match != nil && m.currentBook.PageCount() > 0 {
+		page, _ := strconv.Atoi(match[1])
+		page = min(max(page, 1), m.currentBook.PageCount())
+		p.matches = append(p.matches, paletteCommand{
+			label: trf("go to page %d of %d", page, m.currentBook.PageCount()),
+			run: func(m model) (tea.Model, tea.Cmd) {
+				m.mode = modeReader
+				cmd := m.turnPage(page - 1)
+				return m, cmd
+			},
+		})
+	}
+	p.matches = append(p.matches, m.printPageCommands(query)...)
+	p.matches = append(p.matches, m.locationCommands(query)...)
+	type scored struct {
+		command paletteCommand
+		score   int
+	}
+	var ranked []scored
+	for _, c := range p.commands {
+		if score := commandScore(foldString(c.label), tokenize(query)); score > 0 || query == "" {
+			ranked = append(ranked, scored{c, score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	for _, r := range ranked {
+		p.matches = append(p.matches, r.command)
+	}
+	p.cursor = min(p.cursor, max(len(p.matches)-1, 0))
+}
+
+func commandScore(label string, query []string) int {
+	score := 0
+	for _, token := range query {
+		switch {
+		case strings.HasPrefix(label, token) || strings.Contains(label, " "+token):
+			score += 10
+		case strings.Contains(label, token):
+			score += 5
+		case isSubsequence(token, label):
+			score++
+		default:
+			return 0
+		}
+	}
+	return score
+}
+
+func isSubsequence(needle, haystack string) bool {
+	rest := []rune(needle)
+	for _, r := range haystack {
+		if len(rest) > 0 && r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
+
+// keyMsg builds the key press a binding names, so a palette command runs
+// exactly as its key would.
+func keyMsg(key string) tea.KeyMsg {
+	for t := tea.KeyType(-200); t < 200; t++ {
+		if t != tea.KeyRunes && t.String() == key {
+			return tea.KeyMsg{Type: t}
+		}
+	}
+	if key == " " {
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(key)}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func (m model) openPalette() model {
+	input := textinput.New()
+	input.Prompt = ": "
+	input.Placeholder = tr("command, or a page number")
+	input.Focus()
+	m.palette = commandPalette{open: true, input: input, commands: m.paletteCommands()}
+	m.palette.matchCommands(m)
+	return m
+}
+
+func (m model) updatePalette(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		m.palette.input, cmd = m.palette.input.Update(msg)
+		return m, cmd
+	}
+	if pending := m.palette.pending; pending != nil {
+		m.palette = commandPalette{}
+		if key.String() == "y" {
+			return pending.run(m)
+		}
+		return m, nil
+	}
+	switch key.String() {
+	case "esc":
+		m.palette = commandPalette{}
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "up", "ctrl+p":
+		m.palette.cursor = max(m.palette.cursor-1, 0)
+		return m, nil
+	case "down", "ctrl+n", "tab":
+		m.palette.cursor = min(m.palette.cursor+1, max(len(m.palette.matches)-1, 0))
+		return m, nil
+	case "enter":
+		if len(m.palette.matches) == 0 {
+			return m, nil
+		}
+		command := m.palette.matches[m.palette.cursor]
+		if command.confirm != "" {
+			m.palette.pending = &command
+			return m, nil
+		}
+		m.palette = commandPalette{}
+		return command.run(m)
+	}
+	var cmd tea.Cmd
+	m.palette.input, cmd = m.palette.input.Update(msg)
+	m.palette.matchCommands(m)
+	return m, cmd
+}
+
+func (m model) paletteView() string {
+	if m.palette.pending != nil {
+		return titleStyle().Render(tr("Commands")) + "\n\n" + m.palette.pending.confirm
+	}
+	lines := []string{titleStyle().Render(tr("Commands")), "", m.palette.input.View(), ""}
+	visible := max(m.height-6, 5)
+	start := max(m.palette.cursor-visible+1, 0)
+	cursorStyle := lipgloss.NewStyle().Bold(true).Reverse(true)
+	for i := start; i < len(m.palette.matches) && i < start+visible; i++ {
+		c := m.palette.matches[i]
+		line := fmt.Sprintf(" %-40s %s ", c.label, metaStyle().Render(c.key))
+		if i == m.palette.cursor {
+			line = cursorStyle.Render(fmt.Sprintf(" %-40s %s ", c.label, c.key))
+		}
+		lines = append(lines, line)
+	}
+	if len(m.palette.matches) == 0 {
+		lines = append(lines, metaStyle().Render(tr("No matching command")))
+	}
+	lines = append(lines, "", helpLine(tr("enter: run  ↑/↓: choose  esc: close")))
+	return strings.Join(lines, "\n")
+}
+
+// deleteBook removes a book file with its library entry, cached text and
+// everything the state keeps about it or its stories.
+func (m model) deleteBook(path, title string) (tea.Model, tea.Cmd) {
+	if err := removeBookFile(path); err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+
+	isBook := func(key string) bool {
+		book, _ := splitStoryKey(key)
+		return book == path
+	}
+	for key := range m.state.Pages {
+		if isBook(key) {
+			delete(m.state.Pages, key)
+		}
+	}
+	for key := range m.state.ReadChapters {
+		if isBook(key) {
+			delete(m.state.ReadChapters, key)
+		}
+	}
+	for key := range m.state.Schedules {
+		if isBook(key) {
+			delete(m.state.Schedules, key)
+		}
+	}
+	for key := range m.state.PrintPages {
+		if isBook(key) {
+			delete(m.state.PrintPages, key)
+		}
+	}
+	for key := range m.state.Books {
+		if isBook(key) {
+			delete(m.state.Books, key)
+		}
+	}
+	for key, other := range m.state.Translations {
+		if isBook(key) || isBook(other) {
+			delete(m.state.Translations, key)
+		}
+	}
+	if isBook(m.state.CurrentBook) {
+		m.state.CurrentBook = ""
+		m.state.Page = 0
+		m.currentBook = Book{}
+		m.chapterList.SetItems(nil)
+		m.mode = modeLibrary
+	}
+
+	items, _ := loadLibraryItems(m.config.BooksDir, m.state, m.config.WPM)
+	m.libraryList.SetItems(items)
+	m.status = trf("Deleted %s", title)
+	return m, tea.Batch(m.libraryList.NewStatusMessage(m.status), saveStateCmd(m.store, m.state))
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	parallelGutter   = " │ "
+	minParallelWidth = 20
+)
+
+type translationLoadedMsg struct {
+	path string
+	book Book
+	err  error
+}
+
+func loadTranslationCmd(path string, width, lines int) loadCmd {
+	return func(context.Context) tea.Msg {
+		book, err := loadBook(path, width, lines)
+		return translationLoadedMsg{path: path, book: book, err: err}
+	}
+}
+
+// pairTranslation links two library books as translations of each other;
+// pairing a book with itself removes its link.
+func (s *State) pairTranslation(a, b string) {
+	if s.Translations == nil {
+		s.Translations = make(map[string]string)
+	}
+	if old, ok := s.Translations[a]; ok {
+		delete(s.Translations, old)
+		delete(s.Translations, a)
+	}
+	if a == b {
+		return
+	}
+	if old, ok := s.Translations[b]; ok {
+		delete(s.Translations, old)
+	}
+	s.Translations[a] = b
+	s.Translations[b] = a
+}
+
+func (m *model) toggleParallel() tea.Cmd {
+	path := m.state.Translations[m.state.CurrentBook]
+	if !m.parallel && path == "" {
+		m.status = tr("No translation paired: open the original, then press x on the translation in the library")
+		return nil
+	}
+	m.parallel = !m.parallel
+	m.unlinked, m.rightPane = false, false
+	m.applyFontScale()
+	if m.parallel && m.translationPath != path {
+		m.translation = Book{}
+		m.translationPath = path
+		return m.startLoading(trf("Loading %s", filepath.Base(path)), loadTranslationCmd(path, m.pageWidth, m.pageLines))
+	}
+	return nil
+}
+
+// syncParallel keeps the side-by-side view on a newly opened book only when
+// it has its own translation.
+func (m *model) syncParallel() tea.Cmd {
+	if !m.parallel {
+		return nil
+	}
+	path := m.state.Translations[m.state.CurrentBook]
+	if path == "" {
+		m.parallel, m.unlinked, m.rightPane = false, false, false
+		m.translation = Book{}
+		m.translationPath = ""
+		m.applyFontScale()
+		return nil
+	}
+	if path == m.translationPath {
+		return nil
+	}
+	m.translation = Book{}
+	m.translationPath = path
+	m.unlinked, m.rightPane = false, false
+	load := loadTranslationCmd(path, m.pageWidth, m.pageLines)
+	return func() tea.Msg { return load(context.Background()) }
+}
+
+func (m model) updateTranslationLoaded(msg translationLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.path != m.translationPath {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.parallel = false
+		m.translationPath = ""
+		m.status = msg.err.Error()
+		m.applyFontScale()
+		return m, nil
+	}
+	m.status = ""
+	m.translation = msg.book
+	if len(m.translation.Chapters) > 0 {
+		m.translation.layoutPages(m.pageWidth, m.pageLines)
+	}
+	return m, nil
+}
+
+// alignedPage finds the page of other that matches page of book: the same
+// chapter (or the proportional one when the chapter counts differ) and the
+// same relative position inside it.
+func alignedPage(book, other Book, page int) int {
+	if other.PageCount() == 0 || book.PageCount() == 0 {
+		return -1
+	}
+	index := chapterForPage(book, page)
+	if index < 0 || len(other.Chapters) == 0 {
+		return min(page*other.PageCount()/book.PageCount(), other.PageCount()-1)
+	}
+	otherIndex := index
+	if len(other.Chapters) != len(book.Chapters) {
+		otherIndex = index * len(other.Chapters) / len(book.Chapters)
+	}
+	start, end := chapterPageRange(book, index)
+	otherStart, otherEnd := chapterPageRange(other, otherIndex)
+	return min(otherStart+(page-start)*(otherEnd-otherStart)/max(end-start, 1), other.PageCount()-1)
+}
+
+// translationPage is the page of the right pane: the one aligned with the
+// book's, unless the panes are paged independently.
+func (m model) translationPage() int {
+	if m.unlinked {
+		return min(m.rightPage, m.translation.PageCount()-1)
+	}
+	return alignedPage(m.currentBook, m.translation, m.state.Page)
+}
+
+// toggleLinkedPaging unlinks the panes so each turns its own pages, the
+// keys turning those of the pane picked with switch_pane, or links them
+// again with the right pane back in step.
+func (m *model) toggleLinkedPaging() {
+	if !m.parallel || m.translation.PageCount() == 0 {
+		return
+	}
+	if m.unlinked {
+		m.unlinked, m.rightPane = false, false
+		m.status = tr("Panes linked: the translation follows the book")
+		return
+	}
+	m.rightPage = m.translationPage()
+	m.unlinked = true
+	m.status = trf("Panes paged independently: %s switches the pane the keys turn", m.keys.keysFor(modeReader, actionSwitchPane))
+}
+
+// switchPane picks the pane page keys turn while the panes are unlinked.
+func (m *model) switchPane() {
+	if !m.parallel || !m.unlinked {
+		return
+	}
+	m.rightPane = !m.rightPane
+	if m.rightPane {
+		m.status = trf("Turning the pages of %s", displayTitle(m.translation.Title))
+	} else {
+		m.status = trf("Turning the pages of %s", displayTitle(m.currentBook.Title))
+	}
+}
+
+// turnRightPage turns the right pane to page while the panes are unlinked.
+func (m *model) turnRightPage(page int) {
+	m.rightPage = max(min(page, m.translation.PageCount()-1), 0)
+	m.status = trf("%s · page %d/%d", displayTitle(m.translation.Title), m.rightPage+1, m.translation.PageCount())
+}
+
+// parallelContent lays out the book and its translation side by side. While
+// the panes are unlinked, the one page keys do not turn is dimmed.
+func (m model) parallelContent(left string, width int) string {
+	right := ""
+	if page := m.translationPage(); page >= 0 {
+		right = m.translation.Page(page)
+	}
+	column := lipgloss.NewStyle().Width(width)
+	leftColumn, rightColumn := column, column
+	if m.unlinked {
+		if m.rightPane {
+			leftColumn = leftColumn.Faint(true)
+		} else {
+			rightColumn = rightColumn.Faint(true)
+		}
+	}
+	gutter := strings.TrimSuffix(strings.Repeat(parallelGutter+"\n", max(lipgloss.Height(left), lipgloss.Height(right))), "\n")
+	return lipgloss.JoinHorizontal(lipgloss.Top, leftColumn.Render(left), metaStyle().Render(gutter), rightColumn.Render(right))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const defaultPDFToText = "pdftotext"
+
+type pdfOutlineItem struct {
+	Page  int    `xml:"page,attr"`
+	Title string `xml:",chardata"`
+}
+
+type pdfXML struct {
+	Outline []pdfOutlineItem `xml:"outline>item"`
+}
+
+// importPDF converts a PDF into a library book with pdftotext. Chapters
+// come from the top level of the PDF's outline (read with pdftohtml from
+// the same Poppler install); without one, chapter headings are spotted in
+// the text as for plain text books.
+func importPDF(cfg Config, path string) (string, error) {
+	tool := cfg.PDFToText
+	if tool == "" {
+		tool = defaultPDFToText
+	}
+	out, err := exec.Command(tool, "-enc", "UTF-8", "-eol", "unix", path, "-").Output()
+	if err != nil {
+		return "", fmt.Errorf(tr("run %s (set pdftotext in the config): %w"), tool, err)
+	}
+	pages := strings.Split(string(out), "\f")
+
+	title := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var b strings.Builder
+	fmt.Fprintf(&b, "<html><head><title>%s</title></head><body>\n", html.EscapeString(title))
+	if outline := pdfOutline(tool, path, len(pages)); len(outline) > 1 {
+		if first := outline[0].Page - 1; first > 0 {
+			fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(title))
+			writePlainTextBody(&b, strings.Join(pages[:first], "\n\n"), false)
+		}
+		for i, item := range outline {
+			end := len(pages)
+			if i+1 < len(outline) {
+				end = outline[i+1].Page - 1
+			}
+			fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(item.Title))
+			writePlainTextBody(&b, strings.Join(pages[item.Page-1:end], "\n\n"), false)
+		}
+	} else {
+		writePlainTextBody(&b, strings.Join(pages, "\n\n"), true)
+	}
+	b.WriteString("</body></html>\n")
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	entry := LibraryEntry{Title: title, Source: "file://" + abs, Preset: presetOCRText}
+	stored, err := storeBook(cfg.BooksDir, sanitizeFilename(title), strings.NewReader(b.String()), entry)
+	if err != nil {
+		return "", err
+	}
+	_, err = loadAddedBook(stored, pageLineWidth, pageLineCount)
+	return stored, err
+}
+
+// pdfOutline reads the top level bookmarks of a PDF, keeping those that
+// point at increasing pages within the document.
+func pdfOutline(pdftotext, path string, pages int) []pdfOutlineItem {
+	tool := filepath.Join(filepath.Dir(pdftotext), "pdftohtml")
+	if filepath.Dir(pdftotext) == "." {
+		tool = "pdftohtml"
+	}
+	out, err := exec.Command(tool, "-xml", "-i", "-q", "-stdout", path).Output()
+	if err != nil {
+		return nil
+	}
+	var doc pdfXML
+	dec := xml.NewDecoder(bytes.NewReader(out))
+	dec.Strict = false
+	if err := dec.Decode(&doc); err != nil {
+		return nil
+	}
+	var items []pdfOutlineItem
+	last := 0
+	for _, item := range doc.Outline {
+		item.Title = compactSpaces(item.Title)
+		if item.Title == "" || item.Page <= last || item.Page > pages {
+			continue
+		}
+		items = append(items, item)
+		last = item.Page
+	}
+	return items
+}
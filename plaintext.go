@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	editionHTML = "html"
+	editionText = "txt"
+
+	maxTextStanzaLineWidth = 50
+)
+
+var (
+	textHeadingRe = regexp.MustCompile(`^(?i:chapter|book|part|act|stave|letter|canto)\s+([IVXLCDM]+|\d+|[A-Z][A-Za-z]*)\b|^[IVXLCDM]+\.?$`)
+	textStartRe   = regexp.MustCompile(`(?i)\*\*\*\s*START OF (THE|THIS) PROJECT GUTENBERG.*\*\*\*`)
+	textEndRe     = regexp.MustCompile(`(?i)\*\*\*\s*END OF (THE|THIS) PROJECT GUTENBERG.*\*\*\*`)
+)
+
+func plainTextURL(id string) string {
+	return fmt.Sprintf("https://www.gutenberg.org/cache/epub/%s/pg%s.txt", id, id)
+}
+
+func fetchPlainTextEdition(ctx context.Context, id, title string) ([]byte, error) {
+	resp, err := getURL(ctx, featureDownload, plainTextURL(id))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return plainTextToHTML(title, string(decodeText(data, resp.Header.Get("Content-Type")))), nil
+}
+
+func plainTextToHTML(title, text string) []byte {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if loc := textStartRe.FindStringIndex(text); loc != nil {
+		text = text[loc[1]:]
+	}
+	if loc := textEndRe.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]]
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "<html><head><title>%s</title></head><body>\n", html.EscapeString(title))
+	writePlainTextBody(&b, text, true)
+	b.WriteString("</body></html>\n")
+	return []byte(b.String())
+}
+
+// writePlainTextBody turns blank-line separated text into HTML, spotting
+// stanzas and, with headings set, chapter headings.
+func writePlainTextBody(b *strings.Builder, text string, headings bool) {
+	for _, para := range strings.Split(text, "\n\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			continue
+		}
+		joined := joinLines(para)
+		first, _, _ := strings.Cut(strings.TrimSpace(para), "\n")
+		if headings && len(joined) < 80 && textHeadingRe.MatchString(strings.TrimSpace(first)) {
+			fmt.Fprintf(b, "<h2>%s</h2>\n", html.EscapeString(joined))
+			continue
+		}
+		if lines := strings.Split(strings.Trim(para, "\n"), "\n"); isTextStanza(lines) {
+			for i := range lines {
+				lines[i] = html.EscapeString(strings.TrimSpace(lines[i]))
+			}
+			fmt.Fprintf(b, "<div class=\"stanza\">%s</div>\n", strings.Join(lines, "<br>\n"))
+			continue
+		}
+		fmt.Fprintf(b, "<p>%s</p>\n", html.EscapeString(joined))
+	}
+}
+
+// joinLines joins the lines of a paragraph with single spaces, keeping the
+// line break after a hyphen at the end of a line for the dehyphenate filter.
+func joinLines(para string) string {
+	var joined []string
+	for _, line := range strings.Split(para, "\n") {
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+		if n := len(joined); n > 0 && strings.HasSuffix(joined[n-1], "-") {
+			joined[n-1] += "\n" + strings.Join(words, " ")
+		} else {
+			joined = append(joined, strings.Join(words, " "))
+		}
+	}
+	return strings.Join(joined, " ")
+}
+
+// isTextStanza spots verse in plain text: prose is hard wrapped near 70
+// columns, so a run of clearly shorter lines is kept line by line.
+func isTextStanza(lines []string) bool {
+	if len(lines) < 3 {
+		return false
+	}
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l == "" || len(l) > maxTextStanzaLineWidth {
+			return false
+		}
+	}
+	return true
+}
+
+func extractionQuality(data []byte) (int, int) {
+	chapters := extractChaptersFromHTML(data, presetGutenbergHTML)
+	words := 0
+	for _, ch := range chapters {
+		words += len(strings.Fields(ch.Text))
+	}
+	if len(chapters) == 0 {
+		words = len(strings.Fields(cleanHTMLToText(string(data), presetGutenbergHTML)))
+	}
+	return len(chapters), words
+}
+
+func betterExtraction(candidate, current []byte) bool {
+	candidateChapters, candidateWords := extractionQuality(candidate)
+	currentChapters, currentWords := extractionQuality(current)
+	if candidateChapters != currentChapters {
+		return candidateChapters > currentChapters
+	}
+	return candidateWords > currentWords
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	xhtml "golang.org/x/net/html"
+)
+
+const (
+	popularURL = "https://www.gutenberg.org/browse/scores/top"
+	popularTTL = 6 * time.Hour
+)
+
+type popularPeriod struct {
+	anchor string
+	label  string
+}
+
+var popularPeriods = []popularPeriod{
+	{anchor: "books-last1", label: "yesterday"},
+	{anchor: "books-last7", label: "last 7 days"},
+	{anchor: "books-last30", label: "last 30 days"},
+}
+
+var downloadCountRe = regexp.MustCompile(`\s*\((\d+)\)$`)
+
+func fetchPopularCmd(cacheDir string, period int) loadCmd {
+	return func(ctx context.Context) tea.Msg {
+		results, err := popularBooks(ctx, cacheDir, popularPeriods[period].anchor)
+		if err == nil {
+			results, err = filterResults(ctx, results)
+		}
+		if err != nil {
+			return booksMsg{err: err}
+		}
+		title := tr("Popular · ") + tr(popularPeriods[period].label)
+		return booksMsg{items: buildBookItems(gutenbergSource{}, results), title: title, popular: true}
+	}
+}
+
+func popularBooks(ctx context.Context, cacheDir, anchor string) ([]bookResult, error) {
+	data, err := popularPage(ctx, cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	root, err := xhtml.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	list := popularList(root, anchor)
+	if list == nil {
+		return nil, fmt.Errorf(tr("popular list %s not found"), anchor)
+	}
+
+	var books []bookResult
+	for li := list.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != xhtml.ElementNode || li.Data != "li" {
+			continue
+		}
+		for a := li.FirstChild; a != nil; a = a.NextSibling {
+			href, ok := attr(a, "href")
+			if a.Type != xhtml.ElementNode || a.Data != "a" || !ok || ebookIDFromURL(href) == "" {
+				continue
+			}
+			books = append(books, parsePopularEntry(strings.TrimSpace(textContent(a)), href))
+		}
+	}
+	return books, nil
+}
+
+func parsePopularEntry(text, href string) bookResult {
+	result := bookResult{URL: "https://www.gutenberg.org" + href}
+	if m := downloadCountRe.FindStringSubmatch(text); m != nil {
+		result.Extra = trf("%s downloads", m[1])
+		text = text[:len(text)-len(m[0])]
+	}
+	if i := strings.LastIndex(text, " by "); i > 0 {
+		result.Title = text[:i]
+		result.Subtitle = text[i+len(" by "):]
+	} else {
+		result.Title = text
+	}
+	return result
+}
+
+func popularList(root *xhtml.Node, anchor string) *xhtml.Node {
+	var heading *xhtml.Node
+	var walk func(*xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if heading != nil {
+			return
+		}
+		if n.Type == xhtml.ElementNode && n.Data == "h2" {
+			if attrEquals(n, "id", anchor) {
+				heading = n
+				return
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if attrEquals(c, "id", anchor) || attrEquals(c, "name", anchor) {
+					heading = n
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	if heading == nil {
+		return nil
+	}
+	for n := heading.NextSibling; n != nil; n = n.NextSibling {
+		if n.Type == xhtml.ElementNode && n.Data == "ol" {
+			return n
+		}
+		if n.Type == xhtml.ElementNode && n.Data == "h2" {
+			break
+		}
+	}
+	return nil
+}
+
+func attrEquals(n *xhtml.Node, name, value string) bool {
+	v, ok := attr(n, name)
+	return ok && v == value
+}
+
+func popularPage(ctx context.Context, cacheDir string) ([]byte, error) {
+	path := filepath.Join(cacheDir, "popular.html")
+	info, statErr := os.Stat(path)
+	if statErr == nil && time.Since(info.ModTime()) < popularTTL {
+		return os.ReadFile(path)
+	}
+
+	data, err := downloadPopularPage(ctx)
+	if err != nil {
+		if statErr == nil {
+			return os.ReadFile(path)
+		}
+		return nil, err
+	}
+	if err := os.MkdirAll(cacheDir, 0o755); err == nil {
+		os.WriteFile(path, data, 0o644)
+	}
+	return data, nil
+}
+
+func downloadPopularPage(ctx context.Context) ([]byte, error) {
+	resp, err := getURL(ctx, featureSearch, popularURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prefetcher runs work ahead of need in the background: the next page of
+// search results, the pages of the next chapter. Each key runs at most once
+// at a time and its result is kept until the model takes it.
+type prefetcher struct {
+	mu      sync.Mutex
+	running map[string]bool
+	done    map[string]tea.Msg
+}
+
+type prefetchedMsg struct{ key string }
+
+func newPrefetcher() *prefetcher {
+	return &prefetcher{running: make(map[string]bool), done: make(map[string]tea.Msg)}
+}
+
+// schedule returns a command running job in the background, or nil when
+// the same key is already running or its result is waiting to be taken.
+// Jobs returning nil only warm a cache and leave nothing to take.
+func (p *prefetcher) schedule(key string, job func() tea.Msg) tea.Cmd {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.done[key]; ok || p.running[key] {
+		return nil
+	}
+	p.running[key] = true
+	return func() tea.Msg {
+		msg := job()
+		p.mu.Lock()
+		delete(p.running, key)
+		if msg != nil {
+			p.done[key] = msg
+		}
+		p.mu.Unlock()
+		return prefetchedMsg{key: key}
+	}
+}
+
+// take hands over a finished job's result; running reports whether the
+// caller should wait for a prefetchedMsg instead.
+func (p *prefetcher) take(key string) (msg tea.Msg, running bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if msg, ok := p.done[key]; ok {
+		delete(p.done, key)
+		return msg, false
+	}
+	return nil, p.running[key]
+}
+
+func (p *prefetcher) forget(prefix string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key := range p.done {
+		if strings.HasPrefix(key, prefix) {
+			delete(p.done, key)
+		}
+	}
+}
+
+// searchPaging tracks a paged search so scrolling to the end of the results
+// can append the next page, which is fetched before it is reached.
+type searchPaging struct {
+	source pagedSource
+	query  string
+	page   int
+	last   bool
+}
+
+func searchPageKey(source BookSource, query string, page int) string {
+	return fmt.Sprintf("search:%s:%s:%d", source.Name(), query, page)
+}
+
+func feedPageKey(feedURL string) string {
+	return "feed:" + feedURL
+}
+
+// fetchBooksPageCmd fetches a page of results, or the first page after it
+// the content filter leaves something on, so a filtered page doesn't end
+// the search.
+func fetchBooksPageCmd(source pagedSource, query string, page int) func() tea.Msg {
+	return func() tea.Msg {
+		ctx := context.Background()
+		for {
+			books, err := source.SearchPage(ctx, query, page)
+			if err != nil {
+				return booksMsg{err: err}
+			}
+			kept, err := filterResults(ctx, books)
+			if err != nil {
+				return booksMsg{err: err}
+			}
+			if len(kept) > 0 || len(books) == 0 {
+				return booksMsg{items: buildBookItems(source, kept), query: query, page: page}
+			}
+			page++
+		}
+	}
+}
+
+// prefetchResults starts fetching what follows the results on screen: the
+// next page of a paged search or the "More..." link of an OPDS feed.
+func (m model) prefetchResults() tea.Cmd {
+	if s := m.search; s.source != nil && !s.last {
+		return m.prefetch.schedule(searchPageKey(s.source, s.query, s.page+1), fetchBooksPageCmd(s.source, s.query, s.page+1))
+	}
+	items := m.bookList.Items()
+	if len(items) == 0 {
+		return nil
+	}
+	if item, ok := items[len(items)-1].(bookItem); ok && item.result.Feed && item.result.Extra == "next page" {
+		fetch := fetchFeedCmd(item.source, item.result.URL)
+		return m.prefetch.schedule(feedPageKey(item.result.URL), func() tea.Msg { return fetch(context.Background()) })
+	}
+	return nil
+}
+
+// moreResults appends the next page of a paged search once the cursor
+// reaches the last result.
+func (m model) moreResults() (tea.Model, tea.Cmd) {
+	s := m.search
+	if s.source == nil || s.last || m.awaiting != "" || m.bookList.FilterState() != list.Unfiltered || m.bookList.Index() < len(m.bookList.Items())-1 {
+		return m, nil
+	}
+	key := searchPageKey(s.source, s.query, s.page+1)
+	msg, running := m.prefetch.take(key)
+	if msg == nil {
+		m.awaiting = key
+		m.status = tr("Loading more results...")
+		if running {
+			return m, nil
+		}
+		return m, m.prefetch.schedule(key, fetchBooksPageCmd(s.source, s.query, s.page+1))
+	}
+	return m.appendResults(msg.(booksMsg))
+}
+
+func (m model) appendResults(msg booksMsg) (tea.Model, tea.Cmd) {
+	m.awaiting = ""
+	if msg.err != nil {
+		m.search.last = true
+		m.status = msg.err.Error()
+		return m, nil
+	}
+	m.search.page = msg.page
+	if len(msg.items) == 0 {
+		m.search.last = true
+		m.status = trf("%d books", len(m.bookList.Items()))
+		return m, nil
+	}
+	cmd := m.bookList.SetItems(append(m.bookList.Items(), msg.items...))
+	m.status = trf("%d books", len(m.bookList.Items()))
+	return m, tea.Batch(cmd, fetchCoversCmd(m.config.CacheDir, coverIDs(msg.items, m.covers)), m.prefetchResults())
+}
+
+// prefetchNextChapter paginates the chapter after the one being read, so
+// crossing into it does not wait for layout.
+func (m model) prefetchNextChapter() tea.Cmd {
+	index := chapterForPage(m.currentBook, m.state.Page)
+	if index < 0 || index+1 >= len(m.currentBook.Chapters) {
+		return nil
+	}
+	book := m.currentBook
+	key := fmt.Sprintf("chapter:%s:%d:%dx%d", m.state.CurrentBook, index+1, m.pageWidth, m.pageLines)
+	return m.prefetch.schedule(key, func() tea.Msg {
+		book.chapterPages(index + 1)
+		return nil
+	})
+}
+
+func (m model) updatePrefetched(msg prefetchedMsg) (tea.Model, tea.Cmd) {
+	if msg.key != m.awaiting {
+		return m, nil
+	}
+	result, _ := m.prefetch.take(msg.key)
+	books, ok := result.(booksMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.search.source != nil && msg.key == searchPageKey(m.search.source, m.search.query, m.search.page+1) {
+		return m.appendResults(books)
+	}
+	m.awaiting = ""
+	return m.Update(books)
+}
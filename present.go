@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const remoteInterval = 200 * time.Millisecond
+
+// Remote commands, one per line in the presenter's remote file.
+const (
+	remoteNext     = "next"
+	remotePrev     = "prev"
+	remoteNextPage = "next_page"
+	remotePrevPage = "prev_page"
+)
+
+var (
+	wordRe        = regexp.MustCompile(`\S+`)
+	sentenceEndRe = regexp.MustCompile(`[.!?…]["'»”’)\]]*$`)
+)
+
+// presentText and presentSentence are the high contrast styles of
+// presentation mode: bold text, with the sentence being read in reverse
+// video so it shows on a projector whatever the theme.
+var (
+	presentText     = lipgloss.NewStyle().Bold(true)
+	presentSentence = lipgloss.NewStyle().Bold(true).Reverse(true)
+)
+
+type remoteMsg struct {
+	id       int
+	commands []string
+}
+
+// markSentences renders a page in the text style with sentence current in
+// the highlight style, and counts its sentences. A sentence ends at closing
+// punctuation or a blank line; line breaks are kept so verse and tables
+// stay as laid out.
+func markSentences(page string, current int, text, highlight lipgloss.Style) (string, int) {
+	lines := strings.Split(stripStyles(page), "\n")
+	sentence, open := 0, false
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if open {
+				sentence, open = sentence+1, false
+			}
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, loc := range wordRe.FindAllStringIndex(line, -1) {
+			gap, word := line[last:loc[0]], line[loc[0]:loc[1]]
+			if last > 0 && open && sentence == current {
+				gap = highlight.Render(gap)
+			}
+			style := text
+			if sentence == current {
+				style = highlight
+			}
+			b.WriteString(gap + style.Render(word))
+			open = true
+			if sentenceEndRe.MatchString(word) {
+				sentence, open = sentence+1, false
+			}
+			last = loc[1]
+		}
+		lines[i] = b.String()
+	}
+	if open {
+		sentence++
+	}
+	return strings.Join(lines, "\n"), sentence
+}
+
+// largePrint reports whether pages are drawn double size, by the setting
+// or by presentation mode.
+func (m model) largePrint() bool {
+	return m.config.LargePrint || m.presenting
+}
+
+func (m *model) togglePresentation() tea.Cmd {
+	m.presenting = !m.presenting
+	m.sentence = 0
+	if !m.presenting {
+		m.applyFontScale()
+		m.status = tr("Presentation mode off")
+		return nil
+	}
+	m.parallel, m.glossing = false, false
+	m.applyFontScale()
+	m.status = tr("Presenting: →/space next sentence, ← previous, P to leave; gutberg remote controls it from another pane")
+	m.remoteID++
+	return remoteTickCmd(m.config.CacheDir, m.remoteID)
+}
+
+// moveSentence steps the highlight, turning the page past either end.
+func (m *model) moveSentence(delta int) tea.Cmd {
+	_, count := markSentences(m.currentBook.Page(m.state.Page), -1, presentText, presentSentence)
+	next := m.sentence + delta
+	switch {
+	case next >= count:
+		return m.turnPage(m.state.Page + 1)
+	case next < 0:
+		cmd := m.turnPage(m.state.Page - 1)
+		if cmd != nil {
+			_, count = markSentences(m.currentBook.Page(m.state.Page), -1, presentText, presentSentence)
+			m.sentence = max(count-1, 0)
+		}
+		return cmd
+	}
+	m.sentence = next
+	return m.speakSentence()
+}
+
+func (m model) presentationView() string {
+	contentWidth := m.pageWidth
+	if contentWidth == 0 {
+		contentWidth = pageLineWidth
+	}
+	page, count := markSentences(m.currentBook.Page(m.state.Page), m.sentence, presentText, presentSentence)
+	content := lipgloss.NewStyle().Width(contentWidth + pagePadding).PaddingLeft(pagePadding).Render(page)
+	position := trf("%s · page %d/%d · sentence %d/%d", displayTitle(m.currentBook.Title), m.state.Page+1, m.currentBook.PageCount(), min(m.sentence+1, count), count)
+	lines := []string{largePrintLines(content), "", metaStyle().Render(position)}
+	if status := m.statusText(); status != "" {
+		lines = append(lines, metaStyle().Render(status))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func remotePath(cacheDir string, pid int) string {
+	return filepath.Join(presenceDir(cacheDir), strconv.Itoa(pid)+".remote")
+}
+
+// remoteTickCmd collects the commands gutberg remote left for this
+// instance. The file is renamed before reading so commands written
+// meanwhile wait for the next tick.
+func remoteTickCmd(cacheDir string, id int) tea.Cmd {
+	return tea.Tick(remoteInterval, func(time.Time) tea.Msg {
+		path := remotePath(cacheDir, os.Getpid())
+		taken := path + ".taken"
+		if err := os.Rename(path, taken); err != nil {
+			return remoteMsg{id: id}
+		}
+		data, err := os.ReadFile(taken)
+		os.Remove(taken)
+		if err != nil {
+			debugLog.Warn("remote read failed", "err", err)
+			return remoteMsg{id: id}
+		}
+		return remoteMsg{id: id, commands: strings.Fields(string(data))}
+	})
+}
+
+func (m model) updateRemote(msg remoteMsg) (tea.Model, tea.Cmd) {
+	if !m.presenting || msg.id != m.remoteID {
+		return m, nil
+	}
+	var cmds []tea.Cmd
+	for _, command := range msg.commands {
+		if m.mode != modeReader {
+			break
+		}
+		switch command {
+		case remoteNext:
+			cmds = append(cmds, m.moveSentence(1))
+		case remotePrev:
+			cmds = append(cmds, m.moveSentence(-1))
+		case remoteNextPage:
+			cmds = append(cmds, m.turnPage(m.state.Page+1))
+		case remotePrevPage:
+			cmds = append(cmds, m.turnPage(m.state.Page-1))
+		default:
+			debugLog.Warn("unknown remote command", "command", command)
+		}
+	}
+	cmds = append(cmds, remoteTickCmd(m.config.CacheDir, m.remoteID))
+	return m, tea.Batch(cmds...)
+}
+
+// sendRemote queues a command for the presenter with the given pid.
+func sendRemote(cacheDir string, pid int, command string) error {
+	file, err := os.OpenFile(remotePath(cacheDir, pid), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(file, command); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// remoteModel is gutberg remote: a small screen in another pane that turns
+// keystrokes into commands for the gutberg that is presenting.
+type remoteModel struct {
+	cacheDir string
+	target   presence
+	status   string
+}
+
+func runRemote(cfg Config) error {
+	var target presence
+	for _, p := range readPresences(cfg.CacheDir, time.Now()) {
+		if p.Presenting && p.Updated.After(target.Updated) {
+			target = p
+		}
+	}
+	if target.PID == 0 {
+		return errors.New(tr("no gutberg is presenting: press P in the reader first"))
+	}
+	_, err := tea.NewProgram(remoteModel{cacheDir: cfg.CacheDir, target: target}).Run()
+	return err
+}
+
+func (r remoteModel) Init() tea.Cmd {
+	return nil
+}
+
+func (r remoteModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return r, nil
+	}
+	var command string
+	switch key.String() {
+	case "right", " ", "enter", "n", "j":
+		command = remoteNext
+	case "left", "backspace", "p", "k":
+		command = remotePrev
+	case "pgdown", "]":
+		command = remoteNextPage
+	case "pgup", "[":
+		command = remotePrevPage
+	case "q", "esc", "ctrl+c":
+		return r, tea.Quit
+	default:
+		return r, nil
+	}
+	r.status = trf("sent %s", command)
+	if err := sendRemote(r.cacheDir, r.target.PID, command); err != nil {
+		r.status = err.Error()
+	}
+	return r, nil
+}
+
+func (r remoteModel) View() string {
+	return strings.Join([]string{
+		titleStyle().Render(trf("Remote for %s (pid %d)", r.target.Title, r.target.PID)),
+		helpLine(tr("→/space: next sentence  ←: previous  pgdn/pgup: page  q: quit")),
+		metaStyle().Render(r.status),
+	}, "\n") + "\n"
+}
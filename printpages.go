@@ -0,0 +1,103 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// printPageRe is the palette query about a print edition: "print 480"
+// sets its page count (0 forgets it) or goes to that print page.
+var printPageRe = regexp.MustCompile(`^(?:(?:go\s*to|ir\s*a)\s*)?(?:print|impres[ao])\s*(?:edition|edici[oó]n)?\s*(?:pages?|p[aá]ginas?)?\s*(\d+)$`)
+
+// bookFraction is how far into the book a page starts, by words, which
+// lines up with a print edition better than screen pages do: headings and
+// short chapters take a page of their own on screen.
+func bookFraction(book Book, page int) float64 {
+	index := chapterForPage(book, page)
+	if index < 0 || book.Words == 0 {
+		return 0
+	}
+	words := 0
+	for _, ch := range book.Chapters[:index] {
+		words += ch.Words
+	}
+	_, offset := pagePosition(book, page)
+	return (float64(words) + offset*float64(book.Chapters[index].Words)) / float64(book.Words)
+}
+
+// fractionPage is the page where a fraction of the book's words is reached.
+func fractionPage(book Book, fraction float64) int {
+	target := fraction * float64(book.Words)
+	words := 0.0
+	for i, ch := range book.Chapters {
+		if next := words + float64(ch.Words); next > target || i == len(book.Chapters)-1 {
+			offset := 0.0
+			if ch.Words > 0 {
+				offset = min((target-words)/float64(ch.Words), 1)
+			}
+			return positionPage(book, i, offset)
+		}
+		words += float64(ch.Words)
+	}
+	return 0
+}
+
+// printPage is the approximate page of a print edition of printPages pages
+// at the middle of the screen page.
+func printPage(book Book, page, printPages int) int {
+	middle := bookFraction(book, page)
+	if page+1 < book.PageCount() {
+		middle = (middle + bookFraction(book, page+1)) / 2
+	}
+	return min(1+int(middle*float64(printPages)), printPages)
+}
+
+func (m *model) setPrintPages(pages int) {
+	key := m.state.CurrentBook
+	if pages <= 0 {
+		delete(m.state.PrintPages, key)
+		m.status = tr("Print edition removed")
+		return
+	}
+	if m.state.PrintPages == nil {
+		m.state.PrintPages = make(map[string]int)
+	}
+	m.state.PrintPages[key] = pages
+	m.status = trf("Print edition of %d pages: this is print page ~%d", pages, printPage(m.currentBook, m.state.Page, pages))
+}
+
+// printPageCommands are the palette commands for a query about the print
+// edition of the open book.
+func (m model) printPageCommands(query string) []paletteCommand {
+	match := printPageRe.FindStringSubmatch(query)
+	if match == nil || m.state.CurrentBook == "" || m.currentBook.PageCount() == 0 {
+		return nil
+	}
+	n, _ := strconv.Atoi(match[1])
+	var commands []paletteCommand
+	if printPages := m.state.PrintPages[m.state.CurrentBook]; printPages > 0 && n > 0 {
+		n := min(n, printPages)
+		commands = append(commands, paletteCommand{
+			label: trf("go to print page ~%d of %d", n, printPages),
+			run: func(m model) (tea.Model, tea.Cmd) {
+				m.mode = modeReader
+				cmd := m.turnPage(fractionPage(m.currentBook, (float64(n)-0.5)/float64(printPages)))
+				return m, cmd
+			},
+		})
+	}
+	label := trf("print edition has %d pages", n)
+	if n == 0 {
+		label = tr("forget the print edition")
+	}
+	commands = append(commands, paletteCommand{
+		label: label,
+		run: func(m model) (tea.Model, tea.Cmd) {
+			m.setPrintPages(n)
+			return m, saveStateCmd(m.store, m.state)
+		},
+	})
+	return commands
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const privacyLogLines = 10
+
+func (m model) updatePrivacy(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch m.keys.lookup(modePrivacy, msg.String()) {
+		case actionUp:
+			if m.privacyCursor > 0 {
+				m.privacyCursor--
+			}
+		case actionDown:
+			if m.privacyCursor < len(networkFeatures)-1 {
+				m.privacyCursor++
+			}
+		case actionToggle:
+			feature := networkFeatures[m.privacyCursor]
+			enabled := !m.config.Privacy[feature.Key]
+			m.config.Privacy[feature.Key] = enabled
+			netAudit.setEnabled(feature.Key, enabled)
+			return m, saveConfigCmd(m.config)
+		case actionBack:
+			m.mode = modeLibrary
+		case actionQuit:
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m model) privacyView() string {
+	cursorStyle := lipgloss.NewStyle().Bold(true)
+
+	lines := []string{titleStyle().Render(tr("Privacy")), ""}
+	if offline() {
+		lines = append(lines, metaStyle().Render(tr("Offline mode is on: no feature uses the network.")), "")
+	}
+	for i, feature := range networkFeatures {
+		check := " "
+		if m.config.Privacy[feature.Key] {
+			check = "x"
+		}
+		line := fmt.Sprintf("[%s] %s", check, tr(feature.Label))
+		if i == m.privacyCursor {
+			line = cursorStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+		hosts := plannedHosts(m.config, feature.Key)
+		if len(hosts) > 0 {
+			lines = append(lines, metaStyle().Render("      "+strings.Join(hosts, ", ")))
+		}
+	}
+
+	lines = append(lines, "", titleStyle().Render(tr("Recent requests")))
+	entries := netAudit.recent(privacyLogLines)
+	if len(entries) == 0 {
+		lines = append(lines, metaStyle().Render(tr("No requests this session.")))
+	}
+	for _, entry := range entries {
+		lines = append(lines, metaStyle().Render(formatAuditEntry(entry)))
+	}
+	if m.config.AuditFile != "" {
+		lines = append(lines, "", metaStyle().Render(tr("Full log: ")+m.config.AuditFile))
+	}
+	lines = append(lines, "", helpLine(m.keys.hint(modePrivacy, actionToggle, actionBack, actionHelp, actionQuit)))
+	return strings.Join(lines, "\n")
+}
+
+func formatAuditEntry(entry auditEntry) string {
+	result := fmt.Sprintf("%d", entry.Status)
+	switch {
+	case entry.Blocked:
+		result = tr("blocked")
+	case entry.Error != "":
+		result = tr("error")
+	}
+	return fmt.Sprintf("%s  %-8s  %-7s  %s", entry.Time.Format("15:04:05"), entry.Feature, result, entry.URL)
+}
+
+func plannedHosts(cfg Config, feature string) []string {
+	seen := make(map[string]bool)
+	switch feature {
+	case featureSearch, featureDownload:
+		seen["www.gutenberg.org"] = true
+		seen["standardebooks.org"] = true
+		if feature == featureSearch {
+			seen["gutendex.com"] = true
+		}
+		for _, feed := range cfg.OPDSFeeds {
+			if u, err := url.Parse(feed.URL); err == nil && u.Host != "" {
+				seen[u.Host] = true
+			}
+		}
+	case featureCovers:
+		seen["www.gutenberg.org"] = true
+	case featureEmail:
+		if cfg.SMTP.Host != "" {
+			seen[cfg.SMTP.Host] = true
+		}
+	case featureCatalog:
+		for _, feed := range cfg.OPDSFeeds {
+			if u, err := url.Parse(feed.URL); err == nil && u.Host != "" {
+				seen[u.Host] = true
+			}
+		}
+	}
+	for _, host := range netAudit.hosts(feature) {
+		seen[host] = true
+	}
+	hosts := make([]string, 0, len(seen))
+	for host := range seen {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func saveConfigCmd(cfg Config) tea.Cmd {
+	return func() tea.Msg {
+		if err := writeConfig(cfg.Path, cfg); err != nil {
+			return errMsg{err: err}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	profileEnv      = "GUTBERG_PROFILE"
+	profilesDirName = "profiles"
+)
+
+// resolveProfile returns the profile named by the -profile flag, or by
+// GUTBERG_PROFILE when the flag is not given. An empty name is the default
+// profile.
+func resolveProfile(flagValue string) (string, error) {
+	name := strings.TrimSpace(flagValue)
+	if name == "" {
+		name = strings.TrimSpace(os.Getenv(profileEnv))
+	}
+	if name == "" {
+		return "", nil
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf(tr("bad profile name %q"), name)
+	}
+	return name, nil
+}
+
+// profileDir is where a profile keeps its config, state, books and cache,
+// so people sharing a machine each have their own progress.
+func profileDir(configDir, profile string) string {
+	if profile == "" {
+		return configDir
+	}
+	return filepath.Join(configDir, profilesDirName, profile)
+}
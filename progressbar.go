@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	barRead          = "━"
+	barUnread        = "─"
+	barReadChapter   = "╋"
+	barUnreadChapter = "┼"
+	barQuote         = "•"
+	barHere          = "●"
+	barCursor        = "◆"
+	minBarWidth      = 10
+)
+
+// barCell is the cell of a bar width cells wide that shows page.
+func barCell(page, pages, width int) int {
+	return min(page*width/max(pages, 1), width-1)
+}
+
+// cellPage is the first page a cell of the bar shows.
+func cellPage(cell, pages, width int) int {
+	return min((cell*pages+width-1)/width, pages-1)
+}
+
+// progressBar is the whole book in one line of width cells: read up to the
+// current page, with a tick where each chapter starts and a dot where each
+// saved quote is. While scrubbing, the cell to go to is marked.
+func (m model) progressBar(width int) string {
+	book := m.currentBook
+	pages := book.PageCount()
+	if pages == 0 || width < minBarWidth {
+		return ""
+	}
+	here := barCell(m.state.Page, pages, width)
+	cells := make([]string, width)
+	for i := range cells {
+		cells[i] = barUnread
+		if i <= here {
+			cells[i] = barRead
+		}
+	}
+	for _, ch := range book.Chapters[1:] {
+		cell := barCell(ch.StartPage, pages, width)
+		cells[cell] = barUnreadChapter
+		if cell <= here {
+			cells[cell] = barReadChapter
+		}
+	}
+	if locations := bookLocations(book); locations > 0 {
+		for _, q := range m.bookQuotes {
+			cells[min(max(q.Location-1, 0)*width/locations, width-1)] = barQuote
+		}
+	}
+	cells[here] = barHere
+	if m.scrubbing {
+		cells[barCell(m.scrubPage, pages, width)] = lipgloss.NewStyle().Reverse(true).Render(barCursor)
+	}
+	return metaStyle().Render(strings.Join(cells, ""))
+}
+
+// barWidth is the width of the progress bar, that of the page.
+func (m model) barWidth() int {
+	if m.pageWidth == 0 {
+		return pageLineWidth
+	}
+	return m.pageWidth
+}
+
+func (m *model) startScrub() {
+	m.scrubbing = true
+	m.scrubPage = m.state.Page
+}
+
+// updateScrub moves along the progress bar a cell, or a page when pages are
+// wider than cells, or a chapter at a time, and turns to the page picked
+// with enter.
+func (m model) updateScrub(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	pages, width := m.currentBook.PageCount(), m.barWidth()
+	cell := barCell(m.scrubPage, pages, width)
+	chapter := chapterForPage(m.currentBook, m.scrubPage)
+	switch key.String() {
+	case "enter":
+		m.scrubbing = false
+		return m, m.turnPage(m.scrubPage)
+	case "esc", "ctrl+c":
+		m.scrubbing = false
+		return m, nil
+	}
+	switch m.keys.lookup(modeReader, key.String()) {
+	case actionPrevPage:
+		m.scrubPage = max(min(cellPage(max(cell-1, 0), pages, width), m.scrubPage-1), 0)
+	case actionNextPage:
+		m.scrubPage = min(max(cellPage(min(cell+1, width-1), pages, width), m.scrubPage+1), pages-1)
+	case actionPrevChapter:
+		if chapter >= 0 {
+			start := m.currentBook.Chapters[chapter].StartPage
+			if start == m.scrubPage && chapter > 0 {
+				start = m.currentBook.Chapters[chapter-1].StartPage
+			}
+			m.scrubPage = start
+		}
+	case actionNextChapter:
+		if chapter >= 0 && chapter+1 < len(m.currentBook.Chapters) {
+			m.scrubPage = m.currentBook.Chapters[chapter+1].StartPage
+		}
+	case actionFirstPage:
+		m.scrubPage = 0
+	case actionLastPage:
+		m.scrubPage = pages - 1
+	case actionScrub, actionBack, actionQuit:
+		m.scrubbing = false
+	}
+	return m, nil
+}
+
+// scrubKey names a key of a reader action on the progress bar, where enter
+// and esc go and cancel instead; space is passed over for the arrows.
+func (m model) scrubKey(act action) string {
+	for _, b := range m.keys.bindings[modeReader] {
+		if b.action != act {
+			continue
+		}
+		for _, key := range b.keys {
+			if key != "enter" && key != "esc" && key != " " {
+				return keyLabel(key)
+			}
+		}
+	}
+	return "?"
+}
+
+// scrubLine names the page picked on the progress bar and its chapter.
+func (m model) scrubLine() string {
+	line := trf("Go to page %d of %d", m.scrubPage+1, m.currentBook.PageCount())
+	if index := chapterForPage(m.currentBook, m.scrubPage); index >= 0 && m.currentBook.Chapters[index].Title != "" {
+		line += " · " + m.currentBook.Chapters[index].Title
+	}
+	return line + " · " + trf("%s/%s move  %s/%s: chapters  enter: go  esc: cancel",
+		m.scrubKey(actionPrevPage), m.scrubKey(actionNextPage), m.scrubKey(actionPrevChapter), m.scrubKey(actionNextChapter))
+}
+
+// clickBar turns to the page under a click on the progress bar, at column x
+// of the bar: the start of the chapter whose tick is there, if any.
+func (m *model) clickBar(x int) tea.Cmd {
+	pages, width := m.currentBook.PageCount(), m.barWidth()
+	if x < 0 || x >= width || pages == 0 {
+		return nil
+	}
+	m.scrubbing = false
+	page := cellPage(x, pages, width)
+	for _, ch := range m.currentBook.Chapters[1:] {
+		if barCell(ch.StartPage, pages, width) == x {
+			page = ch.StartPage
+			break
+		}
+	}
+	return m.turnPage(page)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// The -json output of the query commands. Field names are part of the
+// interface scripts build on: add fields, but don't rename or drop them.
+type (
+	listedBook struct {
+		Title   string `json:"title"`
+		Path    string `json:"path"`
+		Story   string `json:"story,omitempty"`
+		ID      string `json:"gutenberg_id,omitempty"`
+		Edition string `json:"edition,omitempty"`
+		Current bool   `json:"current"`
+		Page    int    `json:"page,omitempty"`
+		// Finished is the date the book was finished, as YYYY-MM-DD.
+		Finished string `json:"finished,omitempty"`
+		Rating   int    `json:"rating,omitempty"`
+	}
+
+	searchResult struct {
+		Title    string `json:"title"`
+		Subtitle string `json:"subtitle,omitempty"`
+		URL      string `json:"url"`
+		Extra    string `json:"extra,omitempty"`
+		Format   string `json:"format,omitempty"`
+	}
+
+	readingTotals struct {
+		Days    int `json:"days"`
+		Pages   int `json:"pages"`
+		Minutes int `json:"minutes"`
+	}
+
+	readingGoal struct {
+		Pages   int  `json:"pages,omitempty"`
+		Minutes int  `json:"minutes,omitempty"`
+		Met     bool `json:"met"`
+	}
+
+	readingStatus struct {
+		Profile string        `json:"profile,omitempty"`
+		Book    *listedBook   `json:"book,omitempty"`
+		Today   readingTotals `json:"today"`
+		Goal    *readingGoal  `json:"goal,omitempty"`
+		Streak  int           `json:"streak"`
+	}
+
+	readingStats struct {
+		Today         readingTotals `json:"today"`
+		Last7Days     readingTotals `json:"last_7_days"`
+		Last30Days    readingTotals `json:"last_30_days"`
+		AllTime       readingTotals `json:"all_time"`
+		Streak        int           `json:"streak"`
+		Books         int           `json:"books"`
+		StartedBooks  int           `json:"started_books"`
+		FinishedBooks int           `json:"finished_books"`
+	}
+)
+
+// queryFlags is the flag set of a query command, with its -json switch.
+func queryFlags(name, usage string) (*flag.FlagSet, *bool) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	asJSON := fs.Bool("json", false, tr("print JSON instead of a table"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	return fs, asJSON
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func listedBooks(cfg Config, state State) ([]listedBook, error) {
+	items, err := loadLibraryItems(cfg.BooksDir, state, cfg.WPM)
+	if err != nil {
+		return nil, err
+	}
+	books := make([]listedBook, 0, len(items))
+	for _, item := range items {
+		item := item.(libraryItem)
+		book := listedBook{Title: item.title, Path: item.key, Story: item.story, ID: item.id, Edition: item.edition, Current: item.key == state.CurrentBook}
+		if page, ok := state.Pages[item.key]; ok {
+			book.Page = page + 1
+		}
+		book.Finished, book.Rating = state.Books[item.key].Finished, state.Books[item.key].Rating
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+// runList is gutberg list: the library, with the page reached in each book.
+func runList(cfg Config, args []string) error {
+	fs, asJSON := queryFlags("list", tr("Usage: gutberg list [-json]"))
+	fs.Parse(args)
+	state, err := loadStateFrom(cfg)
+	if err != nil {
+		return err
+	}
+	books, err := listedBooks(cfg, state)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(books)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, tr("TITLE\tPAGE\tPATH"))
+	for _, b := range books {
+		title, page := b.Title, "-"
+		if b.Story != "" {
+			title += " · " + b.Story
+		}
+		if b.Page > 0 {
+			page = fmt.Sprint(b.Page)
+		}
+		if b.Current {
+			title = "* " + title
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", title, page, b.Path)
+	}
+	return w.Flush()
+}
+
+// runSearch is gutberg search: a book search in one of the sources, the
+// first one unless -source names another.
+func runSearch(cfg Config, args []string) error {
+	fs, asJSON := queryFlags("search", tr("Usage: gutberg search [-source name] [-json] <query>"))
+	sourceName := fs.String("source", "", tr("source to search, e.g. gutenberg, standard, runeberg, gallica or an OPDS feed name"))
+	fs.Parse(args)
+	query := strings.Join(fs.Args(), " ")
+	if query == "" {
+		fs.Usage()
+		return errors.New(tr("search needs a query"))
+	}
+	sources := configuredSources(cfg)
+	source := sources[0]
+	if *sourceName != "" {
+		source = nil
+		for _, s := range sources {
+			if strings.Contains(strings.ToLower(s.Name()), strings.ToLower(*sourceName)) {
+				source = s
+				break
+			}
+		}
+		if source == nil {
+			return fmt.Errorf(tr("unknown source %q"), *sourceName)
+		}
+	}
+	ctx := context.Background()
+	found, err := source.Search(ctx, query)
+	if err == nil {
+		found, err = filterResults(ctx, found)
+	}
+	if err != nil {
+		return err
+	}
+	results := make([]searchResult, 0, len(found))
+	for _, r := range found {
+		results = append(results, searchResult{Title: r.Title, Subtitle: r.Subtitle, URL: r.URL, Extra: r.Extra, Format: r.Format})
+	}
+	if *asJSON {
+		return printJSON(results)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, tr("TITLE\tBY\tURL"))
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Title, r.Subtitle, r.URL)
+	}
+	return w.Flush()
+}
+
+func dayTotals(log map[string]ReadingDay, from, to time.Time) readingTotals {
+	var t readingTotals
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if d, ok := log[day.Format(scheduleDateLayout)]; ok {
+			t.Days++
+			t.Pages += d.Pages
+			t.Minutes += d.minutes()
+		}
+	}
+	return t
+}
+
+// runStatus is gutberg status: the open book and today's reading.
+func runStatus(cfg Config, args []string) error {
+	fs, asJSON := queryFlags("status", tr("Usage: gutberg status [-json]"))
+	fs.Parse(args)
+	state, err := loadStateFrom(cfg)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	status := readingStatus{
+		Profile: cfg.Profile,
+		Today:   dayTotals(state.Reading, now, now),
+		Streak:  readingStreak(cfg, state.Reading, now),
+	}
+	if state.CurrentBook != "" {
+		books, err := listedBooks(cfg, state)
+		if err != nil {
+			return err
+		}
+		for _, b := range books {
+			if b.Current {
+				status.Book = &b
+			}
+		}
+	}
+	if cfg.hasGoal() {
+		status.Goal = &readingGoal{Pages: cfg.GoalPages, Minutes: cfg.GoalMinutes, Met: cfg.goalMet(state.Reading[now.Format(scheduleDateLayout)])}
+	}
+	if *asJSON {
+		return printJSON(status)
+	}
+	if status.Book != nil {
+		fmt.Println(trf("Reading: %s, page %d", status.Book.Title, status.Book.Page))
+	} else {
+		fmt.Println(tr("No book open"))
+	}
+	fmt.Println(trf("Today: %d pages, %d min", status.Today.Pages, status.Today.Minutes))
+	if progress := goalProgress(cfg, state.Reading, now); progress != "" {
+		fmt.Println(trf("Goal: %s", progress))
+	}
+	return nil
+}
+
+// runStats is gutberg stats: reading totals from the reading log.
+func runStats(cfg Config, args []string) error {
+	fs, asJSON := queryFlags("stats", tr("Usage: gutberg stats [-json]"))
+	fs.Parse(args)
+	state, err := loadStateFrom(cfg)
+	if err != nil {
+		return err
+	}
+	books, err := listedBooks(cfg, state)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	stats := readingStats{
+		Today:      dayTotals(state.Reading, now, now),
+		Last7Days:  dayTotals(state.Reading, now.AddDate(0, 0, -6), now),
+		Last30Days: dayTotals(state.Reading, now.AddDate(0, 0, -29), now),
+		AllTime:    dayTotals(state.Reading, now.AddDate(0, 0, -readingLogDays), now),
+		Streak:     readingStreak(cfg, state.Reading, now),
+		Books:      len(books),
+	}
+	for _, b := range books {
+		if b.Page > 0 {
+			stats.StartedBooks++
+		}
+		if b.Finished != "" {
+			stats.FinishedBooks++
+		}
+	}
+	if *asJSON {
+		return printJSON(stats)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, tr("\tDAYS\tPAGES\tMIN\t"))
+	for _, row := range []struct {
+		label  string
+		totals readingTotals
+	}{
+		{tr("today"), stats.Today},
+		{tr("last 7 days"), stats.Last7Days},
+		{tr("last 30 days"), stats.Last30Days},
+		{tr("all time"), stats.AllTime},
+	} {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t\n", row.label, row.totals.Days, row.totals.Pages, row.totals.Minutes)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println(trf("Library: %d books, %d started, %d finished", stats.Books, stats.StartedBooks, stats.FinishedBooks))
+	if stats.Streak > 0 {
+		fmt.Println(trf("Streak: %d days", stats.Streak))
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	quotesFileName   = "quotes.json"
+	quotesMarkdown   = "quotes.md"
+	quoteExcerptSize = 80
+)
+
+// Quote is a passage captured in the reader with what a citation needs.
+// Page is the page of the print edition, known once its page count is set;
+// Location always is.
+type Quote struct {
+	Text     string `json:"text"`
+	Book     string `json:"book"`
+	Title    string `json:"title"`
+	Author   string `json:"author,omitempty"`
+	Chapter  string `json:"chapter,omitempty"`
+	Page     int    `json:"page,omitempty"`
+	Location int    `json:"location,omitempty"`
+	Added    string `json:"added"`
+}
+
+type quoteItem struct {
+	quote Quote
+}
+
+func (q quoteItem) Title() string {
+	text := strings.Join(strings.Fields(q.quote.Text), " ")
+	if runes := []rune(text); len(runes) > quoteExcerptSize {
+		text = strings.TrimSpace(string(runes[:quoteExcerptSize-1])) + "…"
+	}
+	return "“" + text + "”"
+}
+func (q quoteItem) Description() string { return q.quote.source(false) }
+func (q quoteItem) FilterValue() string {
+	return q.quote.Text + " " + q.quote.Title + " " + q.quote.Author
+}
+
+type quoteSavedMsg struct {
+	deleted bool
+	err     error
+}
+
+// source is the reference line of a citation: author, title, chapter and
+// page, with the title in italics for Markdown.
+func (q Quote) source(markdown bool) string {
+	var parts []string
+	if q.Author != "" {
+		parts = append(parts, q.Author)
+	}
+	title := q.Title
+	if markdown {
+		title = "*" + markdownEscape(title) + "*"
+	}
+	parts = append(parts, title)
+	if q.Chapter != "" {
+		parts = append(parts, q.Chapter)
+	}
+	switch {
+	case q.Page > 0:
+		parts = append(parts, trf("p. %d", q.Page))
+	case q.Location > 0:
+		parts = append(parts, trf("loc %d", q.Location))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// citation is the quote ready to paste: the passage in quotation marks and
+// its source.
+func (q Quote) citation() string {
+	return fmt.Sprintf("“%s”\n— %s", q.Text, q.source(false))
+}
+
+// markdown is the quote as a Markdown block quote.
+func (q Quote) markdown() string {
+	var b strings.Builder
+	for i, para := range strings.Split(q.Text, "\n\n") {
+		if i > 0 {
+			b.WriteString(">\n")
+		}
+		fmt.Fprintf(&b, "> %s\n", markdownEscape(para))
+	}
+	fmt.Fprintf(&b, ">\n> — %s\n", q.source(true))
+	return b.String()
+}
+
+func loadQuotes(store stateStore) ([]Quote, error) {
+	var quotes []Quote
+	err := loadJSONFile(store, quotesFileName, &quotes)
+	return quotes, err
+}
+
+// changeQuotes changes the saved quotes and writes them back with, next to
+// them, the same quotes as Markdown, to read or paste from.
+func changeQuotes(store stateStore, change func([]Quote) []Quote) error {
+	var quotes []Quote
+	return updateJSONFile(store, quotesFileName, &quotes, func() error {
+		quotes = change(quotes)
+		var b strings.Builder
+		b.WriteString("# " + tr("Quotes") + "\n")
+		for _, q := range quotes {
+			b.WriteString("\n" + q.markdown())
+		}
+		return store.SaveFile(quotesMarkdown, []byte(b.String()))
+	})
+}
+
+func addQuoteCmd(store stateStore, q Quote) tea.Cmd {
+	return func() tea.Msg {
+		err := changeQuotes(store, func(quotes []Quote) []Quote {
+			return append(quotes, q)
+		})
+		return quoteSavedMsg{err: err}
+	}
+}
+
+func deleteQuoteCmd(store stateStore, q Quote) tea.Cmd {
+	return func() tea.Msg {
+		err := changeQuotes(store, func(quotes []Quote) []Quote {
+			if i := slices.Index(quotes, q); i >= 0 {
+				return slices.Delete(quotes, i, i+1)
+			}
+			return quotes
+		})
+		return quoteSavedMsg{deleted: true, err: err}
+	}
+}
+
+// captureQuote saves the selected passage of the reader with its source.
+func (m *model) captureQuote() tea.Cmd {
+	paras := m.selectedParagraphs()
+	if len(paras) == 0 {
+		return nil
+	}
+	path, _ := splitStoryKey(m.state.CurrentBook)
+	q := Quote{
+		Text:     strings.Join(paras, "\n\n"),
+		Book:     m.state.CurrentBook,
+		Title:    displayTitle(m.currentBook.Title),
+		Author:   libraryAuthor(path),
+		Location: pageLocation(m.currentBook, m.state.Page),
+		Added:    time.Now().Format(scheduleDateLayout),
+	}
+	if index := chapterForPage(m.currentBook, m.state.Page); index >= 0 {
+		q.Chapter = m.currentBook.Chapters[index].Title
+	}
+	if pages := m.state.PrintPages[m.state.CurrentBook]; pages > 0 {
+		q.Page = printPage(m.currentBook, m.state.Page, pages)
+	}
+	m.status = trf("Saved the quote (%d words)", len(strings.Fields(q.Text)))
+	return addQuoteCmd(m.store, q)
+}
+
+func (m model) updateQuoteSaved(msg quoteSavedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = msg.err.Error()
+		return m, nil
+	}
+	m.loadBookQuotes()
+	if msg.deleted && m.mode == modeQuotes {
+		m.openQuotes()
+		return m, m.quoteList.NewStatusMessage(tr("Deleted the quote"))
+	}
+	return m, nil
+}
+
+// openQuotes shows the quotes, newest first.
+func (m *model) openQuotes() {
+	quotes, err := loadQuotes(m.store)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	items := make([]list.Item, 0, len(quotes))
+	for i := len(quotes) - 1; i >= 0; i-- {
+		items = append(items, quoteItem{quote: quotes[i]})
+	}
+	m.quoteList.SetItems(items)
+	m.quoteList.Title = trf("Quotes · %d", len(quotes))
+	if m.mode != modeQuotes {
+		m.quotesBack = m.mode
+	}
+	m.mode = modeQuotes
+}
+
+func (m model) updateQuotes(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && m.quoteList.FilterState() != list.Filtering {
+		item, selected := m.quoteList.SelectedItem().(quoteItem)
+		switch m.keys.lookup(modeQuotes, key.String()) {
+		case actionOpen:
+			if selected {
+				cmd := m.openQuote(item.quote)
+				return m, cmd
+			}
+		case actionCopy:
+			if selected {
+				status := m.quoteList.NewStatusMessage(tr("Copied the citation to the clipboard"))
+				return m, tea.Batch(status, copyToClipboard(item.quote.citation()+"\n"))
+			}
+		case actionDelete:
+			if selected {
+				return m, deleteQuoteCmd(m.store, item.quote)
+			}
+		case actionBack:
+			m.mode = m.quotesBack
+			return m, nil
+		case actionQuit:
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.quoteList, cmd = m.quoteList.Update(msg)
+	return m, cmd
+}
+
+// openQuote opens the book of a quote at the page it was taken from.
+func (m *model) openQuote(q Quote) tea.Cmd {
+	if q.Book == m.state.CurrentBook && m.currentBook.PageCount() > 0 {
+		m.mode = modeReader
+		return m.turnPage(quotePage(m.currentBook, q.Location))
+	}
+	path, _ := splitStoryKey(q.Book)
+	if _, err := os.Stat(path); err != nil {
+		return m.quoteList.NewStatusMessage(trf("%s is no longer in the library", q.Title))
+	}
+	m.quoteAt = &q
+	return m.startLoading(tr("Loading book"), openBookCmd(q.Book, m.pageWidth, m.pageLines))
+}
+
+// turnToQuote moves a book just opened from the quotes to the quote's page.
+func (m *model) turnToQuote(path string) {
+	q := m.quoteAt
+	if q == nil || q.Book != path {
+		return
+	}
+	m.quoteAt = nil
+	page := quotePage(m.currentBook, q.Location)
+	m.state.Page = page
+	m.state.Pages[path] = page
+}
+
+// quotePage is the page a quote was taken from: the last page starting at
+// its location, which the pages before it may share.
+func quotePage(book Book, location int) int {
+	page := locationPage(book, location)
+	for page+1 < book.PageCount() && pageLocation(book, page+1) == location {
+		page++
+	}
+	return page
+}
+
+func (m model) quotesView() string {
+	return m.quoteList.View() + "\n" + m.footerLine(m.keys.hint(modeQuotes, actionOpen, actionCopy, actionDelete, actionBack, actionHelp, actionQuit))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	rateLimitRetries   = 4
+	rateLimitFirstWait = 10 * time.Second
+	rateLimitMaxWait   = 5 * time.Minute
+	blockPageSample    = 4 << 10
+)
+
+// blockPageRe finds the captcha or block page Gutenberg serves, with a 200
+// or a 403, in place of the page asked for once it turns a client away.
+var blockPageRe = regexp.MustCompile(`(?i)captcha|cf-chl-|your ip address (?:has been|is) blocked`)
+
+// rateLimitMsg tells the screen a host is turning requests away and when the
+// next try is.
+type rateLimitMsg struct {
+	host  string
+	until time.Time
+}
+
+// rateLimits is when each host that rate limited us takes requests again.
+// Requests to it wait until then, so they queue behind the one retrying
+// instead of being turned away too.
+var rateLimits = struct {
+	mu     sync.Mutex
+	until  map[string]time.Time
+	notify chan rateLimitMsg
+}{until: make(map[string]time.Time), notify: make(chan rateLimitMsg, 1)}
+
+func gutenbergHost(host string) bool {
+	return host == "gutenberg.org" || strings.HasSuffix(host, ".gutenberg.org")
+}
+
+// rateLimited reports whether resp turns the request away for making too
+// many: a 429 from any host, or a 403 or block page from Gutenberg, which
+// answers with those instead. A block page is only looked for in HTML, and
+// what is read of it is put back.
+func rateLimited(host string, resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if !gutenbergHost(host) {
+		return false
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		return true
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		return false
+	}
+	sample := make([]byte, blockPageSample)
+	n, _ := io.ReadFull(resp.Body, sample)
+	sample = sample[:n]
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(sample), resp.Body), resp.Body}
+	return blockPageRe.Match(sample)
+}
+
+// retryWait is how long to wait before retry attempt: what the host asks
+// for in Retry-After, or a wait that doubles with each attempt.
+func retryWait(resp *http.Response, attempt int) time.Duration {
+	wait := rateLimitFirstWait << attempt
+	if after := resp.Header.Get("Retry-After"); after != "" {
+		if seconds, err := strconv.Atoi(strings.TrimSpace(after)); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if at, err := http.ParseTime(after); err == nil {
+			wait = time.Until(at)
+		}
+	}
+	return min(max(wait, time.Second), rateLimitMaxWait)
+}
+
+// waitForHost holds a request until the host it goes to takes requests
+// again, or ctx is cancelled.
+func waitForHost(ctx context.Context, host string) error {
+	rateLimits.mu.Lock()
+	until := rateLimits.until[host]
+	rateLimits.mu.Unlock()
+	if wait := time.Until(until); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	return ctx.Err()
+}
+
+// backOff records that host takes no requests for wait and tells the screen.
+func backOff(host string, wait time.Duration) {
+	until := time.Now().Add(wait)
+	rateLimits.mu.Lock()
+	if until.After(rateLimits.until[host]) {
+		rateLimits.until[host] = until
+	}
+	rateLimits.mu.Unlock()
+	select {
+	case rateLimits.notify <- rateLimitMsg{host: host, until: until}:
+	default:
+	}
+}
+
+// rateLimitError is the error of a request still turned away once retries
+// run out.
+func rateLimitError(host string) error {
+	return errors.New(trf("%s is rate limiting requests, try again in a few minutes", rateLimitName(host)))
+}
+
+func rateLimitName(host string) string {
+	if gutenbergHost(host) {
+		return "Gutenberg"
+	}
+	return host
+}
+
+// rateLimitCmd waits for the next time a host rate limits a request.
+func rateLimitCmd() tea.Cmd {
+	return func() tea.Msg {
+		return <-rateLimits.notify
+	}
+}
+
+func (m model) updateRateLimit(msg rateLimitMsg) (tea.Model, tea.Cmd) {
+	m.rateLimit = msg
+	if m.loading.active {
+		return m, rateLimitCmd()
+	}
+	m.status = m.rateLimitNote()
+	status := m.status
+	return m, tea.Batch(rateLimitCmd(), tea.Tick(time.Until(msg.until), func(time.Time) tea.Msg { return statusClearMsg{status: status} }))
+}
+
+// rateLimitNote counts down to the retry of a rate limited request, while
+// one is waiting.
+func (m model) rateLimitNote() string {
+	wait := time.Until(m.rateLimit.until)
+	if wait <= 0 {
+		return ""
+	}
+	return trf("%s is rate limiting, retrying in %ds", rateLimitName(m.rateLimit.host), int(wait.Round(time.Second)/time.Second))
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ttsCommands are tried in order when tts_command is not set. Each reads
+// the text to speak on its standard input; {lang} is the book's language.
+var ttsCommands = []string{"espeak-ng -v {lang}", "espeak -v {lang}", "say"}
+
+// spokenSentence marks the sentence being read aloud on the page.
+var spokenSentence = lipgloss.NewStyle().Reverse(true)
+
+// spokenMsg is sent when the text to speech command has finished a
+// sentence, which is what moves the highlight on.
+type spokenMsg struct {
+	id  int
+	err error
+}
+
+// speech is the text to speech command speaking, if any, and the id of the
+// sentence that may be spoken: a command for an older one is not started,
+// or is killed.
+var speech struct {
+	mu   sync.Mutex
+	id   int
+	proc *os.Process
+}
+
+// ttsCommand is the command that speaks text in language, split in fields.
+func ttsCommand(cfg Config, language string) ([]string, error) {
+	line := cfg.TTSCommand
+	if line == "" {
+		for _, candidate := range ttsCommands {
+			if _, err := exec.LookPath(strings.Fields(candidate)[0]); err == nil {
+				line = candidate
+				break
+			}
+		}
+	}
+	if line == "" {
+		return nil, errors.New(tr("no text to speech command found: install espeak-ng or set tts_command in the config"))
+	}
+	if language == "" {
+		language = "en"
+	}
+	fields := strings.Fields(line)
+	for i, field := range fields {
+		fields[i] = strings.ReplaceAll(field, "{lang}", language)
+	}
+	return fields, nil
+}
+
+// speakCmd speaks text and reports when it is done.
+func speakCmd(command []string, text string, id int) tea.Cmd {
+	return func() tea.Msg {
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		speech.mu.Lock()
+		if speech.id != id {
+			speech.mu.Unlock()
+			return spokenMsg{id: id}
+		}
+		err := cmd.Start()
+		if err == nil {
+			speech.proc = cmd.Process
+		}
+		speech.mu.Unlock()
+		if err != nil {
+			return spokenMsg{id: id, err: err}
+		}
+		err = cmd.Wait()
+		speech.mu.Lock()
+		if speech.proc == cmd.Process {
+			speech.proc = nil
+		}
+		speech.mu.Unlock()
+		return spokenMsg{id: id, err: err}
+	}
+}
+
+// stopSpeech silences the sentence being spoken and lets only id be spoken
+// from now on.
+func stopSpeech(id int) {
+	speech.mu.Lock()
+	defer speech.mu.Unlock()
+	speech.id = id
+	if speech.proc != nil {
+		speech.proc.Kill()
+		speech.proc = nil
+	}
+}
+
+// pageSentences are the sentences of a page as markSentences counts them,
+// with line breaks joined.
+func pageSentences(page string) []string {
+	var sentences, words []string
+	end := func() {
+		if len(words) > 0 {
+			sentences = append(sentences, strings.Join(words, " "))
+		}
+		words = nil
+	}
+	for _, line := range strings.Split(stripStyles(page), "\n") {
+		if strings.TrimSpace(line) == "" {
+			end()
+			continue
+		}
+		for _, word := range wordRe.FindAllString(line, -1) {
+			words = append(words, word)
+			if sentenceEndRe.MatchString(word) {
+				end()
+			}
+		}
+	}
+	end()
+	return sentences
+}
+
+// toggleReadAloud starts reading the page aloud from the sentence shown,
+// or stops.
+func (m *model) toggleReadAloud() tea.Cmd {
+	if m.speaking {
+		m.stopReadingAloud()
+		m.status = tr("Reading aloud off")
+		return nil
+	}
+	command, err := ttsCommand(m.config, m.glossLanguage())
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+	m.speaking, m.speech = true, command
+	m.glossing = false
+	m.status = trf("Reading aloud with %s (%s to stop)", command[0], m.keys.keysFor(modeReader, actionReadAloud))
+	return m.speakSentence()
+}
+
+func (m *model) stopReadingAloud() {
+	m.speaking = false
+	m.speechID++
+	stopSpeech(m.speechID)
+}
+
+// speakSentence speaks the highlighted sentence, silencing any other.
+func (m *model) speakSentence() tea.Cmd {
+	if !m.speaking {
+		return nil
+	}
+	m.speechID++
+	stopSpeech(m.speechID)
+	sentences := pageSentences(m.currentBook.Page(m.state.Page))
+	if m.sentence >= len(sentences) {
+		id := m.speechID
+		return func() tea.Msg { return spokenMsg{id: id} }
+	}
+	return speakCmd(m.speech, sentences[m.sentence], m.speechID)
+}
+
+// updateSpoken moves the highlight to the next sentence once the last one
+// has been spoken, turning the page after its last sentence.
+func (m model) updateSpoken(msg spokenMsg) (tea.Model, tea.Cmd) {
+	if !m.speaking || msg.id != m.speechID {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.speaking = false
+		m.status = trf("Reading aloud stopped: %v", msg.err)
+		return m, nil
+	}
+	if m.sentence+1 < len(pageSentences(m.currentBook.Page(m.state.Page))) {
+		m.sentence++
+		return m, m.speakSentence()
+	}
+	if m.state.Page >= m.currentBook.PageCount()-1 {
+		m.speaking = false
+		m.status = tr("End of the book, reading aloud off")
+		return m, nil
+	}
+	return m, m.turnPage(m.state.Page + 1)
+}
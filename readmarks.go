@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const readMark = "✓"
+
+func (s State) chapterRead(book string, index int) bool {
+	return slices.Contains(s.ReadChapters[book], index)
+}
+
+// markChapterRead records a chapter as read and reports whether it was new.
+func (s *State) markChapterRead(book string, index int) bool {
+	if s.chapterRead(book, index) {
+		return false
+	}
+	if s.ReadChapters == nil {
+		s.ReadChapters = make(map[string][]int)
+	}
+	read := append(s.ReadChapters[book], index)
+	slices.Sort(read)
+	s.ReadChapters[book] = read
+	return true
+}
+
+// markPageRead marks the chapter of the current page as read once its last
+// page is reached, refreshing the checkmarks of the chapter list.
+func (m *model) markPageRead() {
+	index := chapterForPage(m.currentBook, m.state.Page)
+	if index < 0 {
+		return
+	}
+	if _, end := chapterPageRange(m.currentBook, index); m.state.Page != end-1 {
+		return
+	}
+	if m.state.markChapterRead(m.state.CurrentBook, index) {
+		m.chapterList.SetItems(buildChapterItems(m.currentBook, m.state.ReadChapters[m.state.CurrentBook]))
+	}
+}
+
+// firstUnreadChapter returns the first chapter not paged through yet, or -1
+// when the whole book has been read.
+func firstUnreadChapter(book Book, state State, path string) int {
+	for i := range book.Chapters {
+		if !state.chapterRead(path, i) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *model) jumpToFirstUnread() tea.Cmd {
+	index := firstUnreadChapter(m.currentBook, m.state, m.state.CurrentBook)
+	if index < 0 {
+		m.status = tr("Every chapter has been read")
+		return nil
+	}
+	m.mode = modeReader
+	return m.turnPage(m.currentBook.Chapters[index].StartPage)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type refreshMsg struct {
+	authors *authorCatalog
+	library []list.Item
+	err     error
+}
+
+// loadAuthors reads the authors catalog from authors_file, or when it is not
+// set the one update-authors fetched, else the one built into the binary.
+func loadAuthors(cfg Config) (*authorCatalog, error) {
+	if cfg.AuthorsFile == "" {
+		if data, err := os.ReadFile(updatedAuthorsPath(cfg)); err == nil {
+			return indexedAuthors(string(data)), nil
+		}
+		return indexedAuthors(authorsIndexData), nil
+	}
+	data, err := os.ReadFile(expandHome(cfg.AuthorsFile))
+	if err != nil {
+		return nil, err
+	}
+	names, err := loadAuthorsFromEmbedded(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return newAuthorCatalog(names), nil
+}
+
+// authorQuery is the part of the search box matched against author names.
+func authorQuery(input string) string {
+	if q := parseSearchQuery(input); q.hasFields() || q.Work != "" {
+		return q.Author
+	}
+	return input
+}
+
+// refreshCmd re-reads the authors catalog and rescans the library, for
+// files changed while gutberg is running.
+func refreshCmd(cfg Config, state State) loadCmd {
+	return func(context.Context) tea.Msg {
+		authors, err := loadAuthors(cfg)
+		if err != nil {
+			return refreshMsg{err: fmt.Errorf(tr("load authors: %w"), err)}
+		}
+		items, err := loadLibraryItems(cfg.BooksDir, state, cfg.WPM)
+		if err != nil {
+			return refreshMsg{err: err}
+		}
+		return refreshMsg{authors: authors, library: items}
+	}
+}
+
+// updateRefresh swaps in the new catalog and library and drops what was
+// derived from the old ones: the author index, the search matches, cover
+// thumbnails and anything prefetched.
+func (m model) updateRefresh(msg refreshMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = msg.err.Error()
+		return m, nil
+	}
+	m.authors = msg.authors
+	m.indexLetters = nil
+	m.authorList.SetItems(filterAuthors(m.authors, authorQuery(m.authorInput.Value()), 200))
+	clear(m.covers)
+	m.prefetch.forget("")
+	m.status = trf("Reloaded %d authors and %d library books", msg.authors.Len(), len(msg.library))
+	return m, tea.Batch(
+		m.libraryList.SetItems(msg.library),
+		m.libraryList.NewStatusMessage(m.status),
+		fetchCoversCmd(m.config.CacheDir, coverIDs(msg.library, m.covers)),
+		fetchCoversCmd(m.config.CacheDir, coverIDs(m.bookList.Items(), m.covers)),
+	)
+}
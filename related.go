@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	maxRelated          = 6
+	maxRelatedPerSource = 3
+)
+
+// relatedMsg carries the catalog books like the one finished, for the end
+// of book screen of key.
+type relatedMsg struct {
+	key   string
+	books []suggestion
+	err   error
+}
+
+// relatedBooksCmd looks up books like the Gutenberg book id on Gutendex:
+// the most read by the same author, then the most read on its first
+// subject. Books in exclude, the library's, are left out.
+func relatedBooksCmd(key, id string, exclude map[int]bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		var book gutendexBook
+		if err := fetchGutendex(ctx, "https://gutendex.com/books/"+url.PathEscape(id), &book); err != nil {
+			return relatedMsg{key: key, err: err}
+		}
+		exclude[book.ID] = true
+		var out []suggestion
+		add := func(query, reason string) error {
+			var data struct {
+				Results []gutendexBook `json:"results"`
+			}
+			if err := fetchGutendex(ctx, "https://gutendex.com/books/?"+query, &data); err != nil {
+				return err
+			}
+			added := 0
+			for _, b := range data.Results {
+				if added == maxRelatedPerSource || len(out) == maxRelated {
+					break
+				}
+				if exclude[b.ID] || !allowedBook(b) {
+					continue
+				}
+				exclude[b.ID] = true
+				r := b.result()
+				out = append(out, suggestion{label: displayTitle(b.Title), reason: reason, result: &r})
+				added++
+			}
+			return nil
+		}
+		if len(book.Authors) > 0 {
+			author := book.Authors[0].Name
+			if err := add("search="+url.QueryEscape(author), trf("by %s", authorDisplayName(author))); err != nil {
+				return relatedMsg{key: key, err: err}
+			}
+		}
+		if len(book.Subjects) > 0 {
+			subject := book.Subjects[0]
+			if err := add("topic="+url.QueryEscape(subject), trf("also on %s", subjectHeading(subject))); err != nil {
+				return relatedMsg{key: key, err: err}
+			}
+		}
+		return relatedMsg{key: key, books: out}
+	}
+}
+
+// authorDisplayName turns a catalog name, "Hugo, Victor", into "Victor
+// Hugo".
+func authorDisplayName(name string) string {
+	last, first, ok := strings.Cut(name, ", ")
+	if !ok {
+		return name
+	}
+	return first + " " + last
+}
+
+// subjectHeading is the first part of a subject heading, "France" of
+// "France -- History -- 19th century -- Fiction".
+func subjectHeading(subject string) string {
+	heading, _, _ := strings.Cut(subject, " -- ")
+	return heading
+}
+
+// libraryIDs are the Gutenberg numbers of the books in the library.
+func (m model) libraryIDs() map[int]bool {
+	ids := make(map[int]bool)
+	for _, item := range m.libraryList.Items() {
+		if item, ok := item.(libraryItem); ok {
+			if n, err := strconv.Atoi(item.id); err == nil {
+				ids[n] = true
+			}
+		}
+	}
+	return ids
+}
+
+// findRelated starts looking up books like the finished one, when it came
+// from Gutenberg.
+func (m model) findRelated(key string) tea.Cmd {
+	path, story := splitStoryKey(key)
+	if story >= 0 {
+		return nil
+	}
+	lib, _ := loadLibrary(filepath.Dir(path))
+	id := lib.Books[filepath.Base(path)].ID
+	if id == "" {
+		return nil
+	}
+	return relatedBooksCmd(key, id, m.libraryIDs())
+}
+
+func (m model) updateRelated(msg relatedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		debugLog.Warn("related books not found", "book", msg.key, "err", msg.err)
+		return m, nil
+	}
+	if m.mode != modeFinished || msg.key != m.state.CurrentBook {
+		return m, nil
+	}
+	m.finish.suggestions = append(m.finish.suggestions, msg.books...)
+	return m, nil
+}
+
+// downloadRelated downloads a suggestion from the catalog.
+func (m *model) downloadRelated(s suggestion) tea.Cmd {
+	return m.download(bookItem{result: *s.result, source: gutenbergSource{language: m.config.Language}})
+}
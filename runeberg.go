@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"sync"
+
+	xhtml "golang.org/x/net/html"
+)
+
+const (
+	runebergBaseURL    = "https://runeberg.org/"
+	runebergCatalogURL = runebergBaseURL + "katalog.html"
+	maxRunebergResults = 100
+)
+
+var runebergWorkRe = regexp.MustCompile(`^/?([a-z0-9][a-z0-9_-]*)/$`)
+
+// runebergSource searches Project Runeberg, the Nordic counterpart of
+// Gutenberg. It has no search API, so its catalog page is scraped once per
+// session and matched locally; books are fetched as their OCR text edition.
+type runebergSource struct{}
+
+type runebergWork struct {
+	dir    string
+	title  string
+	author string
+	key    string
+}
+
+var runebergCatalog struct {
+	mu    sync.Mutex
+	works []runebergWork
+}
+
+func (runebergSource) Name() string { return "Project Runeberg" }
+
+func (runebergSource) Search(ctx context.Context, query string) ([]bookResult, error) {
+	works, err := loadRunebergCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokens := tokenize(foldString(parseSearchQuery(query).keywords()))
+	var books []bookResult
+	for _, work := range works {
+		if !matchesAllTokens(work.key, tokens) {
+			continue
+		}
+		books = append(books, bookResult{
+			Title:    work.title,
+			Subtitle: work.author,
+			URL:      runebergBaseURL + work.dir + "/",
+		})
+		if len(books) == maxRunebergResults {
+			break
+		}
+	}
+	return books, nil
+}
+
+func (runebergSource) Download(ctx context.Context, result bookResult, outDir string, progress progressFunc) (string, error) {
+	dir := runebergWorkRe.FindStringSubmatch(strings.TrimPrefix(result.URL, strings.TrimSuffix(runebergBaseURL, "/")))
+	if dir == nil {
+		return "", fmt.Errorf(tr("not a Project Runeberg work: %s"), result.URL)
+	}
+	href := runebergBaseURL + "download.pl?mode=ocrtext&work=" + dir[1]
+	resp, err := getURL(ctx, featureDownload, href)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "" && mediaType != "text/plain" {
+		return "", fmt.Errorf(tr("no text edition of %s on Project Runeberg"), result.Title)
+	}
+	data, err := io.ReadAll(trackProgress(resp, progress))
+	if err != nil {
+		return "", err
+	}
+	page := plainTextToHTML(result.Title, string(decodeText(data, contentType)))
+
+	fileName := buildBookFileName(result.Subtitle, result.Title, result.URL)
+	entry := LibraryEntry{Title: result.Title, Author: result.Subtitle, Source: result.URL}
+	return storeBook(outDir, fileName, bytes.NewReader(page), entry)
+}
+
+func loadRunebergCatalog(ctx context.Context) ([]runebergWork, error) {
+	runebergCatalog.mu.Lock()
+	defer runebergCatalog.mu.Unlock()
+	if runebergCatalog.works != nil {
+		return runebergCatalog.works, nil
+	}
+
+	resp, err := getURL(ctx, featureSearch, runebergCatalogURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	works := parseRunebergCatalog(parseHTML(decodeText(data, resp.Header.Get("Content-Type"))))
+	if len(works) == 0 {
+		return nil, errors.New(tr("no works found in the Project Runeberg catalog"))
+	}
+	runebergCatalog.works = works
+	return works, nil
+}
+
+// parseRunebergCatalog reads the catalog table: each row links to a work
+// directory and names its author in the cells around the title.
+func parseRunebergCatalog(root *xhtml.Node) []runebergWork {
+	var works []runebergWork
+	seen := make(map[string]bool)
+	var walk func(*xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if n.Type == xhtml.ElementNode && n.Data == "tr" {
+			if work, ok := runebergRow(n); ok && !seen[work.dir] {
+				seen[work.dir] = true
+				works = append(works, work)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return works
+}
+
+func runebergRow(tr *xhtml.Node) (runebergWork, bool) {
+	var work runebergWork
+	var authors []string
+	for td := tr.FirstChild; td != nil; td = td.NextSibling {
+		if td.Type != xhtml.ElementNode || td.Data != "td" {
+			continue
+		}
+		if link := findWorkLink(td); link != nil && work.dir == "" {
+			href, _ := attr(link, "href")
+			work.dir = runebergWorkRe.FindStringSubmatch(href)[1]
+			work.title = compactSpaces(textContent(link))
+			continue
+		}
+		if text := compactSpaces(textContent(td)); text != "" && work.dir == "" {
+			authors = append(authors, text)
+		}
+	}
+	if work.dir == "" || work.title == "" {
+		return work, false
+	}
+	work.author = strings.Join(authors, ", ")
+	work.key = foldString(work.title + " " + work.author)
+	return work, true
+}
+
+func findWorkLink(n *xhtml.Node) *xhtml.Node {
+	if n.Type == xhtml.ElementNode && n.Data == "a" {
+		if href, _ := attr(n, "href"); runebergWorkRe.MatchString(href) {
+			return n
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if link := findWorkLink(c); link != nil {
+			return link
+		}
+	}
+	return nil
+}
+
+func matchesAllTokens(key string, tokens []string) bool {
+	for _, token := range tokens {
+		if !strings.Contains(key, token) {
+			return false
+		}
+	}
+	return true
+}
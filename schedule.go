@@ -0,0 +1,128 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const scheduleDateLayout = "2006-01-02"
+
+// scheduleLengths are the schedules the reader cycles through, in days.
+var scheduleLengths = []int{7, 14, 30, 60, 90, 365}
+
+// Schedule spreads what is left of a book over a number of days. Targets
+// are fractions of the book, so they survive a change of page size; Pages
+// is the page count last seen, for listing targets without opening it.
+type Schedule struct {
+	Start string  `json:"start"`
+	Days  int     `json:"days"`
+	From  float64 `json:"from"`
+	Pages int     `json:"pages"`
+}
+
+func newSchedule(days, page, pages int, now time.Time) Schedule {
+	return Schedule{Start: now.Format(scheduleDateLayout), Days: days, From: float64(page) / float64(max(pages, 1)), Pages: pages}
+}
+
+// day returns the zero-based day of the schedule, counted in calendar days.
+func (s Schedule) day(now time.Time) int {
+	start, err := time.ParseInLocation(scheduleDateLayout, s.Start, now.Location())
+	if err != nil {
+		return 0
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return max(int(math.Round(today.Sub(start).Hours()/24)), 0)
+}
+
+// target returns the page number (1-based) to reach by the end of day.
+func (s Schedule) target(day, pages int) int {
+	if day >= s.Days-1 {
+		return pages
+	}
+	frac := s.From + (1-s.From)*float64(day+1)/float64(s.Days)
+	return min(int(math.Ceil(frac*float64(pages))), pages)
+}
+
+// daysBehind counts the past days whose target is still ahead of page.
+func (s Schedule) daysBehind(page, pages int, now time.Time) int {
+	day := min(s.day(now), s.Days)
+	behind := 0
+	for d := day - 1; d >= 0 && s.target(d, pages) > page+1; d-- {
+		behind++
+	}
+	return behind
+}
+
+// scheduleStatus describes today's goal for a book at page (0-based).
+func scheduleStatus(s Schedule, page, pages int, now time.Time) string {
+	if pages <= 0 {
+		return ""
+	}
+	day := s.day(now)
+	if page+1 >= pages {
+		return tr("schedule done, book finished")
+	}
+	if day >= s.Days {
+		return trf("schedule over %d days ago, %d pages left", day-s.Days+1, pages-page-1)
+	}
+	status := trf("day %d/%d", day+1, s.Days)
+	target := s.target(day, pages)
+	if page+1 >= target {
+		return status + tr(": today's pages read")
+	}
+	status += trf(": read to page %d (%d to go)", target, target-page-1)
+	if behind := s.daysBehind(page, pages, now); behind > 0 {
+		status += trf(", %d %s behind", behind, plural(behind, tr("day"), tr("days")))
+	}
+	return status
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// cycleSchedule moves the open book to the next schedule length, starting
+// it from the current page, or drops the schedule after the longest one.
+func (m *model) cycleSchedule() {
+	key := m.state.CurrentBook
+	old, ok := m.state.Schedules[key]
+	next := scheduleLengths[0]
+	if ok {
+		next = 0
+		for i, days := range scheduleLengths {
+			if days == old.Days && i+1 < len(scheduleLengths) {
+				next = scheduleLengths[i+1]
+			}
+		}
+	}
+	if next == 0 {
+		delete(m.state.Schedules, key)
+		m.status = tr("Reading schedule removed")
+		return
+	}
+	if m.state.Schedules == nil {
+		m.state.Schedules = make(map[string]Schedule)
+	}
+	pages := m.currentBook.PageCount()
+	s := newSchedule(next, m.state.Page, pages, time.Now())
+	m.state.Schedules[key] = s
+	m.status = trf("Finish in %d days: %s", next, scheduleStatus(s, m.state.Page, pages, time.Now()))
+}
+
+// trackSchedule keeps the page count of a scheduled book current and, when
+// the reader is behind, says so.
+func (m *model) trackSchedule(nudge bool) {
+	s, ok := m.state.Schedules[m.state.CurrentBook]
+	if !ok {
+		return
+	}
+	pages := m.currentBook.PageCount()
+	s.Pages = pages
+	m.state.Schedules[m.state.CurrentBook] = s
+	if nudge && s.daysBehind(m.state.Page, pages, time.Now()) > 0 {
+		m.status = trf("Behind schedule: %s", scheduleStatus(s, m.state.Page, pages, time.Now()))
+	}
+}
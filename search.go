@@ -0,0 +1,117 @@
+package main
+
+import "strings"
+
+type searchQuery struct {
+	Author   string
+	Title    string
+	Subject  string
+	Language string
+	Century  string
+	Work     string
+	Terms    []string
+}
+
+func parseSearchQuery(input string) searchQuery {
+	var q searchQuery
+	for _, token := range splitQueryTokens(input) {
+		field, value, ok := strings.Cut(token, ":")
+		if !ok || value == "" {
+			q.Terms = append(q.Terms, token)
+			continue
+		}
+		value = strings.Trim(value, "\"")
+		switch strings.ToLower(field) {
+		case "author", "a":
+			q.Author = joinTerms(q.Author, value)
+		case "title", "t":
+			q.Title = joinTerms(q.Title, value)
+		case "subject", "s":
+			q.Subject = joinTerms(q.Subject, value)
+		case "work", "w":
+			q.Work = joinTerms(q.Work, value)
+		case "lang", "language", "l":
+			q.Language = strings.ToLower(value)
+		case "century", "c":
+			q.Century = value
+		default:
+			q.Terms = append(q.Terms, token)
+		}
+	}
+	return q
+}
+
+func (q searchQuery) hasFields() bool {
+	return q.Author != "" || q.Title != "" || q.Subject != "" || q.Language != "" || q.Century != ""
+}
+
+func (q searchQuery) workTitle() string {
+	if q.Work == "" {
+		return ""
+	}
+	return strings.Join(append([]string{q.Work}, q.Terms...), " ")
+}
+
+func (q searchQuery) gutenberg() string {
+	parts := []string{}
+	for _, field := range []struct{ prefix, value string }{
+		{"a.", q.Author},
+		{"t.", q.Title},
+		{"s.", q.Subject},
+	} {
+		for _, word := range strings.Fields(field.value) {
+			parts = append(parts, field.prefix+word)
+		}
+	}
+	if q.Language != "" {
+		parts = append(parts, "l."+q.Language)
+	}
+	parts = append(parts, q.Terms...)
+	return strings.Join(parts, " ")
+}
+
+func (q searchQuery) keywords() string {
+	parts := []string{}
+	for _, value := range []string{q.Author, q.Title, q.Subject} {
+		if value != "" {
+			parts = append(parts, value)
+		}
+	}
+	parts = append(parts, q.Terms...)
+	return strings.Join(parts, " ")
+}
+
+func isFieldQuery(input string) bool {
+	return parseSearchQuery(input).hasFields()
+}
+
+func splitQueryTokens(input string) []string {
+	var tokens []string
+	var b strings.Builder
+	inQuotes := false
+	for _, r := range input {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			b.WriteRune(r)
+		case r == ' ' && !inQuotes:
+			if b.Len() > 0 {
+				tokens = append(tokens, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		tokens = append(tokens, b.String())
+	}
+	return tokens
+}
+
+func joinTerms(existing, value string) string {
+	if existing == "" {
+		return value
+	}
+	return existing + " " + value
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	defaultSMTPPort       = 587
+	statusMessageLifetime = 10 * time.Second
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       string
+}
+
+type sentMsg struct {
+	title string
+	to    string
+	err   error
+}
+
+func sendBookCmd(cfg SMTPConfig, path string) tea.Cmd {
+	return func() tea.Msg {
+		title, err := sendBook(cfg, path)
+		return sentMsg{title: title, to: cfg.To, err: err}
+	}
+}
+
+func sendBook(cfg SMTPConfig, path string) (string, error) {
+	if cfg.Host == "" || cfg.To == "" {
+		return "", errors.New(tr("set host and to in the [smtp] section of the config to send books"))
+	}
+	book, err := loadBookFromHTML(path, pageLineWidth, pageLineCount)
+	if err != nil {
+		return "", err
+	}
+	lib, err := loadLibrary(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(path)
+	entry := lib.Books[name]
+	id := entry.ID
+	if id == "" {
+		id = strings.TrimSuffix(name, ".html")
+	}
+	data, err := bookToEPUB(book, entry.Author, id)
+	if err != nil {
+		return "", err
+	}
+	msg, err := buildBookMail(cfg, book.Title, strings.TrimSuffix(name, ".html")+".epub", data)
+	if err != nil {
+		return "", err
+	}
+	return book.Title, sendMail(cfg, msg)
+}
+
+func buildBookMail(cfg SMTPConfig, title, fileName string, attachment []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\n",
+		smtpFrom(cfg), cfg.To, mime.QEncoding.Encode("utf-8", title), time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	text, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(text, "%s, sent from gutberg.\r\n", title)
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType("application/epub+zip", map[string]string{"name": fileName})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": fileName})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(attachment)
+	for len(encoded) > 76 {
+		fmt.Fprintf(part, "%s\r\n", encoded[:76])
+		encoded = encoded[76:]
+	}
+	fmt.Fprintf(part, "%s\r\n", encoded)
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func sendMail(cfg SMTPConfig, msg []byte) error {
+	port := cfg.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	entry := auditEntry{Time: time.Now(), Feature: featureEmail, Host: cfg.Host, URL: "smtp://" + addr}
+	if !netAudit.allowed(featureEmail) {
+		entry.Blocked = true
+		netAudit.record(entry)
+		return blockedError(featureEmail)
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	var err error
+	if port == 465 {
+		err = sendMailTLS(addr, cfg.Host, auth, smtpFrom(cfg), cfg.To, msg)
+	} else {
+		err = smtp.SendMail(addr, auth, smtpFrom(cfg), []string{cfg.To}, msg)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	netAudit.record(entry)
+	return err
+}
+
+func sendMailTLS(addr, host string, auth smtp.Auth, from, to string, msg []byte) error {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func smtpFrom(cfg SMTPConfig) string {
+	if cfg.From != "" {
+		return cfg.From
+	}
+	return cfg.Username
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	serialsFileName    = "serials.json"
+	defaultSerialChunk = "10min"
+)
+
+var chunkRe = regexp.MustCompile(`^(\d+)\s*(m|min|mins|minutes?|w|words?)$`)
+
+// serialProgress is how far a book has been mailed: the next paragraph to
+// send and the number of installments sent so far.
+type serialProgress struct {
+	Chapter   int    `json:"chapter"`
+	Paragraph int    `json:"paragraph"`
+	Part      int    `json:"part"`
+	Sent      string `json:"sent,omitempty"`
+}
+
+type installment struct {
+	part     int
+	chapters []string
+	text     string
+	html     string
+	next     serialProgress
+	last     bool
+}
+
+// runSerialize implements "gutberg serialize": it mails the next installment
+// of a book, once a day. Without -daily it sends one and exits, for cron;
+// with it, it keeps running and sends each day at that time.
+func runSerialize(cfg Config, args []string) error {
+	fs := flag.NewFlagSet("serialize", flag.ExitOnError)
+	bookArg := fs.String("book", "", tr("book to send: Gutenberg number, URL or library file"))
+	chunk := fs.String("chunk", defaultSerialChunk, tr("size of each installment: reading minutes (10min) or words (1500w)"))
+	smtpAddr := fs.String("smtp", "", tr("SMTP server host[:port], instead of the configured one"))
+	to := fs.String("to", "", tr("recipient, instead of the configured one"))
+	daily := fs.String("daily", "", tr("keep running and send every day at this time (HH:MM)"))
+	force := fs.Bool("force", false, tr("send even if an installment already went out today"))
+	fs.Usage = func() {
+		fmt.Println(tr("Usage: gutberg serialize -book 2600 [-chunk 10min] [-smtp host:port] [-to address] [-daily 07:00] [-force]"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *bookArg == "" {
+		fs.Usage()
+		return errors.New(tr("serialize needs -book"))
+	}
+	words, err := chunkWords(*chunk, cfg.WPM)
+	if err != nil {
+		return err
+	}
+	smtpCfg := cfg.SMTP
+	if *smtpAddr != "" {
+		host, port, err := net.SplitHostPort(*smtpAddr)
+		if err != nil {
+			host, port = *smtpAddr, ""
+		}
+		smtpCfg.Host = host
+		if port != "" {
+			if smtpCfg.Port, err = strconv.Atoi(port); err != nil {
+				return fmt.Errorf(tr("bad SMTP port %q"), port)
+			}
+		}
+	}
+	if *to != "" {
+		smtpCfg.To = *to
+	}
+	if smtpCfg.Host == "" || smtpCfg.To == "" {
+		return errors.New(tr("set host and to in the [smtp] section of the config, or pass -smtp and -to"))
+	}
+	path, err := resolveBookArg(context.Background(), cfg.BooksDir, *bookArg, nil)
+	if err != nil {
+		return err
+	}
+
+	if *daily == "" {
+		if err := sendNextInstallment(cfg, smtpCfg, path, words, *force); !errors.Is(err, errSerialFinished) {
+			return err
+		}
+		return nil
+	}
+	at, err := time.Parse("15:04", *daily)
+	if err != nil {
+		return errors.New(tr("-daily wants a time such as 07:30"))
+	}
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		fmt.Println(trf("Next installment at %s", next.Format("2006-01-02 15:04")))
+		time.Sleep(time.Until(next))
+		if err := sendNextInstallment(cfg, smtpCfg, path, words, false); err != nil {
+			if errors.Is(err, errSerialFinished) {
+				return nil
+			}
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+var errSerialFinished = errors.New("every installment has been sent")
+
+func sendNextInstallment(cfg Config, smtpCfg SMTPConfig, path string, words int, force bool) error {
+	serials, err := loadSerials(cfg)
+	if err != nil {
+		return err
+	}
+	progress := serials[path]
+	today := time.Now().Format(scheduleDateLayout)
+	if progress.Sent == today && !force {
+		fmt.Println(trf("Installment %d already sent today (use -force to send the next one)", progress.Part))
+		return nil
+	}
+	book, err := loadBookFromHTML(path, pageLineWidth, pageLineCount)
+	if err != nil {
+		return err
+	}
+	inst, ok := nextInstallment(book, progress, words)
+	if !ok {
+		fmt.Println(trf("%s: every installment has been sent", book.Title))
+		return errSerialFinished
+	}
+	msg, err := buildInstallmentMail(smtpCfg, book.Title, inst)
+	if err != nil {
+		return err
+	}
+	if err := sendMail(smtpCfg, msg); err != nil {
+		return err
+	}
+	inst.next.Sent = today
+	serials[path] = inst.next
+	if err := saveSerials(cfg, serials); err != nil {
+		return err
+	}
+	debugLog.Info("installment sent", "path", path, "part", inst.part, "to", smtpCfg.To)
+	fmt.Println(trf("Sent installment %d of %s to %s", inst.part, book.Title, smtpCfg.To))
+	return nil
+}
+
+// chunkWords reads an installment size given as minutes of reading, at the
+// configured speed, or as a word count.
+func chunkWords(chunk string, wpm int) (int, error) {
+	m := chunkRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(chunk)))
+	if m == nil {
+		return 0, fmt.Errorf(tr("chunk must be minutes (10min) or words (1500w), not %q"), chunk)
+	}
+	n, _ := strconv.Atoi(m[1])
+	if n <= 0 {
+		return 0, errors.New(tr("chunk must be more than zero"))
+	}
+	if strings.HasPrefix(m[2], "w") {
+		return n, nil
+	}
+	return n * max(wpm, minWPM), nil
+}
+
+// resolveBookArg finds the book a subcommand names: a file, a library book by file
+// name, or a Gutenberg number or URL, downloaded into the library if needed.
+func resolveBookArg(ctx context.Context, booksDir, arg string, progress progressFunc) (string, error) {
+	for _, candidate := range []string{arg, filepath.Join(booksDir, arg), filepath.Join(booksDir, arg+".html")} {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return downloadBookHTML(ctx, arg, "", "", "", booksDir, progress)
+}
+
+// nextInstallment gathers whole paragraphs from where the last installment
+// stopped until about words words, crossing into the following chapters.
+func nextInstallment(book Book, from serialProgress, words int) (installment, bool) {
+	inst := installment{part: from.Part + 1, next: serialProgress{Chapter: from.Chapter, Paragraph: from.Paragraph, Part: from.Part + 1}}
+	var text, body strings.Builder
+	count := 0
+	for c := from.Chapter; c < len(book.Chapters) && count < words; c++ {
+		paras := strings.Split(book.ChapterText(c), paragraphBreak)
+		start := 0
+		if c == from.Chapter {
+			start = from.Paragraph
+		}
+		if start == 0 {
+			title := book.Chapters[c].Title
+			inst.chapters = append(inst.chapters, title)
+			fmt.Fprintf(&text, "%s\n\n", strings.ToUpper(title))
+			fmt.Fprintf(&body, "<h2>%s</h2>\n", html.EscapeString(title))
+		}
+		for p := start; p < len(paras); p++ {
+			plain := strings.TrimSpace(strings.ReplaceAll(stripStyles(paras[p]), verseMark, ""))
+			if plain == "" {
+				continue
+			}
+			text.WriteString(plain + "\n\n")
+			body.WriteString(paragraphXHTML(paras[p]))
+			count += len(strings.Fields(plain))
+			if count >= words {
+				inst.next.Chapter, inst.next.Paragraph = c, p+1
+				if p+1 >= len(paras) {
+					inst.next.Chapter, inst.next.Paragraph = c+1, 0
+				}
+				break
+			}
+		}
+		if count < words {
+			inst.next.Chapter, inst.next.Paragraph = c+1, 0
+		}
+	}
+	if count == 0 {
+		return installment{}, false
+	}
+	inst.last = inst.next.Chapter >= len(book.Chapters)
+	inst.text, inst.html = text.String(), body.String()
+	return inst, true
+}
+
+// buildInstallmentMail writes an installment as plain text with an HTML
+// alternative.
+func buildInstallmentMail(cfg SMTPConfig, title string, inst installment) ([]byte, error) {
+	subject := fmt.Sprintf("%s · part %d", title, inst.part)
+	if len(inst.chapters) > 0 {
+		subject += " · " + inst.chapters[0]
+	}
+	footer := fmt.Sprintf("Part %d of %s, sent by gutberg.", inst.part, title)
+	if inst.last {
+		footer = fmt.Sprintf("The end. This was the last part of %s, sent by gutberg.", title)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\n",
+		smtpFrom(cfg), cfg.To, mime.QEncoding.Encode("utf-8", subject), time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	// Quoted-printable keeps the lines of long paragraphs within what mail
+	// servers accept, and the text readable in the raw message.
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}, "Content-Transfer-Encoding": {"quoted-printable"}})
+	if err != nil {
+		return nil, err
+	}
+	text := quotedprintable.NewWriter(part)
+	fmt.Fprintf(text, "%s\r\n-- \r\n%s\r\n", strings.ReplaceAll(inst.text, "\n", "\r\n"), footer)
+	if err := text.Close(); err != nil {
+		return nil, err
+	}
+
+	part, err = mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/html; charset=utf-8"}, "Content-Transfer-Encoding": {"quoted-printable"}})
+	if err != nil {
+		return nil, err
+	}
+	page := quotedprintable.NewWriter(part)
+	fmt.Fprintf(page, "<!DOCTYPE html>\r\n<html><head><meta charset=\"utf-8\"><title>%s</title></head>\r\n<body style=\"max-width:36em;margin:auto;font-family:Georgia,serif;line-height:1.5\">\r\n%s<hr>\r\n<p><small>%s</small></p>\r\n</body></html>\r\n",
+		html.EscapeString(subject), strings.ReplaceAll(inst.html, "\n", "\r\n"), html.EscapeString(footer))
+	if err := page.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func serialsPath(cfg Config) string {
+	return filepath.Join(filepath.Dir(cfg.StateFile), serialsFileName)
+}
+
+func loadSerials(cfg Config) (map[string]serialProgress, error) {
+	serials := make(map[string]serialProgress)
+	data, err := os.ReadFile(serialsPath(cfg))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return serials, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &serials); err != nil {
+		return nil, err
+	}
+	return serials, nil
+}
+
+func saveSerials(cfg Config, serials map[string]serialProgress) error {
+	data, err := json.MarshalIndent(serials, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(serialsPath(cfg), data, 0o644)
+}
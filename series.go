@@ -0,0 +1,191 @@
+package main
+
+import (
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+const seriesSampleBytes = 8 << 10
+
+// seriesTitleRe splits a title into its series, its volume and the
+// volume's own title: "Henry VI, Part 3", "Historia de España — Tomo II
+// (of 6)" or Gutenberg's "Les Misérables, v. 2/5: Cosette". A bare number
+// is not enough, or "Henry V" would be volume 5 of Henry.
+var seriesTitleRe = regexp.MustCompile(`(?i)^(.*?)[\s,.:;—–-]+(?:vol(?:ume|umen)?\.?|v\.|part(?:e|ie)?|book|libro|livre|tome|tomo|band|teil)\s*(\d+|[ivxlc]+)\b\.?(?:\s*\(of\s+\d+\)|/\d+)?(?:\s*[:;—–-]\s*(.+))?$`)
+
+// seriesMetaRe finds the series calibre records in the head of a book, as
+// epubToHTML writes it too.
+var seriesMetaRe = regexp.MustCompile(`(?is)<meta\s+name\s*=\s*["']calibre:series(_index)?["']\s+content\s*=\s*["']([^"']*)["']`)
+
+// bookSeries is the series a library book belongs to and its volume: the
+// ones its entry records, from the book's metadata, or those its title
+// names.
+func bookSeries(entry LibraryEntry, title string) (string, int, bool) {
+	if entry.Series != "" && entry.Volume > 0 {
+		return entry.Series, entry.Volume, true
+	}
+	series, volume, _, ok := titleSeries(title)
+	return series, volume, ok
+}
+
+// titleSeries is the series, volume and volume title a title names.
+func titleSeries(title string) (string, int, string, bool) {
+	match := seriesTitleRe.FindStringSubmatch(displayTitle(title))
+	if match == nil || strings.TrimSpace(match[1]) == "" {
+		return "", 0, "", false
+	}
+	n, err := strconv.Atoi(match[2])
+	if err != nil {
+		n = romanValue(strings.ToUpper(match[2]))
+	}
+	if n <= 0 {
+		return "", 0, "", false
+	}
+	return strings.TrimSpace(match[1]), n, strings.TrimSpace(match[3]), true
+}
+
+// groupSeries marks the library items that are volumes of a series with
+// more than one of them in the library, so they are listed together under
+// the series name in volume order.
+func groupSeries(items []list.Item, lib Library) {
+	names := make(map[string]string)
+	counts := make(map[string]int)
+	for i, item := range items {
+		item := item.(libraryItem)
+		entry := lib.Books[filepath.Base(item.path)]
+		title := item.title
+		if entry.Title != "" {
+			title = entry.Title
+		}
+		series, volume, ok := bookSeries(entry, title)
+		if !ok {
+			continue
+		}
+		folded := foldString(series)
+		if _, seen := names[folded]; !seen {
+			names[folded] = series
+		}
+		counts[folded]++
+		item.series, item.volume = series, volume
+		if _, _, subtitle, named := titleSeries(title); named {
+			item.volumeTitle = subtitle
+		} else {
+			item.volumeTitle = displayTitle(title)
+		}
+		items[i] = item
+	}
+	for i, item := range items {
+		item := item.(libraryItem)
+		if item.series == "" {
+			continue
+		}
+		folded := foldString(item.series)
+		if counts[folded] < 2 {
+			item.series, item.volume, item.volumeTitle = "", 0, ""
+		} else {
+			item.series = names[folded]
+		}
+		items[i] = item
+	}
+}
+
+// sortName is the name the library sorts an item by: its series, for the
+// volumes of one.
+func (l libraryItem) sortName() string {
+	if l.series != "" {
+		return l.series
+	}
+	return l.title
+}
+
+func romanValue(s string) int {
+	values := map[byte]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100}
+	total := 0
+	for i := 0; i < len(s); i++ {
+		v := values[s[i]]
+		if i+1 < len(s) && values[s[i+1]] > v {
+			total -= v
+		} else {
+			total += v
+		}
+	}
+	return total
+}
+
+// seriesIndex reads a series index, which calibre writes as "2.0".
+func seriesIndex(s string) int {
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// declaredSeries is the series the head of an HTML book declares, if any.
+func declaredSeries(data []byte) (string, int) {
+	if len(data) > seriesSampleBytes {
+		data = data[:seriesSampleBytes]
+	}
+	var series string
+	var volume int
+	for _, match := range seriesMetaRe.FindAllSubmatch(data, -1) {
+		if len(match[1]) > 0 {
+			volume = seriesIndex(html.UnescapeString(string(match[2])))
+		} else {
+			series = strings.TrimSpace(html.UnescapeString(string(match[2])))
+		}
+	}
+	if series == "" || volume <= 0 {
+		return "", 0
+	}
+	return series, volume
+}
+
+// fileSeries is the series the book at path declares.
+func fileSeries(path string) (string, int) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0
+	}
+	defer f.Close()
+	data := make([]byte, seriesSampleBytes)
+	n, _ := f.Read(data)
+	return declaredSeries(data[:n])
+}
+
+// series is the series an EPUB package records: calibre's metadata or, in
+// EPUB 3, a collection with its group position.
+func (p epubPackage) series() (string, int) {
+	var name, index string
+	for _, meta := range p.Metas {
+		switch meta.Name {
+		case "calibre:series":
+			name = meta.Content
+		case "calibre:series_index":
+			index = meta.Content
+		}
+	}
+	if name == "" {
+		for _, meta := range p.Metas {
+			if meta.Property != "belongs-to-collection" || meta.ID == "" {
+				continue
+			}
+			for _, refine := range p.Metas {
+				if refine.Refines == "#"+meta.ID && refine.Property == "group-position" {
+					name, index = meta.Value, refine.Value
+				}
+			}
+		}
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || seriesIndex(index) <= 0 {
+		return "", 0
+	}
+	return name, seriesIndex(index)
+}
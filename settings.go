@@ -0,0 +1,420 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultWPM = 250
+	minWPM     = 50
+	maxWPM     = 2000
+)
+
+type settingKind int
+
+const (
+	settingChoice settingKind = iota
+	settingText
+)
+
+type settingField struct {
+	key     string
+	label   string
+	kind    settingKind
+	choices func() []string
+	empty   string
+}
+
+var settingFields = []settingField{
+	{key: "theme", label: "Theme", kind: settingChoice, choices: themeNames},
+	{key: "colors", label: "Colors", kind: settingChoice, choices: func() []string { return colorModes }},
+	{key: "ui_language", label: "Interface language", kind: settingChoice, choices: func() []string { return uiLanguages }},
+	{key: "download_format", label: "Download format", kind: settingChoice, choices: func() []string { return downloadFormats }},
+	{key: "language", label: "Search language", kind: settingText, empty: "(any)"},
+	{key: "books_dir", label: "Books directory", kind: settingText},
+	{key: "export_dir", label: "Export directory", kind: settingText},
+	{key: "keymap", label: "Keymap profile", kind: settingChoice, choices: func() []string { return keymapProfiles }},
+	{key: "wpm", label: "Reading speed (WPM)", kind: settingText},
+	{key: "auto_turn", label: "Auto page turn (seconds, 0: by reading speed)", kind: settingText},
+	{key: "goal_pages", label: "Daily goal (pages, 0: none)", kind: settingText},
+	{key: "goal_minutes", label: "Daily goal (minutes, 0: none)", kind: settingText},
+	{key: "header", label: "Reader header", kind: settingText},
+	{key: "status_bar", label: "Reader status bar", kind: settingText},
+	{key: "footer", label: "Reader footer", kind: settingText},
+	{key: "large_print", label: "Large print", kind: settingChoice, choices: func() []string { return []string{"off", "on"} }},
+	{key: "progress_bar", label: "Progress bar", kind: settingChoice, choices: func() []string { return []string{"off", "on"} }},
+	{key: "mouse", label: "Mouse", kind: settingChoice, choices: func() []string { return []string{"off", "on"} }},
+	{key: "page_transition", label: "Page transition", kind: settingChoice, choices: func() []string { return pageTransitions }},
+	{key: "notify", label: "Notifications", kind: settingChoice, choices: func() []string { return notifyModes }},
+	{key: "quiet", label: "Quiet mode", kind: settingChoice, choices: func() []string { return []string{"off", "on"} }},
+	{key: "offline", label: "Offline mode", kind: settingChoice, choices: func() []string { return []string{"off", "on"} }},
+	{key: "hide_adult", label: "Hide adult books", kind: settingChoice, choices: func() []string { return []string{"off", "on"} }},
+	{key: "blocked_subjects", label: "Hidden subjects (comma separated)", kind: settingText, empty: "(none)"},
+	{key: "filters", label: "Text filters", kind: settingChoice, choices: func() []string { return presetNames }},
+	{key: "keep_boilerplate", label: "Keep license and notes", kind: settingChoice, choices: func() []string { return []string{"off", "on"} }},
+}
+
+func (m model) settingValue(key string) string {
+	switch key {
+	case "theme":
+		return m.config.Theme
+	case "colors":
+		return m.config.Colors
+	case "language":
+		return m.config.Language
+	case "books_dir":
+		return m.config.BooksDir
+	case "export_dir":
+		return m.config.ExportDir
+	case "keymap":
+		return m.config.Keymap
+	case "wpm":
+		return strconv.Itoa(m.config.WPM)
+	case "auto_turn":
+		return strconv.Itoa(m.config.AutoTurn)
+	case "goal_pages":
+		return strconv.Itoa(m.config.GoalPages)
+	case "goal_minutes":
+		return strconv.Itoa(m.config.GoalMinutes)
+	case "header":
+		return m.config.Header
+	case "status_bar":
+		return m.config.StatusBar
+	case "footer":
+		return m.config.Footer
+	case "page_transition":
+		return m.config.PageTransition
+	case "ui_language":
+		return m.config.UILanguage
+	case "download_format":
+		return m.config.DownloadFormat
+	case "notify":
+		return m.config.Notify
+	case "quiet":
+		if m.config.Quiet {
+			return "on"
+		}
+		return "off"
+	case "offline":
+		if offline() {
+			return "on"
+		}
+		return "off"
+	case "hide_adult":
+		if m.config.HideAdult {
+			return "on"
+		}
+		return "off"
+	case "blocked_subjects":
+		return strings.Join(m.config.BlockedSubjects, ", ")
+	case "filters":
+		return m.config.Filters.Default
+	case "keep_boilerplate":
+		if m.config.Filters.KeepBoilerplate {
+			return "on"
+		}
+		return "off"
+	case "progress_bar":
+		if m.config.ProgressBar {
+			return "on"
+		}
+		return "off"
+	case "mouse":
+		if m.config.Mouse {
+			return "on"
+		}
+		return "off"
+	case "large_print":
+		if m.config.LargePrint {
+			return "on"
+		}
+		return "off"
+	}
+	return ""
+}
+
+// settingCmd is what the terminal must be told once a setting changed:
+// whether to report the mouse.
+func (m model) settingCmd(key string) tea.Cmd {
+	if key != "mouse" {
+		return nil
+	}
+	if m.config.Mouse {
+		return tea.EnableMouseCellMotion
+	}
+	return tea.DisableMouse
+}
+
+func (m *model) applySetting(key, value string) error {
+	value = strings.TrimSpace(value)
+	switch key {
+	case "theme":
+		if !setTheme(value) {
+			return fmt.Errorf(tr("unknown theme %q"), value)
+		}
+		m.config.Theme = value
+	case "colors":
+		if !setColorMode(value) {
+			return fmt.Errorf(tr("unknown color mode %q"), value)
+		}
+		m.config.Colors = value
+	case "language":
+		value = strings.ToLower(value)
+		if value != "" && (len(value) < 2 || len(value) > 3 || strings.Trim(value, "abcdefghijklmnopqrstuvwxyz") != "") {
+			return errors.New(tr("language must be a 2 or 3 letter code, e.g. en"))
+		}
+		m.config.Language = value
+		catalogLanguage = value
+		m.indexLetters = nil
+		m.sources = configuredSources(m.config)
+		if m.sourceIndex >= len(m.sources) {
+			m.sourceIndex = 0
+		}
+	case "books_dir":
+		if value == "" {
+			return errors.New(tr("books directory cannot be empty"))
+		}
+		value = expandHome(value)
+		if err := os.MkdirAll(value, 0o755); err != nil {
+			return err
+		}
+		items, err := loadLibraryItems(value, m.state, m.config.WPM)
+		if err != nil {
+			return err
+		}
+		m.config.BooksDir = value
+		m.libraryList.SetItems(items)
+	case "export_dir":
+		if value == "" {
+			return errors.New(tr("export directory cannot be empty"))
+		}
+		m.config.ExportDir = expandHome(value)
+	case "keymap":
+		if !validKeymapProfile(value) {
+			return fmt.Errorf(tr("unknown keymap profile %q"), value)
+		}
+		m.config.Keymap = value
+		m.keys = newKeymap(value, m.config.Keys)
+	case "wpm":
+		wpm, err := strconv.Atoi(value)
+		if err != nil || wpm < minWPM || wpm > maxWPM {
+			return fmt.Errorf(tr("reading speed must be between %d and %d"), minWPM, maxWPM)
+		}
+		m.config.WPM = wpm
+	case "auto_turn":
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			return errors.New(tr("auto page turn must be a number of seconds, or 0 to follow the reading speed"))
+		}
+		m.config.AutoTurn = seconds
+	case "goal_pages":
+		pages, err := strconv.Atoi(value)
+		if err != nil || pages < 0 {
+			return errors.New(tr("daily page goal must be a number of pages, or 0 for none"))
+		}
+		m.config.GoalPages = pages
+	case "goal_minutes":
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 0 {
+			return errors.New(tr("daily reading goal must be a number of minutes, or 0 for none"))
+		}
+		m.config.GoalMinutes = minutes
+	case "header":
+		m.config.Header = value
+	case "status_bar":
+		m.config.StatusBar = value
+	case "footer":
+		m.config.Footer = value
+	case "large_print":
+		m.config.LargePrint = value == "on"
+		m.applyFontScale()
+	case "progress_bar":
+		m.config.ProgressBar = value == "on"
+		m.applyFontScale()
+	case "mouse":
+		m.config.Mouse = value == "on"
+	case "page_transition":
+		if !validTransition(value) {
+			return fmt.Errorf(tr("unknown page transition %q"), value)
+		}
+		m.config.PageTransition = value
+	case "ui_language":
+		if !setUILanguage(value) {
+			return fmt.Errorf(tr("unknown interface language %q"), value)
+		}
+		m.config.UILanguage = value
+		m.indexLetters = nil
+		m.localize()
+	case "download_format":
+		if !validDownloadFormat(value) {
+			return fmt.Errorf(tr("unknown download format %q"), value)
+		}
+		m.config.DownloadFormat = value
+	case "notify":
+		if !validNotify(value) {
+			return fmt.Errorf(tr("unknown notification %q"), value)
+		}
+		m.config.Notify = value
+	case "quiet":
+		m.config.Quiet = value == "on"
+	case "offline":
+		m.config.Offline = value == "on"
+		netAudit.setOffline(m.config.Offline)
+	case "hide_adult":
+		m.config.HideAdult = value == "on"
+		configureContentFilter(m.config)
+	case "blocked_subjects":
+		m.config.BlockedSubjects = splitSubjects(value)
+		configureContentFilter(m.config)
+	case "filters":
+		if !validPreset(value) {
+			return fmt.Errorf(tr("unknown filter preset %q"), value)
+		}
+		m.config.Filters.Default = value
+		return configureFilters(m.config.Filters)
+	case "keep_boilerplate":
+		m.config.Filters.KeepBoilerplate = value == "on"
+		return configureFilters(m.config.Filters)
+	}
+	return nil
+}
+
+func (m model) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.settingsEditing {
+		return m.updateSettingsInput(msg)
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch m.keys.lookup(modeSettings, msg.String()) {
+		case actionUp:
+			if m.settingsCursor > 0 {
+				m.settingsCursor--
+			}
+		case actionDown:
+			if m.settingsCursor < len(settingFields)-1 {
+				m.settingsCursor++
+			}
+		case actionToggle:
+			field := settingFields[m.settingsCursor]
+			if field.kind == settingChoice {
+				next := nextChoice(field.choices(), m.settingValue(field.key))
+				if err := m.applySetting(field.key, next); err != nil {
+					m.status = err.Error()
+					return m, nil
+				}
+				m.status = ""
+				return m, tea.Batch(saveConfigCmd(m.config), m.settingCmd(field.key))
+			}
+			m.settingsEditing = true
+			m.settingsInput.SetValue(m.settingValue(field.key))
+			m.settingsInput.CursorEnd()
+			m.settingsInput.Focus()
+			return m, nil
+		case actionKeyTester:
+			m.testerKey = ""
+			m.mode = modeKeyTester
+		case actionBack:
+			m.status = ""
+			m.mode = modeLibrary
+		case actionQuit:
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m model) updateSettingsInput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "enter":
+			field := settingFields[m.settingsCursor]
+			if err := m.applySetting(field.key, m.settingsInput.Value()); err != nil {
+				m.status = err.Error()
+				return m, nil
+			}
+			m.status = ""
+			m.settingsEditing = false
+			m.settingsInput.Blur()
+			return m, saveConfigCmd(m.config)
+		case "esc":
+			m.status = ""
+			m.settingsEditing = false
+			m.settingsInput.Blur()
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.settingsInput, cmd = m.settingsInput.Update(msg)
+	return m, cmd
+}
+
+func (m model) settingsView() string {
+	cursorStyle := lipgloss.NewStyle().Bold(true)
+
+	lines := []string{titleStyle().Render(tr("Settings")), ""}
+	for i, field := range settingFields {
+		value := m.settingValue(field.key)
+		if i == m.settingsCursor && m.settingsEditing {
+			value = m.settingsInput.View()
+		} else if value == "" {
+			empty := field.empty
+			if empty == "" {
+				empty = "(none)"
+			}
+			value = metaStyle().Render(tr(empty))
+		} else if field.kind == settingChoice {
+			value = tr(value)
+		}
+		line := fmt.Sprintf("%-22s %s", tr(field.label), value)
+		if i == m.settingsCursor {
+			line = cursorStyle.Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "")
+	if status := m.statusText(); status != "" {
+		lines = append(lines, status, "")
+	}
+	if m.settingsEditing {
+		lines = append(lines, helpLine(tr("enter: save  esc: cancel")))
+	} else {
+		lines = append(lines, helpLine(m.keys.hint(modeSettings, actionToggle, actionKeyTester, actionBack, actionHelp, actionQuit)))
+	}
+	if conflicts := m.keys.conflicts(); len(conflicts) > 0 {
+		lines = append(lines, "", titleStyle().Render(tr("Key conflicts")))
+		for _, conflict := range conflicts {
+			lines = append(lines, conflict)
+		}
+		lines = append(lines, "")
+	}
+	lines = append(lines, metaStyle().Render(tr("Saved to ")+m.config.Path))
+	return strings.Join(lines, "\n")
+}
+
+func nextChoice(choices []string, current string) string {
+	for i, choice := range choices {
+		if choice == current {
+			return choices[(i+1)%len(choices)]
+		}
+	}
+	return choices[0]
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+type BookSource interface {
+	Name() string
+	Search(ctx context.Context, query string) ([]bookResult, error)
+	Download(ctx context.Context, result bookResult, outDir string, progress progressFunc) (string, error)
+}
+
+// pagedSource is a BookSource whose search results come a page at a time.
+type pagedSource interface {
+	BookSource
+	SearchPage(ctx context.Context, query string, page int) ([]bookResult, error)
+}
+
+type gutenbergSource struct {
+	language string
+}
+
+func (gutenbergSource) Name() string { return "Project Gutenberg" }
+
+// isGutenberg reports whether a source's books are Gutenberg ebooks, which
+// come in several formats and have covers.
+func isGutenberg(source BookSource) bool {
+	switch source.(type) {
+	case gutenbergSource, bookshelfSource:
+		return true
+	}
+	return false
+}
+
+func (s gutenbergSource) Search(ctx context.Context, query string) ([]bookResult, error) {
+	return s.SearchPage(ctx, query, 0)
+}
+
+func (s gutenbergSource) SearchPage(ctx context.Context, query string, page int) ([]bookResult, error) {
+	q := parseSearchQuery(query)
+	if q.Language == "" {
+		q.Language = s.language
+	}
+	if q.Century != "" {
+		return gutendexSearch(ctx, q, page)
+	}
+	return fetchBooks(ctx, q.gutenberg(), page)
+}
+
+func (gutenbergSource) Download(ctx context.Context, result bookResult, outDir string, progress progressFunc) (string, error) {
+	return downloadBookHTML(ctx, result.URL, result.Subtitle, result.Title, result.Format, outDir, progress)
+}
+
+type standardEbooksSource struct{}
+
+func (standardEbooksSource) Name() string { return "Standard Ebooks" }
+
+func (standardEbooksSource) Search(ctx context.Context, query string) ([]bookResult, error) {
+	query = parseSearchQuery(query).keywords()
+	searchURL := "https://standardebooks.org/ebooks?per-page=48&query=" + url.QueryEscape(query)
+	resp, err := getURL(ctx, featureSearch, searchURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	root, err := xhtml.Parse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var books []bookResult
+	var walk func(*xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if n.Type == xhtml.ElementNode && n.Data == "li" {
+			typeOf, _ := attr(n, "typeof")
+			about, ok := attr(n, "about")
+			if ok && strings.Contains(typeOf, "schema:Book") {
+				title := findPropertyText(n, "schema:name")
+				if title != "" {
+					books = append(books, bookResult{
+						Title:    strings.TrimSpace(title),
+						Subtitle: strings.TrimSpace(findClassText(n, "author")),
+						URL:      resolveURL(searchURL, about),
+					})
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	return books, nil
+}
+
+func (standardEbooksSource) Download(ctx context.Context, result bookResult, outDir string, progress progressFunc) (string, error) {
+	href := strings.TrimRight(result.URL, "/") + "/text/single-page"
+	resp, err := getURL(ctx, featureDownload, href)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	fileName := buildBookFileName(result.Subtitle, result.Title, result.URL)
+	entry := LibraryEntry{Title: result.Title, Author: result.Subtitle, Source: result.URL}
+	return storeBook(outDir, fileName, trackProgress(resp, progress), entry)
+}
+
+type opdsSource struct {
+	feed OPDSFeed
+}
+
+func (s opdsSource) Name() string {
+	if s.feed.Name != "" {
+		return s.feed.Name
+	}
+	return s.feed.URL
+}
+
+func (s opdsSource) Search(ctx context.Context, query string) ([]bookResult, error) {
+	return searchOPDS(ctx, s.feed.URL, parseSearchQuery(query).keywords())
+}
+
+func (s opdsSource) Download(ctx context.Context, result bookResult, outDir string, progress progressFunc) (string, error) {
+	return downloadOPDSBook(ctx, result.URL, result.Format, result.Subtitle, result.Title, outDir, progress)
+}
+
+func configuredSources(cfg Config) []BookSource {
+	sources := []BookSource{gutenbergSource{language: cfg.Language}, standardEbooksSource{}, runebergSource{}, gallicaSource{}}
+	for _, feed := range cfg.OPDSFeeds {
+		sources = append(sources, opdsSource{feed: feed})
+	}
+	return sources
+}
+
+func storeBook(outDir, fileName string, r io.Reader, entry LibraryEntry) (string, error) {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return "", err
+	}
+	if fileName == "" {
+		fileName = "book.html"
+	}
+	if !strings.HasSuffix(fileName, ".html") {
+		fileName += ".html"
+	}
+
+	lib, err := loadLibrary(outDir)
+	if err != nil {
+		return "", err
+	}
+	fileName = freeBookName(outDir, fileName, lib, entry)
+	outPath := filepath.Join(outDir, fileName)
+	outFile, err := os.Create(outPath)
+	if err != nil {
+		return "", err
+	}
+	defer outFile.Close()
+
+	if _, err := io.Copy(outFile, r); err != nil {
+		return "", err
+	}
+	if entry.Series == "" {
+		entry.Series, entry.Volume = fileSeries(outPath)
+	}
+
+	lib.Books[fileName] = entry
+	if err := saveLibrary(outDir, lib); err != nil {
+		return "", err
+	}
+	return outPath, nil
+}
+
+func findPropertyText(n *xhtml.Node, property string) string {
+	var out string
+	var walk func(*xhtml.Node)
+	walk = func(node *xhtml.Node) {
+		if node.Type == xhtml.ElementNode {
+			if val, _ := attr(node, "property"); val == property {
+				out = textContent(node)
+				return
+			}
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+			if out != "" {
+				return
+			}
+		}
+	}
+	walk(n)
+	return out
+}
+
+func findClassText(n *xhtml.Node, class string) string {
+	var out string
+	var walk func(*xhtml.Node)
+	walk = func(node *xhtml.Node) {
+		if node.Type == xhtml.ElementNode && hasClass(node, class) {
+			out = textContent(node)
+			return
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+			if out != "" {
+				return
+			}
+		}
+	}
+	walk(n)
+	return out
+}
+
+func sourceLabel(sources []BookSource, index int) string {
+	if len(sources) == 0 {
+		return ""
+	}
+	return trf("Source: %s (%d/%d)", sources[index].Name(), index+1, len(sources))
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	defaultHeader    = "{title}"
+	defaultStatusBar = "Page {page}/{pages} · loc {location} · {chapter} {chapter_page}/{chapter_pages} · {percent}% · ~{minutes_left} min left"
+	defaultFooter    = "{keys}"
+	// legacyStatusBar is the default status bar from before locations.
+	legacyStatusBar = "Page {page}/{pages} · {chapter} {chapter_page}/{chapter_pages} · {percent}% · ~{minutes_left} min left"
+)
+
+// legacyFooters are the default footers of older versions, still found in
+// their config files.
+var legacyFooters = []string{
+	"Enter/Espacio: next  pgup: prev  +/-: size  c: chapters  b: library  ?: help  q: quit",
+	"Enter/Space: next  pgup: prev  +/-: size  c: chapters  b: library  ?: help  q: quit",
+}
+
+// footerActions are the reader keys {keys} names.
+var footerActions = []action{actionNextPage, actionPrevPage, actionBiggerText, actionSmallerText, actionChapters, actionLibrary, actionHelp, actionQuit}
+
+type clockMsg time.Time
+
+type batteryMsg string
+
+func chapterForPage(book Book, page int) int {
+	index := -1
+	for i, ch := range book.Chapters {
+		if ch.StartPage > page {
+			break
+		}
+		index = i
+	}
+	return index
+}
+
+func chapterPageRange(book Book, index int) (int, int) {
+	start := book.Chapters[index].StartPage
+	return start, start + book.Chapters[index].Pages
+}
+
+// pagePosition turns a page into a chapter and the fraction of it read, a
+// position that survives a different page size.
+func pagePosition(book Book, page int) (int, float64) {
+	index := chapterForPage(book, page)
+	if index < 0 {
+		return -1, 0
+	}
+	start, end := chapterPageRange(book, index)
+	if end <= start {
+		return index, 0
+	}
+	return index, float64(page-start) / float64(end-start)
+}
+
+// positionPage is the page at a position from pagePosition.
+func positionPage(book Book, chapter int, offset float64) int {
+	start, end := chapterPageRange(book, chapter)
+	return min(start+int(offset*float64(end-start)), max(end-1, start))
+}
+
+func (m model) statusValues() map[string]string {
+	book := m.currentBook
+	page := m.state.Page
+	values := map[string]string{
+		"title":         displayTitle(book.Title),
+		"page":          fmt.Sprintf("%d", page+1),
+		"pages":         fmt.Sprintf("%d", book.PageCount()),
+		"percent":       "0",
+		"chapter":       "",
+		"chapter_page":  "",
+		"chapter_pages": "",
+		"minutes_left":  fmt.Sprintf("%d", minutesLeft(book, page, m.config.WPM)),
+		"clock":         time.Now().Format("15:04"),
+		"battery":       m.battery,
+		"host":          m.remoteHost,
+		"language":      languageName(book.Language),
+		"schedule":      "",
+		"sleep":         m.sleepLeft(),
+		"print_page":    "",
+		"location":      "",
+		"locations":     "",
+		"keys":          m.keys.hint(modeReader, footerActions...),
+	}
+	if total := bookLocations(book); total > 0 && book.PageCount() > 0 {
+		values["location"] = fmt.Sprintf("%d", pageLocation(book, page))
+		values["locations"] = fmt.Sprintf("%d", total)
+	}
+	if printPages := m.state.PrintPages[m.state.CurrentBook]; printPages > 0 && book.PageCount() > 0 {
+		values["print_page"] = fmt.Sprintf("~%d", printPage(book, page, printPages))
+	}
+	if s, ok := m.state.Schedules[m.state.CurrentBook]; ok {
+		values["schedule"] = scheduleStatus(s, page, book.PageCount(), time.Now())
+	}
+	if total := book.PageCount(); total > 0 {
+		values["percent"] = fmt.Sprintf("%d", (page+1)*100/total)
+	}
+	if index := chapterForPage(book, page); index >= 0 {
+		start, end := chapterPageRange(book, index)
+		values["chapter"] = book.Chapters[index].Title
+		if lang := book.Chapters[index].Language; lang != "" {
+			values["language"] = languageName(lang)
+		}
+		values["chapter_page"] = fmt.Sprintf("%d", page-start+1)
+		values["chapter_pages"] = fmt.Sprintf("%d", end-start)
+	}
+	return values
+}
+
+func renderTemplate(tmpl string, values map[string]string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(tmpl, "{")
+		if start < 0 {
+			b.WriteString(tmpl)
+			break
+		}
+		end := strings.Index(tmpl[start:], "}")
+		if end < 0 {
+			b.WriteString(tmpl)
+			break
+		}
+		end += start
+		b.WriteString(tmpl[:start])
+		name := tmpl[start+1 : end]
+		if value, ok := values[name]; ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(tmpl[start : end+1])
+		}
+		tmpl = tmpl[end+1:]
+	}
+	return b.String()
+}
+
+func (m model) batteryCmd() tea.Cmd {
+	if !strings.Contains(m.config.Header+m.config.StatusBar+m.config.Footer, "{battery}") {
+		return nil
+	}
+	return func() tea.Msg {
+		if percent, ok := batteryPercent(); ok {
+			return batteryMsg(fmt.Sprintf("%d%%", percent))
+		}
+		return batteryMsg("")
+	}
+}
+
+func sshHost() string {
+	if os.Getenv("SSH_CONNECTION") == "" && os.Getenv("SSH_CLIENT") == "" && os.Getenv("SSH_TTY") == "" {
+		return ""
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+func clockTickCmd() tea.Cmd {
+	return tea.Tick(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)), func(t time.Time) tea.Msg {
+		return clockMsg(t)
+	})
+}
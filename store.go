@@ -0,0 +1,467 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	storeFile   = "file"
+	storeSQLite = "sqlite"
+	storeRemote = "remote"
+)
+
+var stateStores = []string{storeFile, storeSQLite, storeRemote}
+
+const (
+	defaultSQLite = "sqlite3"
+	// sqliteFileName is the database of the sqlite store, next to the state
+	// file.
+	sqliteFileName = "state.db"
+)
+
+// syncFileName records, next to the state file, what the sync server last
+// had of each file.
+const syncFileName = "sync.json"
+
+// stateStore is where the reading state is kept between runs: the state
+// file, a SQLite database or a sync server picked with state_store in the
+// config. The
+// annotations, such as the quotes and the vocabulary, are files kept by
+// name next to the state; one not saved yet loads as nil.
+type stateStore interface {
+	Load() (State, error)
+	Save(State) error
+	LoadFile(name string) ([]byte, error)
+	SaveFile(name string, data []byte) error
+}
+
+type fileStore struct {
+	path string
+}
+
+func (s fileStore) Load() (State, error) { return loadState(s.path) }
+
+func (s fileStore) Save(state State) error { return saveState(s.path, state) }
+
+func (s fileStore) LoadFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(s.path), name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s fileStore) SaveFile(name string, data []byte) error {
+	return writeFileAtomic(filepath.Join(filepath.Dir(s.path), name), data)
+}
+
+// writeFileAtomic writes a file through a temporary one renamed over it, so
+// a crash halfway leaves the old file whole.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// remoteStore keeps the state on a sync server as one JSON document, and
+// each annotations file as another next to it: GET fetches one, PUT
+// replaces it and 404 means nothing was saved yet. Local copies next to the
+// state file are read when the server can't be reached, so reading goes on
+// offline and syncs on the next save.
+//
+// The server's ETag of each file is remembered, with whether the local copy
+// has changes the server hasn't got, and uploads send it in If-Match. A file
+// changed on another device meanwhile is not overwritten unseen: the local
+// copy wins, and the server's is kept next to it as name.conflict.
+type remoteStore struct {
+	url   string
+	local fileStore
+
+	mu     sync.Mutex
+	synced map[string]syncRecord
+	// fetched are the files read from the server this run; after that the
+	// local copy, which every save goes through, is as new.
+	fetched map[string]bool
+	// failing is set after a failed upload, so a server that is down is
+	// reported once and not on every page turn.
+	failing bool
+}
+
+type syncRecord struct {
+	ETag  string `json:"etag,omitempty"`
+	Dirty bool   `json:"dirty,omitempty"`
+}
+
+func newStateStore(cfg Config) (stateStore, error) {
+	switch cfg.StateStore {
+	case "", storeFile:
+		return fileStore{path: cfg.StateFile}, nil
+	case storeRemote:
+		if cfg.StateURL == "" {
+			return nil, errors.New(tr("state_store = \"remote\" needs a state_url"))
+		}
+		if _, err := url.Parse(cfg.StateURL); err != nil {
+			return nil, fmt.Errorf(tr("state_url: %w"), err)
+		}
+		return &remoteStore{url: cfg.StateURL, local: fileStore{path: cfg.StateFile}}, nil
+	case storeSQLite:
+		tool := cfg.SQLite
+		if tool == "" {
+			tool = defaultSQLite
+		}
+		local := fileStore{path: cfg.StateFile}
+		return sqliteStore{path: filepath.Join(filepath.Dir(cfg.StateFile), sqliteFileName), tool: tool, local: local}, nil
+	}
+	return nil, fmt.Errorf(tr("unknown state_store %q: use file, sqlite or remote"), cfg.StateStore)
+}
+
+// loadStateFrom loads the state from the store the config picks.
+func loadStateFrom(cfg Config) (State, error) {
+	store, err := newStateStore(cfg)
+	if err != nil {
+		return State{}, err
+	}
+	return store.Load()
+}
+
+// stateName is the state's file among the files kept.
+func (s *remoteStore) stateName() string {
+	return filepath.Base(s.local.path)
+}
+
+// fileURL is where the server keeps a file: the state at state_url, and
+// the others by name next to it.
+func (s *remoteStore) fileURL(name string) string {
+	base, err := url.Parse(s.url)
+	if name == s.stateName() || err != nil {
+		return s.url
+	}
+	return base.ResolveReference(&url.URL{Path: name}).String()
+}
+
+func (s *remoteStore) Load() (State, error) { return loadStateFile(s, s.stateName()) }
+
+func (s *remoteStore) Save(state State) error { return saveStateFile(s, s.stateName(), state) }
+
+// loadStateFile reads the state from the store's file name; none saved yet
+// is an empty state.
+func loadStateFile(store stateStore, name string) (State, error) {
+	data, err := store.LoadFile(name)
+	if err != nil {
+		return State{}, err
+	}
+	state := State{Pages: make(map[string]int)}
+	if data == nil {
+		return state, nil
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, err
+	}
+	if state.Pages == nil {
+		state.Pages = make(map[string]int)
+	}
+	return state, nil
+}
+
+func saveStateFile(store stateStore, name string, state State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.SaveFile(name, data)
+}
+
+func (s *remoteStore) LoadFile(name string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	local, err := s.local.LoadFile(name)
+	if err != nil || s.fetched[name] {
+		return local, err
+	}
+	remote, etag, err := s.download(name)
+	if err != nil {
+		var status *statusError
+		if !errors.As(err, &status) || status.code != http.StatusNotFound {
+			debugLog.Warn("state sync failed, using the local copy", "file", name, "err", err)
+		}
+		return local, nil
+	}
+	if s.fetched == nil {
+		s.fetched = make(map[string]bool)
+	}
+	s.fetched[name] = true
+	record := s.record(name)
+	if record.Dirty {
+		// Changes saved here while the server was away are kept, and go up
+		// on the next save.
+		if !bytes.Equal(remote, local) && (etag == "" || etag != record.ETag) {
+			s.setAside(name, remote)
+		}
+		record.ETag = etag
+		s.setRecord(name, record)
+		return local, nil
+	}
+	if err := s.local.SaveFile(name, remote); err != nil {
+		return nil, err
+	}
+	s.setRecord(name, syncRecord{ETag: etag})
+	return remote, nil
+}
+
+func (s *remoteStore) SaveFile(name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.local.SaveFile(name, data); err != nil {
+		return err
+	}
+	record := s.record(name)
+	record.Dirty = true
+	s.setRecord(name, record)
+
+	err := s.upload(name, data, record.ETag)
+	var status *statusError
+	if errors.As(err, &status) && status.code == http.StatusPreconditionFailed {
+		var aside string
+		if aside, err = s.overwrite(name, data); err == nil {
+			s.failing = false
+			return fmt.Errorf(tr("sync server: %s was changed on another device, its copy is kept in %s"), name, aside)
+		}
+	}
+	if err == nil {
+		s.failing = false
+		return nil
+	}
+	if s.failing {
+		return nil
+	}
+	s.failing = true
+	return fmt.Errorf(tr("sync server: %w (saved locally)"), err)
+}
+
+func (s *remoteStore) download(name string) ([]byte, string, error) {
+	resp, err := getURL(context.Background(), featureSync, s.fileURL(name))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// upload puts a file on the server if the server's copy is still etag.
+func (s *remoteStore) upload(name string, data []byte, etag string) error {
+	kind := mime.TypeByExtension(filepath.Ext(name))
+	if kind == "" {
+		kind = "application/json"
+	}
+	header := http.Header{"Content-Type": {kind}}
+	if etag != "" {
+		header.Set("If-Match", etag)
+	}
+	resp, err := sendRequest(context.Background(), featureSync, http.MethodPut, s.fileURL(name), header, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	s.setRecord(name, syncRecord{ETag: resp.Header.Get("ETag")})
+	return nil
+}
+
+// overwrite uploads a file changed on another device since it was loaded
+// here, keeping the server's copy next to the local one.
+func (s *remoteStore) overwrite(name string, data []byte) (string, error) {
+	remote, etag, err := s.download(name)
+	if err != nil {
+		return "", err
+	}
+	aside := s.setAside(name, remote)
+	return aside, s.upload(name, data, etag)
+}
+
+func (s *remoteStore) setAside(name string, data []byte) string {
+	path := filepath.Join(filepath.Dir(s.local.path), name+".conflict")
+	if err := writeFileAtomic(path, data); err != nil {
+		debugLog.Error("sync conflict not kept", "file", name, "err", err)
+	}
+	debugLog.Warn("sync conflict: the server's copy was kept aside", "file", name, "path", path)
+	return path
+}
+
+func (s *remoteStore) record(name string) syncRecord {
+	if s.synced == nil {
+		s.synced = make(map[string]syncRecord)
+		if data, err := s.local.LoadFile(syncFileName); err == nil && data != nil {
+			json.Unmarshal(data, &s.synced)
+		}
+	}
+	return s.synced[name]
+}
+
+func (s *remoteStore) setRecord(name string, record syncRecord) {
+	s.record(name)
+	s.synced[name] = record
+	data, err := json.MarshalIndent(s.synced, "", "  ")
+	if err == nil {
+		err = s.local.SaveFile(syncFileName, data)
+	}
+	if err != nil {
+		debugLog.Error("sync record not saved", "err", err)
+	}
+}
+
+// sqliteStore keeps the state and the annotations files as rows of a SQLite
+// database, run through the sqlite3 shell, so each save is a transaction
+// that a crash can't leave half written. A file with no row yet is read
+// from the JSON files next to the state, so switching stores keeps what
+// was saved before.
+type sqliteStore struct {
+	path  string
+	tool  string
+	local fileStore
+}
+
+func (s sqliteStore) Load() (State, error) {
+	return loadStateFile(s, filepath.Base(s.local.path))
+}
+
+func (s sqliteStore) Save(state State) error {
+	return saveStateFile(s, filepath.Base(s.local.path), state)
+}
+
+func (s sqliteStore) LoadFile(name string) ([]byte, error) {
+	// The x keeps an empty file apart from one with no row.
+	out, err := s.run(fmt.Sprintf("SELECT 'x' || hex(data) FROM files WHERE name = %s;", sqlQuote(name)))
+	if err != nil {
+		return nil, err
+	}
+	row := strings.TrimSpace(string(out))
+	if row == "" {
+		return s.local.LoadFile(name)
+	}
+	return hex.DecodeString(strings.TrimPrefix(row, "x"))
+}
+
+func (s sqliteStore) SaveFile(name string, data []byte) error {
+	_, err := s.run(fmt.Sprintf("INSERT OR REPLACE INTO files (name, data) VALUES (%s, X'%x');", sqlQuote(name), data))
+	return err
+}
+
+// run runs a statement on the database, creating its table the first time.
+func (s sqliteStore) run(statement string) ([]byte, error) {
+	cmd := exec.Command(s.tool, "-bail", s.path)
+	cmd.Stdin = strings.NewReader(".timeout 5000\n" +
+		"CREATE TABLE IF NOT EXISTS files (name TEXT PRIMARY KEY, data BLOB NOT NULL);\n" +
+		statement + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = errors.New(msg)
+		}
+		debugLog.Error("sqlite store failed", "path", s.path, "err", err)
+		return nil, fmt.Errorf(tr("run %s (set sqlite3 in the config): %w"), s.tool, err)
+	}
+	return out, nil
+}
+
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// filesMu serializes the changes to the annotations files: each loads its
+// file, changes it and saves it back, and two at once would lose one.
+var filesMu sync.Mutex
+
+// loadJSONFile reads a JSON file kept by the store into v, which a file not
+// saved yet leaves as it is.
+func loadJSONFile(store stateStore, name string, v any) error {
+	data, err := store.LoadFile(name)
+	if err != nil || data == nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// updateJSONFile loads a JSON file kept by the store into v, lets change
+// change it and saves it back, one change at a time.
+func updateJSONFile(store stateStore, name string, v any, change func() error) error {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	if err := loadJSONFile(store, name, v); err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.SaveFile(name, data)
+}
+
+// stateSaves orders the saves of the state. Each is numbered when it is
+// asked for, in Update; the first to run saves the newest state asked for
+// and the older ones coming after it are dropped, so saves run one at a
+// time and an older state never lands last.
+var stateSaves saveQueue
+
+type saveQueue struct {
+	mu      sync.Mutex
+	asked   int
+	saved   int
+	store   stateStore
+	pending State
+	saving  sync.Mutex
+}
+
+func (q *saveQueue) ask(store stateStore, state State) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.asked++
+	q.store, q.pending = store, state
+	return q.asked
+}
+
+// flush saves the newest state asked for, unless save number seq, or a
+// later one, is saved already.
+func (q *saveQueue) flush(seq int) error {
+	q.saving.Lock()
+	defer q.saving.Unlock()
+	q.mu.Lock()
+	if seq <= q.saved {
+		q.mu.Unlock()
+		return nil
+	}
+	store, state := q.store, q.pending
+	q.saved = q.asked
+	q.mu.Unlock()
+	return store.Save(state)
+}
+
+// saveStateNow saves the state before returning, in order with the saves
+// running in the background.
+func saveStateNow(store stateStore, state State) error {
+	return stateSaves.flush(stateSaves.ask(store, state))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func newSQLiteTestStore(t *testing.T) (stateStore, string) {
+	t.Helper()
+	if _, err := exec.LookPath(defaultSQLite); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	dir := t.TempDir()
+	store, err := newStateStore(Config{StateStore: storeSQLite, StateFile: filepath.Join(dir, "state.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, dir
+}
+
+func TestSQLiteStoreSavesState(t *testing.T) {
+	store, dir := newSQLiteTestStore(t)
+	state, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Pages == nil || state.CurrentBook != "" {
+		t.Fatalf("empty store loaded %+v", state)
+	}
+	state.CurrentBook = "l'homme.html"
+	state.Pages["l'homme.html"] = 12
+	if err := store.Save(state); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CurrentBook != state.CurrentBook || got.Pages["l'homme.html"] != 12 {
+		t.Errorf("Load() = %+v, want %+v", got, state)
+	}
+	if _, err := os.Stat(filepath.Join(dir, sqliteFileName)); err != nil {
+		t.Errorf("no database: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "state.json")); !os.IsNotExist(err) {
+		t.Errorf("state.json written next to the database: %v", err)
+	}
+}
+
+func TestSQLiteStoreFiles(t *testing.T) {
+	store, dir := newSQLiteTestStore(t)
+	if err := os.WriteFile(filepath.Join(dir, "quotes.json"), []byte(`["old"]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		save []byte
+		want string
+	}{
+		{nil, `["old"]`},
+		{[]byte(`["new", "it's"]`), `["new", "it's"]`},
+		{[]byte{}, ""},
+	} {
+		if tc.save != nil {
+			if err := store.SaveFile("quotes.json", tc.save); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := store.LoadFile("quotes.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("LoadFile = %q, want %q", got, tc.want)
+		}
+	}
+	if got, err := store.LoadFile("vocabulary.json"); got != nil || err != nil {
+		t.Errorf("LoadFile of a file never saved = %q, %v, want nil", got, err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	styleItalicOn  = "\x1b[3m"
+	styleItalicOff = "\x1b[23m"
+	styleBoldOn    = "\x1b[1m"
+	styleBoldOff   = "\x1b[22m"
+)
+
+var styleCodeRe = regexp.MustCompile("\x1b\\[(3|23|1|22)m")
+
+// balanceStyles makes every line open and close its own styles, so a line can
+// be rendered on its own without leaking or losing italics and bold.
+func balanceStyles(text string) string {
+	lines := strings.Split(text, "\n")
+	italic, bold := false, false
+	for i, line := range lines {
+		var prefix string
+		if italic {
+			prefix += styleItalicOn
+		}
+		if bold {
+			prefix += styleBoldOn
+		}
+		for _, code := range styleCodeRe.FindAllString(line, -1) {
+			switch code {
+			case styleItalicOn:
+				italic = true
+			case styleItalicOff:
+				italic = false
+			case styleBoldOn:
+				bold = true
+			case styleBoldOff:
+				bold = false
+			}
+		}
+		var suffix string
+		if italic {
+			suffix += styleItalicOff
+		}
+		if bold {
+			suffix += styleBoldOff
+		}
+		lines[i] = prefix + line + suffix
+	}
+	return strings.Join(lines, "\n")
+}
+
+func stripStyles(text string) string {
+	return styleCodeRe.ReplaceAllString(text, "")
+}
+
+func visibleWidth(text string) int {
+	return runewidth.StringWidth(stripStyles(text))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	xhtml "golang.org/x/net/html"
+)
+
+const maxTableCellWidth = 30
+
+type tableRow struct {
+	cells  []string
+	header bool
+}
+
+func tableRows(table *xhtml.Node) []tableRow {
+	var rows []tableRow
+	var walk func(*xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if n.Type != xhtml.ElementNode {
+			return
+		}
+		switch n.Data {
+		case "table":
+			if n != table {
+				return
+			}
+		case "tr":
+			row := tableRow{header: true}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == xhtml.ElementNode && (c.Data == "td" || c.Data == "th") {
+					row.header = row.header && c.Data == "th"
+					row.cells = append(row.cells, compactSpaces(textContent(c)))
+				}
+			}
+			if len(row.cells) > 0 {
+				rows = append(rows, row)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(table)
+	return rows
+}
+
+func drawTable(rows []tableRow) string {
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row.cells))
+	}
+	widths := make([]int, cols)
+	for _, row := range rows {
+		for i, c := range row.cells {
+			widths[i] = max(widths[i], min(runewidth.StringWidth(c), maxTableCellWidth))
+		}
+	}
+
+	border := func(left, mid, right string) string {
+		parts := make([]string, cols)
+		for i, w := range widths {
+			parts[i] = strings.Repeat("─", w+2)
+		}
+		return left + strings.Join(parts, mid) + right
+	}
+
+	lines := []string{border("┌", "┬", "┐")}
+	for r, row := range rows {
+		wrapped := make([][]string, cols)
+		height := 1
+		for i := range wrapped {
+			if i < len(row.cells) && row.cells[i] != "" {
+				wrapped[i] = strings.Split(wrapParagraph(row.cells[i], widths[i]), "\n")
+			}
+			height = max(height, len(wrapped[i]))
+		}
+		for l := 0; l < height; l++ {
+			parts := make([]string, cols)
+			for i, w := range widths {
+				text := ""
+				if l < len(wrapped[i]) {
+					text = truncateVisible(wrapped[i][l], w)
+				}
+				parts[i] = " " + text + strings.Repeat(" ", w-runewidth.StringWidth(text)) + " "
+			}
+			lines = append(lines, "│"+strings.Join(parts, "│")+"│")
+		}
+		if row.header && r+1 < len(rows) && !rows[r+1].header {
+			lines = append(lines, border("├", "┼", "┤"))
+		}
+	}
+	lines = append(lines, border("└", "┴", "┘"))
+	return strings.Join(lines, "\n")
+}
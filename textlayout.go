@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// Kinsoku rules: characters that may not start a line and characters that
+// may not end one when breaking CJK text between any two characters.
+const (
+	noLineStart = "、。，．・：；？！ー）」』】〕〉》〙〗〟’”｝｠ゝゞぁぃぅぇぉっゃゅょゎァィゥェォッャュョヮヵヶ…‥,.:;!?)]}"
+	noLineEnd   = "（「『【〔〈《〘〖〝‘“｛｟([{"
+)
+
+type wrapToken struct {
+	text  string
+	width int
+	space bool
+}
+
+// wrapTokens splits text into the pieces wrapParagraph may break between:
+// whole words for spaced scripts, single characters for CJK runs.
+func wrapTokens(text string) []wrapToken {
+	var tokens []wrapToken
+	for _, word := range strings.Fields(text) {
+		if !hasWideRunes(word) {
+			tokens = append(tokens, wrapToken{text: word, width: visibleWidth(word), space: true})
+			continue
+		}
+		start := len(tokens)
+		var pending, narrow strings.Builder
+		flushNarrow := func() {
+			if narrow.Len() > 0 {
+				tokens = append(tokens, wrapToken{text: narrow.String(), width: visibleWidth(narrow.String())})
+				narrow.Reset()
+			}
+		}
+		for i := 0; i < len(word); {
+			if code := styleCodeRe.FindString(word[i:]); code != "" && strings.HasPrefix(word[i:], code) {
+				pending.WriteString(code)
+				i += len(code)
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(word[i:])
+			i += size
+			if runewidth.RuneWidth(r) < 2 && !strings.ContainsRune(noLineStart+noLineEnd, r) {
+				narrow.WriteString(pending.String())
+				narrow.WriteRune(r)
+				pending.Reset()
+				continue
+			}
+			flushNarrow()
+			tokens = append(tokens, wrapToken{text: pending.String() + string(r), width: runewidth.RuneWidth(r)})
+			pending.Reset()
+		}
+		flushNarrow()
+		if pending.Len() > 0 && len(tokens) > start {
+			tokens[len(tokens)-1].text += pending.String()
+		}
+		if len(tokens) > start {
+			tokens[start].space = true
+		}
+	}
+	return tokens
+}
+
+func hasWideRunes(word string) bool {
+	for _, r := range word {
+		if runewidth.RuneWidth(r) == 2 {
+			return true
+		}
+	}
+	return false
+}
+
+func tokensWidth(tokens []wrapToken) int {
+	width := 0
+	for i, t := range tokens {
+		if i > 0 && t.space {
+			width++
+		}
+		width += t.width
+	}
+	return width
+}
+
+func joinTokens(tokens []wrapToken) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		if i > 0 && t.space {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+func kinsokuRune(token string, set string, last bool) bool {
+	plain := stripStyles(token)
+	if plain == "" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(plain)
+	if last {
+		r, _ = utf8.DecodeLastRuneInString(plain)
+	}
+	return strings.ContainsRune(set, r)
+}
+
+// visualLine reorders a logical line for display, so Hebrew and Arabic read
+// right to left even in terminals without bidi support. Lines without
+// right-to-left characters are returned untouched.
+func visualLine(line string, rtl bool) string {
+	plain := stripStyles(line)
+	if !hasRTLRunes(plain) {
+		return line
+	}
+	var p bidi.Paragraph
+	opts := []bidi.Option{}
+	if rtl {
+		opts = append(opts, bidi.DefaultDirection(bidi.RightToLeft))
+	}
+	if _, err := p.SetString(plain, opts...); err != nil {
+		return line
+	}
+	order, err := p.Order()
+	if err != nil {
+		return line
+	}
+	runs := make([]string, order.NumRuns())
+	for i := range runs {
+		run := order.Run(i)
+		runs[i] = run.String()
+		if run.Direction() == bidi.RightToLeft {
+			runs[i] = reverseKeepingEdges(runs[i], !rtl)
+		}
+	}
+	if rtl {
+		for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+			runs[i], runs[j] = runs[j], runs[i]
+		}
+	}
+	return strings.Join(runs, "")
+}
+
+// reverseKeepingEdges reverses a right-to-left run; in a left-to-right line
+// the spaces around it stay where they are, as they separate it from the
+// neighbouring words rather than belong to it.
+func reverseKeepingEdges(run string, keep bool) string {
+	if !keep {
+		return bidi.ReverseString(run)
+	}
+	core := strings.TrimSpace(run)
+	start := strings.Index(run, core)
+	return run[:start] + bidi.ReverseString(core) + run[start+len(core):]
+}
+
+func hasRTLRunes(text string) bool {
+	for _, r := range text {
+		if p, _ := bidi.LookupRune(r); p.Class() == bidi.R || p.Class() == bidi.AL {
+			return true
+		}
+	}
+	return false
+}
+
+func visualPage(page string, rtl bool) string {
+	lines := strings.Split(page, "\n")
+	for i, line := range lines {
+		lines[i] = visualLine(line, rtl)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+const (
+	themeAuto        = "auto"
+	defaultThemeName = themeAuto
+)
+
+type theme struct {
+	Name  string
+	Title lipgloss.CompleteColor
+	Meta  lipgloss.CompleteColor
+	Help  lipgloss.CompleteColor
+}
+
+var themes = []theme{
+	{
+		Name:  "dark",
+		Title: lipgloss.CompleteColor{TrueColor: "#7B7BFF", ANSI256: "105", ANSI: "12"},
+		Meta:  lipgloss.CompleteColor{TrueColor: "#8A8F98", ANSI256: "245", ANSI: "7"},
+		Help:  lipgloss.CompleteColor{TrueColor: "#6B7079", ANSI256: "242", ANSI: "8"},
+	},
+	{
+		Name:  "light",
+		Title: lipgloss.CompleteColor{TrueColor: "#5A2CA0", ANSI256: "55", ANSI: "5"},
+		Meta:  lipgloss.CompleteColor{TrueColor: "#5C6068", ANSI256: "240", ANSI: "8"},
+		Help:  lipgloss.CompleteColor{TrueColor: "#3F4349", ANSI256: "238", ANSI: "0"},
+	},
+}
+
+var colorModes = []string{"auto", "truecolor", "256", "16"}
+
+var activeTheme = themes[0]
+
+var (
+	detectOnce    sync.Once
+	detectedTheme string
+)
+
+func themeNames() []string {
+	names := make([]string, 0, len(themes)+1)
+	names = append(names, themeAuto)
+	for _, t := range themes {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func setTheme(name string) bool {
+	if name == themeAuto {
+		name = backgroundTheme()
+	}
+	for _, t := range themes {
+		if t.Name == name {
+			activeTheme = t
+			return true
+		}
+	}
+	return false
+}
+
+func setColorMode(name string) bool {
+	switch name {
+	case "auto":
+		lipgloss.SetColorProfile(termenv.NewOutput(os.Stdout).EnvColorProfile())
+	case "truecolor":
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	case "256":
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	case "16":
+		lipgloss.SetColorProfile(termenv.ANSI)
+	default:
+		return false
+	}
+	return true
+}
+
+func backgroundTheme() string {
+	detectOnce.Do(func() {
+		dark, ok := colorFgBgDark(os.Getenv("COLORFGBG"))
+		if !ok {
+			dark = lipgloss.HasDarkBackground()
+		}
+		detectedTheme = "light"
+		if dark {
+			detectedTheme = "dark"
+		}
+	})
+	return detectedTheme
+}
+
+func colorFgBgDark(value string) (bool, bool) {
+	fields := strings.Split(value, ";")
+	bg, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || bg < 0 || bg > 15 {
+		return false, false
+	}
+	return bg != 7 && bg < 9, true
+}
+
+func titleStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Bold(true).Foreground(activeTheme.Title)
+}
+
+func metaStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(activeTheme.Meta)
+}
+
+func helpStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(activeTheme.Help)
+}
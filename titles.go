@@ -0,0 +1,101 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Titles are shown through displayTitle and sorted by sortTitle; the title
+// in the library, the search results and the -json output stays as the
+// source wrote it.
+
+var (
+	// editionNoiseRe matches what transcribers append to a title about the
+	// edition rather than the work: "(Illustrated Edition)", "[EBook]",
+	// ", Complete".
+	editionNoiseRe = regexp.MustCompile(`(?i)\s*(?:[(\[][^)\]]*\b(?:edition|illustrated|unabridged|e-?book|e-?text)\b[^)\]]*[)\]]|[,:;—–-]+\s*(?:complete|illustrated|unabridged)(?:\s+edition)?)\s*$`)
+	// romanNumeralRe matches the volume and part numbers of titles, I to
+	// XXXIX; longer numerals are too easily words like MIX.
+	romanNumeralRe = regexp.MustCompile(`^X{0,3}(?:IX|IV|V?I{0,3})[.,:;]?$`)
+)
+
+// leadingArticles are moved to the end of a title for sorting, so "The
+// Time Machine" sorts under T and "La Regenta" under R.
+var leadingArticles = []string{
+	"the ", "a ", "an ",
+	"el ", "la ", "los ", "las ", "un ", "una ",
+	"le ", "les ", "l'", "l’",
+	"der ", "die ", "das ",
+	"il ", "lo ", "gli ",
+}
+
+// smallTitleWords stay lowercase inside a title recased from capitals.
+var smallTitleWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "in": true,
+	"on": true, "at": true, "to": true, "for": true, "by": true, "with": true, "from": true,
+	"de": true, "del": true, "la": true, "las": true, "el": true, "los": true, "y": true,
+	"en": true, "con": true, "por": true,
+}
+
+// displayTitle is a title as lists and the reader show it: without edition
+// noise and, when transcribed in capitals, in title case.
+func displayTitle(title string) string {
+	title = strings.TrimSpace(title)
+	for {
+		trimmed := editionNoiseRe.ReplaceAllString(title, "")
+		if trimmed == title || trimmed == "" {
+			break
+		}
+		title = trimmed
+	}
+	if isAllCaps(title) {
+		title = titleCase(title)
+	}
+	return title
+}
+
+// sortTitle is the key titles sort by: the display title with a leading
+// article moved to its end.
+func sortTitle(title string) string {
+	title = displayTitle(title)
+	lower := strings.ToLower(title)
+	for _, article := range leadingArticles {
+		if strings.HasPrefix(lower, article) && len(title) > len(article) {
+			return title[len(article):] + ", " + strings.TrimSpace(title[:len(article)])
+		}
+	}
+	return title
+}
+
+func isAllCaps(s string) bool {
+	letters := 0
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters > 3
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		lower := strings.ToLower(word)
+		switch {
+		case romanNumeralRe.MatchString(word) && strings.Trim(word, ".,:;") != "":
+		case i > 0 && smallTitleWords[lower] && !strings.HasSuffix(words[i-1], ":"):
+			words[i] = lower
+		default:
+			parts := strings.Split(lower, "-")
+			for j := range parts {
+				parts[j] = capitalize(parts[j])
+			}
+			words[i] = strings.Join(parts, "-")
+		}
+	}
+	return strings.Join(words, " ")
+}
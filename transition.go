@@ -0,0 +1,200 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	transitionNone  = "none"
+	transitionSlide = "slide"
+	transitionFade  = "fade"
+
+	transitionFrames     = 6
+	transitionFrameDelay = 20 * time.Millisecond
+
+	chapterFlashDuration = 2 * time.Second
+)
+
+var pageTransitions = []string{transitionNone, transitionSlide, transitionFade}
+
+type pageTransition struct {
+	id      int
+	active  bool
+	from    string
+	forward bool
+	frame   int
+}
+
+type transitionMsg struct{ id int }
+
+func validTransition(name string) bool {
+	for _, t := range pageTransitions {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+func transitionTickCmd(id int) tea.Cmd {
+	return tea.Tick(transitionFrameDelay, func(time.Time) tea.Msg { return transitionMsg{id: id} })
+}
+
+func (m *model) turnPage(page int) tea.Cmd {
+	prev := m.state.Page
+	if page < 0 || page >= m.currentBook.PageCount() || page == prev {
+		return nil
+	}
+	m.state.Page = page
+	m.status = ""
+	// A pane following another only shows the leader's page turns, which
+	// the leader saves and counts towards its goals.
+	var cue, saveState tea.Cmd
+	if m.following == 0 {
+		m.state.Pages[m.state.CurrentBook] = page
+		m.markPageRead()
+		m.trackSchedule(false)
+		if page == prev+1 {
+			cue = m.logReading(time.Now())
+		}
+		saveState = saveStateCmd(m.store, m.state)
+	}
+	m.glossIndex, m.glossWord = 0, 0
+	m.sentence = 0
+	save := tea.Batch(saveState, m.prefetchNextChapter(), cue, m.speakSentence())
+	if m.config.PageTransition == transitionNone || prev < 0 || prev >= m.currentBook.PageCount() {
+		return save
+	}
+	m.transition = pageTransition{
+		id:      m.transition.id + 1,
+		active:  true,
+		from:    m.currentBook.Page(prev),
+		forward: page > prev,
+	}
+	return tea.Batch(save, transitionTickCmd(m.transition.id))
+}
+
+// statusClearMsg clears a brief reader status, unless another replaced it.
+type statusClearMsg struct{ status string }
+
+// jumpChapter moves to the start of the chapter delta chapters away and
+// names it in the status line for a moment.
+func (m *model) jumpChapter(delta int) tea.Cmd {
+	index := chapterForPage(m.currentBook, m.state.Page)
+	if index < 0 {
+		return nil
+	}
+	target := min(max(index+delta, 0), len(m.currentBook.Chapters)-1)
+	cmd := m.turnPage(m.currentBook.Chapters[target].StartPage)
+	if cmd == nil {
+		return nil
+	}
+	title := m.currentBook.Chapters[target].Title
+	if title == "" {
+		title = trf("Chapter %d", target+1)
+	}
+	m.status = trf("%d/%d · %s", target+1, len(m.currentBook.Chapters), title)
+	status := m.status
+	return tea.Batch(cmd, tea.Tick(chapterFlashDuration, func(time.Time) tea.Msg { return statusClearMsg{status: status} }))
+}
+
+func (m model) updateTransition(msg transitionMsg) (tea.Model, tea.Cmd) {
+	if !m.transition.active || msg.id != m.transition.id {
+		return m, nil
+	}
+	m.transition.frame++
+	if m.transition.frame >= transitionFrames {
+		m.transition.active = false
+		return m, nil
+	}
+	return m, transitionTickCmd(msg.id)
+}
+
+func (m model) transitionPage(page string, width int) string {
+	t := m.transition
+	if !t.active || m.mode != modeReader {
+		return page
+	}
+	switch m.config.PageTransition {
+	case transitionSlide:
+		return slideFrame(t.from, page, width, t.frame, t.forward)
+	case transitionFade:
+		faint := lipgloss.NewStyle().Faint(true)
+		if t.frame < transitionFrames/2 {
+			return faint.Render(t.from)
+		}
+		return faint.Render(page)
+	}
+	return page
+}
+
+func slideFrame(from, to string, width, frame int, forward bool) string {
+	fromLines := strings.Split(from, "\n")
+	toLines := strings.Split(to, "\n")
+	rows := max(len(fromLines), len(toLines))
+	offset := (frame + 1) * width / (transitionFrames + 1)
+
+	lines := make([]string, rows)
+	for i := range lines {
+		old := padCells(lineAt(fromLines, i), width)
+		next := padCells(lineAt(toLines, i), width)
+		if forward {
+			lines[i] = joinCells(old[offset:]) + joinCells(next[:offset])
+		} else {
+			lines[i] = joinCells(next[width-offset:]) + joinCells(old[:width-offset])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func lineAt(lines []string, i int) string {
+	if i < len(lines) {
+		return stripStyles(lines[i])
+	}
+	return ""
+}
+
+// padCells splits line into terminal columns; a wide character fills its
+// first column and leaves an empty string in the second.
+func padCells(line string, width int) []string {
+	cells := make([]string, 0, width)
+	for _, r := range line {
+		w := runewidth.RuneWidth(r)
+		if w == 0 {
+			continue
+		}
+		if len(cells)+w > width {
+			break
+		}
+		cells = append(cells, string(r))
+		if w == 2 {
+			cells = append(cells, "")
+		}
+	}
+	for len(cells) < width {
+		cells = append(cells, " ")
+	}
+	return cells
+}
+
+// joinCells renders a slice of columns, blanking wide characters cut in half
+// by the slice edges.
+func joinCells(cells []string) string {
+	var b strings.Builder
+	for i, c := range cells {
+		switch {
+		case c == "" && i == 0:
+			b.WriteByte(' ')
+		case c != "" && runewidth.StringWidth(c) == 2 && i == len(cells)-1:
+			b.WriteByte(' ')
+		default:
+			b.WriteString(c)
+		}
+	}
+	return b.String()
+}
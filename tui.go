@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -21,128 +26,298 @@ const (
 	modeBooks
 	modeReader
 	modeChapters
+	modeFeeds
+	modePrivacy
+	modeSettings
+	modeKeyTester
+	modeAuthorIndex
+	modeDiscover
+	modeFinished
+	modeQuotes
+	modeVocabulary
+	modeBookshelves
+	modeAdvancedSearch
 )
 
 type authorItem struct {
-	name string
+	name  string
+	works []string
 }
 
 func (a authorItem) Title() string       { return a.name }
-func (a authorItem) Description() string { return "" }
+func (a authorItem) Description() string { return strings.Join(a.works, " | ") }
 func (a authorItem) FilterValue() string { return a.name }
 
 type bookItem struct {
-	title    string
-	url      string
-	subtitle string
-	extra    string
+	result      bookResult
+	source      BookSource
+	downloading bool
+	downloaded  bool
+	done        int64
+	total       int64
+	spin        string
 }
 
-func (b bookItem) Title() string { return b.title }
+func (b bookItem) Title() string {
+	if label := downloadLabel(b); label != "" {
+		return label + " " + displayTitle(b.result.Title)
+	}
+	return displayTitle(b.result.Title)
+}
 func (b bookItem) Description() string {
 	parts := []string{}
-	if b.subtitle != "" {
-		parts = append(parts, b.subtitle)
+	if b.result.Subtitle != "" {
+		parts = append(parts, b.result.Subtitle)
 	}
-	if b.extra != "" {
-		parts = append(parts, b.extra)
+	if b.result.Extra != "" {
+		parts = append(parts, b.result.Extra)
 	}
-	if b.url != "" {
-		parts = append(parts, b.url)
+	if b.result.URL != "" {
+		parts = append(parts, b.result.URL)
 	}
 	return strings.Join(parts, " | ")
 }
-func (b bookItem) FilterValue() string { return b.title }
+func (b bookItem) FilterValue() string { return b.result.Title }
 
 type libraryItem struct {
-	title string
-	path  string
+	title    string
+	path     string
+	key      string
+	story    string
+	schedule string
+	id       string
+	edition  string
+	preset   string
+	finished string
+	// series and volume group the volumes of a series in the library;
+	// volumeTitle is the volume's own title, when it has one.
+	series      string
+	volume      int
+	volumeTitle string
 }
 
-func (l libraryItem) Title() string       { return l.title }
-func (l libraryItem) Description() string { return l.path }
-func (l libraryItem) FilterValue() string { return l.title }
+func (l libraryItem) Title() string {
+	if l.series == "" {
+		return displayTitle(l.title)
+	}
+	title := l.series + " · " + trf("vol. %d", l.volume)
+	if l.volumeTitle != "" {
+		title += " · " + l.volumeTitle
+	}
+	return title
+}
+func (l libraryItem) Description() string {
+	desc := l.path
+	if l.story != "" {
+		desc = l.story
+	}
+	if l.edition == editionText {
+		desc += tr(" (plain text edition)")
+	}
+	if l.preset != "" {
+		desc += trf(" · filters: %s", l.preset)
+	}
+	if l.schedule != "" {
+		desc += " · " + l.schedule
+	}
+	if l.finished != "" {
+		desc += " · " + l.finished
+	}
+	return desc
+}
+func (l libraryItem) FilterValue() string { return l.series + " " + l.title }
+
+type feedItem struct {
+	source opdsSource
+}
+
+func (f feedItem) Title() string       { return f.source.Name() }
+func (f feedItem) Description() string { return f.source.feed.URL }
+func (f feedItem) FilterValue() string { return f.source.Name() }
 
 type chapterItem struct {
-	title string
-	index int
+	title    string
+	index    int
+	language string
 }
 
 func (c chapterItem) Title() string       { return c.title }
-func (c chapterItem) Description() string { return "" }
+func (c chapterItem) Description() string { return languageName(c.language) }
 func (c chapterItem) FilterValue() string { return c.title }
 
 type errMsg struct{ err error }
 
 type booksMsg struct {
-	items []list.Item
-	err   error
+	items   []list.Item
+	title   string
+	popular bool
+	source  BookSource
+	query   string
+	page    int
+	err     error
 }
 
 type bookLoadedMsg struct {
 	book Book
 	path string
+	url  string
 	err  error
 }
 
 type model struct {
-	mode         mode
-	authorInput  textinput.Model
-	authorList   list.Model
-	authors      []string
-	authorsLower []string
-	libraryList  list.Model
-	bookList     list.Model
-	chapterList  list.Model
-	currentBook  Book
-	state        State
-	config       Config
-	status       string
-	err          error
-	width        int
-	height       int
-	pageWidth    int
-	pageLines    int
-	fontScale    int
-}
-
-func newModel(cfg Config, state State, authors []string) (model, error) {
-	authorsLower := make([]string, len(authors))
-	for i, name := range authors {
-		authorsLower[i] = strings.ToLower(name)
-	}
+	mode             mode
+	authorInput      textinput.Model
+	authorList       list.Model
+	authors          *authorCatalog
+	worksQuery       string
+	discovered       gutendexBook
+	discoverFeatured bool
+	popularPeriod    int
+	battery          string
+	remoteHost       string
+	showingPopular   bool
+	libraryList      list.Model
+	bookList         list.Model
+	chapterList      list.Model
+	feedList         list.Model
+	sources          []BookSource
+	sourceIndex      int
+	privacyCursor    int
+	settingsCursor   int
+	settingsEditing  bool
+	settingsInput    textinput.Model
+	testerMode       mode
+	testerKey        string
+	indexLetters     []letterCount
+	indexCursor      int
+	indexLetter      string
+	indexList        list.Model
+	keys             keymap
+	spinner          spinner.Model
+	transition       pageTransition
+	covers           map[string]string
+	currentBook      Book
+	translation      Book
+	translationPath  string
+	parallel         bool
+	unlinked         bool
+	rightPane        bool
+	rightPage        int
+	dict             *dictionary
+	glossing         bool
+	glossIndex       int
+	glossWord        int
+	state            State
+	config           Config
+	store            stateStore
+	delegates        map[mode]list.ItemDelegate
+	status           string
+	err              error
+	width            int
+	height           int
+	pageWidth        int
+	pageLines        int
+	fontScale        int
+	tour             *tutorialTour
+	prefetch         *prefetcher
+	search           searchPaging
+	awaiting         string
+	help             viewport.Model
+	helpOpen         bool
+	palette          commandPalette
+	restore          *crashRestore
+	finish           finishScreen
+	quoteList        list.Model
+	shelfList        list.Model
+	advanced         advancedSearch
+	quotesBack       mode
+	quoteAt          *Quote
+	restoreAt        *crashRestore
+	autoTurning      bool
+	autoTurnID       int
+	sleepAt          time.Time
+	sleepID          int
+	lastTurn         time.Time
+	cueID            int
+	loading          loading
+	rateLimit        rateLimitMsg
+	bookQuotes       []Quote
+	vocabulary       vocabularyReview
+	scrubbing        bool
+	scrubPage        int
+	peers            []presence
+	published        presence
+	following        int
+	peerNotice       string
+	presenting       bool
+	sentence         int
+	speaking         bool
+	speech           []string
+	speechID         int
+	remoteID         int
+	librarySignature uint64
+	duplicate        *duplicatePrompt
+	formats          *formatPicker
+	resizeID         int
+}
 
+func newModel(cfg Config, state State, authors *authorCatalog) (model, error) {
 	authorInput := textinput.New()
-	authorInput.Placeholder = "Author prefix (e.g. ab)"
+	authorInput.Placeholder = tr("Author name (e.g. lorca)")
 	authorInput.Focus()
-	authorInput.CharLimit = 80
-	authorInput.Width = 40
+	authorInput.CharLimit = 120
+	authorInput.Width = 60
 
-	authorList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
-	authorList.Title = "Authors"
+	delegate := list.NewDefaultDelegate()
+	authorList := list.New([]list.Item{}, delegate, 0, 0)
+	authorList.Title = tr("Authors")
 	authorList.SetFilteringEnabled(false)
 
-	libraryItems, err := loadLibraryItems(cfg.BooksDir)
+	libraryItems, err := loadLibraryItems(cfg.BooksDir, state, cfg.WPM)
+	if err != nil {
+		return model{}, err
+	}
+	store, err := newStateStore(cfg)
 	if err != nil {
 		return model{}, err
 	}
-	libraryList := list.New(libraryItems, list.NewDefaultDelegate(), 0, 0)
-	libraryList.Title = "Library"
+	covers := make(map[string]string)
+	withCovers := newCoverDelegate(covers)
+	libraryList := list.New(libraryItems, withCovers, 0, 0)
+	libraryList.Title = tr("Library")
 	libraryList.SetFilteringEnabled(true)
+	libraryList.StatusMessageLifetime = statusMessageLifetime
 
-	bookList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
-	bookList.Title = "Books"
+	bookList := list.New([]list.Item{}, withCovers, 0, 0)
+	bookList.Title = tr("Books")
 	bookList.SetFilteringEnabled(true)
 
-	chapterList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
-	chapterList.Title = "Chapters"
+	chapterList := list.New([]list.Item{}, delegate, 0, 0)
+	chapterList.Title = tr("Chapters")
 	chapterList.SetFilteringEnabled(true)
 
+	quoteList := list.New([]list.Item{}, delegate, 0, 0)
+	quoteList.Title = tr("Quotes")
+	quoteList.SetFilteringEnabled(true)
+	quoteList.StatusMessageLifetime = statusMessageLifetime
+
+	shelfList := list.New([]list.Item{}, delegate, 0, 0)
+	shelfList.Title = tr("Gutenberg bookshelves")
+	shelfList.SetFilteringEnabled(true)
+
+	feedList := list.New(buildFeedItems(cfg.OPDSFeeds), delegate, 0, 0)
+	feedList.Title = tr("OPDS Feeds")
+	feedList.SetFilteringEnabled(true)
+
+	indexList := list.New([]list.Item{}, delegate, 0, 0)
+	indexList.SetFilteringEnabled(true)
+
 	initialMode := modeAuthorSearch
 	var currentBook Book
 	if state.CurrentBook != "" {
-		if _, err := os.Stat(state.CurrentBook); err == nil {
-			book, err := loadBookFromHTML(state.CurrentBook, pageLineWidth, pageLineCount)
+		path, _ := splitStoryKey(state.CurrentBook)
+		if _, err := os.Stat(path); err == nil {
+			book, err := loadBook(state.CurrentBook, pageLineWidth, pageLineCount)
 			if err == nil {
 				currentBook = book
 				state.Page = state.Pages[state.CurrentBook]
@@ -150,38 +325,78 @@ func newModel(cfg Config, state State, authors []string) (model, error) {
 			}
 		}
 	}
-	if initialMode != modeReader && len(libraryItems) > 0 {
+	if initialMode != modeReader && (len(libraryItems) > 0 || offline()) {
 		initialMode = modeLibrary
 	}
 	if len(currentBook.Chapters) > 0 {
-		chapterList.SetItems(buildChapterItems(currentBook))
+		chapterList.SetItems(buildChapterItems(currentBook, state.ReadChapters[state.CurrentBook]))
 	}
 
 	m := model{
-		mode:         initialMode,
-		authorInput:  authorInput,
-		authorList:   authorList,
-		authors:      authors,
-		authorsLower: authorsLower,
-		libraryList:  libraryList,
-		bookList:     bookList,
-		chapterList:  chapterList,
-		currentBook:  currentBook,
-		state:        state,
-		config:       cfg,
-		pageWidth:    pageLineWidth,
-		pageLines:    pageLineCount,
-		fontScale:    0,
+		mode:          initialMode,
+		authorInput:   authorInput,
+		authorList:    authorList,
+		authors:       authors,
+		libraryList:   libraryList,
+		bookList:      bookList,
+		chapterList:   chapterList,
+		quoteList:     quoteList,
+		shelfList:     shelfList,
+		feedList:      feedList,
+		sources:       configuredSources(cfg),
+		settingsInput: textinput.New(),
+		testerMode:    modeReader,
+		indexList:     indexList,
+		keys:          newKeymap(cfg.Keymap, cfg.Keys),
+		covers:        covers,
+		spinner:       spinner.New(spinner.WithSpinner(spinner.Dot)),
+		remoteHost:    sshHost(),
+		currentBook:   currentBook,
+		state:         state,
+		config:        cfg,
+		store:         store,
+		pageWidth:     pageLineWidth,
+		pageLines:     pageLineCount,
+		fontScale:     0,
+		prefetch:      newPrefetcher(),
+		restore:       takeCrashRestore(cfg.CacheDir),
+	}
+	// Mouse clicks are placed on list items by the delegate drawing them.
+	m.delegates = map[mode]list.ItemDelegate{
+		modeAuthorSearch: delegate, modeLibrary: withCovers, modeBooks: withCovers, modeChapters: delegate,
+		modeFeeds: delegate, modeQuotes: delegate, modeBookshelves: delegate, modeAuthorIndex: delegate,
+	}
+	m.localize()
+	if initialMode == modeReader {
+		m.trackSchedule(true)
+		m.loadBookQuotes()
 	}
 
 	return m, nil
 }
 
 func (m model) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, clockTickCmd(), m.batteryCmd(), fetchCoversCmd(m.config.CacheDir, coverIDs(m.libraryList.Items(), m.covers)), m.presenceCmd(), libraryWatchCmd(m.config.BooksDir), authorsRefreshCmd(m.config), rateLimitCmd())
 }
 
+// Update runs update and stops reading aloud once the reader is left or
+// its book replaced.
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if after, ok := next.(model); ok && after.speaking && (after.mode != modeReader || after.state.CurrentBook != m.state.CurrentBook) {
+		after.stopReadingAloud()
+		return after, cmd
+	}
+	return next, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if scheme, ok := colorSchemeReport(msg); ok {
+		msg = scheme
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && m.tour != nil {
+		return m.updateTour(key)
+	}
 	switch msg := msg.(type) {
 	case errMsg:
 		m.err = msg.err
@@ -194,10 +409,135 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.bookList.SetItems(msg.items)
+		m.bookList.Title = tr("Books")
+		if msg.title != "" {
+			m.bookList.Title = msg.title
+		}
+		m.showingPopular = msg.popular
 		m.mode = modeBooks
-		m.status = fmt.Sprintf("%d books", len(msg.items))
+		m.status = trf("%d books", len(msg.items))
+		m.search = searchPaging{}
+		m.awaiting = ""
+		if paged, ok := msg.source.(pagedSource); ok {
+			m.search = searchPaging{source: paged, query: msg.query}
+		}
+		m.prefetch.forget("search:")
+		m.prefetch.forget("feed:")
+		return m, tea.Batch(fetchCoversCmd(m.config.CacheDir, coverIDs(msg.items, m.covers)), m.prefetchResults())
+	case authorsMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.status = msg.err.Error()
+			return m, nil
+		}
+		m.authorList.SetItems(msg.items)
+		m.worksQuery = msg.work
+		m.status = trf("%d authors wrote %q", len(msg.items), msg.work)
+		return m, nil
+	case discoverMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.status = msg.err.Error()
+			return m, nil
+		}
+		m.discovered = msg.book
+		m.discoverFeatured = msg.featured
+		m.status = ""
+		return m, nil
+	case sentMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, m.libraryList.NewStatusMessage(msg.err.Error())
+		}
+		return m, m.libraryList.NewStatusMessage(trf("Sent %s to %s", msg.title, msg.to))
+	case transitionMsg:
+		return m.updateTransition(msg)
+	case translationLoadedMsg:
+		return m.updateTranslationLoaded(msg)
+	case dictionaryLoadedMsg:
+		return m.updateDictionaryLoaded(msg)
+	case prefetchedMsg:
+		return m.updatePrefetched(msg)
+	case collectionMsg:
+		return m.updateCollection(msg)
+	case refreshMsg:
+		return m.updateRefresh(msg)
+	case autoTurnMsg:
+		return m.updateAutoTurn(msg)
+	case spokenMsg:
+		return m.updateSpoken(msg)
+	case sleepMsg:
+		return m.updateSleep(msg)
+	case cueDoneMsg:
+		return m.updateCueDone(msg)
+	case presenceMsg:
+		return m.updatePresence(msg)
+	case remoteMsg:
+		return m.updateRemote(msg)
+	case libraryWatchMsg:
+		return m.updateLibraryWatch(msg)
+	case formatsMsg:
+		return m.updateFormats(msg)
+	case exportedMsg:
+		return m.updateExported(msg)
+	case quoteSavedMsg:
+		return m.updateQuoteSaved(msg)
+	case vocabularySavedMsg:
+		return m.updateVocabularySaved(msg)
+	case bookshelvesMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.status = msg.err.Error()
+			return m, nil
+		}
+		m.shelfList.SetItems(msg.items)
+		m.mode = modeBookshelves
+		return m, nil
+	case webPageMsg:
+		return m.updateWebPage(msg)
+	case relatedMsg:
+		return m.updateRelated(msg)
+	case authorsUpdatedMsg:
+		return m.updateAuthorsUpdated(msg)
+	case rateLimitMsg:
+		return m.updateRateLimit(msg)
+	case colorSchemeMsg:
+		return m.updateColorScheme(msg)
+	case loadedMsg:
+		return m.updateLoaded(msg)
+	case statusClearMsg:
+		if m.status == msg.status {
+			m.status = ""
+		}
 		return m, nil
+	case clockMsg:
+		return m, tea.Batch(clockTickCmd(), m.batteryCmd())
+	case batteryMsg:
+		m.battery = string(msg)
+		return m, nil
+	case coverMsg:
+		m.covers[msg.id] = msg.thumb
+		return m, fetchCoversCmd(m.config.CacheDir, msg.rest)
+	case downloadProgressMsg:
+		m.updateDownloadRow(msg.url, func(b *bookItem) {
+			b.done = msg.done
+			b.total = msg.total
+		})
+		return m, waitForDownload(msg.updates)
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		if !m.spinDownloadRows() && !m.loading.active {
+			return m, nil
+		}
+		return m, cmd
 	case bookLoadedMsg:
+		if msg.url != "" {
+			m.updateDownloadRow(msg.url, func(b *bookItem) {
+				b.downloading = false
+				b.downloaded = msg.err == nil
+			})
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			m.status = msg.err.Error()
@@ -208,32 +548,91 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.state.Page = m.state.Pages[msg.path]
 		m.mode = modeReader
 		m.status = ""
-		m.chapterList.SetItems(buildChapterItems(m.currentBook))
-		items, _ := loadLibraryItems(m.config.BooksDir)
+		m.restorePage(msg.path)
+		m.turnToQuote(msg.path)
+		m.loadBookQuotes()
+		m.trackSchedule(true)
+		var cue tea.Cmd
+		if msg.url != "" {
+			cue = m.notify()
+		}
+		m.chapterList.SetItems(buildChapterItems(m.currentBook, m.state.ReadChapters[msg.path]))
+		items, _ := loadLibraryItems(m.config.BooksDir, m.state, m.config.WPM)
 		m.libraryList.SetItems(items)
-		return m, saveStateCmd(m.state, m.config.StateFile)
+		save := saveStateCmd(m.store, m.state)
+		cmd := m.syncParallel()
+		return m, tea.Batch(save, cmd, m.prefetchNextChapter(), cue)
 	case tea.WindowSizeMsg:
+		// The first size lays the book out at once; later ones wait for
+		// the resizing to settle.
+		first := m.width == 0
 		m.width = msg.Width
 		m.height = msg.Height
 		m.authorList.SetSize(msg.Width, msg.Height)
-		m.libraryList.SetSize(msg.Width, msg.Height)
-		m.bookList.SetSize(msg.Width, msg.Height)
+		m.libraryList.SetSize(msg.Width-coverColumns-1, msg.Height)
+		m.bookList.SetSize(msg.Width-coverColumns-1, msg.Height)
 		m.chapterList.SetSize(msg.Width, msg.Height)
-		pageWidth, pageLines := computePageLayout(msg.Width, msg.Height, m.fontScale)
-		if pageWidth != m.pageWidth || pageLines != m.pageLines {
-			oldTotal := len(m.currentBook.Pages)
-			oldPage := m.state.Page
-			m.pageWidth = pageWidth
-			m.pageLines = pageLines
-			if len(m.currentBook.Chapters) > 0 {
-				m.currentBook.Pages, m.currentBook.Chapters = buildBookPagesForSize(m.currentBook, m.pageWidth, m.pageLines)
-				if oldTotal > 0 && len(m.currentBook.Pages) > 0 {
-					m.state.Page = remapPage(oldPage, oldTotal, len(m.currentBook.Pages))
-				} else if len(m.currentBook.Pages) > 0 && m.state.Page >= len(m.currentBook.Pages) {
-					m.state.Page = len(m.currentBook.Pages) - 1
-				}
-			}
-			return m, saveStateCmd(m.state, m.config.StateFile)
+		m.quoteList.SetSize(msg.Width, msg.Height)
+		m.feedList.SetSize(msg.Width, msg.Height)
+		m.shelfList.SetSize(msg.Width, msg.Height)
+		m.indexList.SetSize(msg.Width, msg.Height)
+		m.help.Width, m.help.Height = msg.Width, max(msg.Height-2, 5)
+		if !first {
+			m.resizeID++
+			return m, resizeSettleCmd(m.resizeID)
+		}
+		if m.applyFontScale() {
+			return m, tea.Batch(saveStateCmd(m.store, m.state), m.prefetchNextChapter())
+		}
+	case resizeSettledMsg:
+		if msg.id == m.resizeID && m.applyFontScale() {
+			return m, tea.Batch(saveStateCmd(m.store, m.state), m.prefetchNextChapter())
+		}
+		return m, nil
+	}
+
+	if key, ok := msg.(tea.KeyMsg); ok && m.restore != nil {
+		return m.updateRestorePrompt(key)
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && m.duplicate != nil {
+		return m.updateDuplicatePrompt(key)
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && m.formats != nil {
+		return m.updateFormatPicker(key)
+	}
+	if m.helpOpen {
+		return m.updateHelp(msg)
+	}
+	if m.palette.open {
+		return m.updatePalette(msg)
+	}
+	if mouse, ok := msg.(tea.MouseMsg); ok {
+		if m.restore != nil || m.duplicate != nil || m.formats != nil || m.tour != nil {
+			return m, nil
+		}
+		return m.updateMouse(mouse)
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && m.loading.active && key.String() == "esc" {
+		m.cancelLoading()
+		return m, m.libraryList.NewStatusMessage(m.status)
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && !m.typing() {
+		if act := m.keys.lookup(m.mode, key.String()); needsNetwork(act) {
+			return m.refuseOffline(act)
+		}
+		switch m.keys.lookup(m.mode, key.String()) {
+		case actionHelp:
+			return m.openHelp(), nil
+		case actionPalette:
+			return m.openPalette(), textinput.Blink
+		case actionRefresh:
+			cmd := m.startLoading(tr("Reloading authors and library"), refreshCmd(m.config, m.state))
+			return m, cmd
+		case actionTutorial:
+			cmd := m.startTutorial()
+			return m, cmd
+		case actionDetectColors:
+			return m, redetectBackgroundCmd()
 		}
 	}
 
@@ -248,35 +647,83 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateReader(msg)
 	case modeChapters:
 		return m.updateChapters(msg)
+	case modeFeeds:
+		return m.updateFeeds(msg)
+	case modePrivacy:
+		return m.updatePrivacy(msg)
+	case modeSettings:
+		return m.updateSettings(msg)
+	case modeKeyTester:
+		return m.updateKeyTester(msg)
+	case modeAuthorIndex:
+		return m.updateAuthorIndex(msg)
+	case modeDiscover:
+		return m.updateDiscover(msg)
+	case modeFinished:
+		return m.updateFinish(msg)
+	case modeQuotes:
+		return m.updateQuotes(msg)
+	case modeVocabulary:
+		return m.updateVocabulary(msg)
+	case modeBookshelves:
+		return m.updateBookshelves(msg)
+	case modeAdvancedSearch:
+		return m.updateAdvancedSearch(msg)
 	default:
 		return m, nil
 	}
 }
 
 func (m model) updateAuthorSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && m.keys.lookup(modeAuthorSearch, key.String()) == actionDiscover {
+		m.mode = modeDiscover
+		m.discovered = gutendexBook{}
+		cmd := m.startLoading(tr("Picking a random book"), discoverCmd(false))
+		return m, cmd
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && m.keys.lookup(modeAuthorSearch, key.String()) == actionAdvancedSearch {
+		cmd := m.openAdvancedSearch()
+		return m, cmd
+	}
 	prev := m.authorInput.Value()
 	var inputCmd tea.Cmd
 	m.authorInput, inputCmd = m.authorInput.Update(msg)
 	if m.authorInput.Value() != prev {
-		m.authorList.SetItems(filterAuthors(m.authors, m.authorsLower, m.authorInput.Value(), 200))
+		m.worksQuery = ""
+		m.authorList.SetItems(filterAuthors(m.authors, authorQuery(m.authorInput.Value()), 200))
 	}
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "enter":
+		switch m.keys.lookup(modeAuthorSearch, msg.String()) {
+		case actionOpen:
+			source := m.sources[m.sourceIndex]
+			if work := parseSearchQuery(m.authorInput.Value()).workTitle(); work != "" && work != m.worksQuery {
+				cmd := m.startLoading(tr("Looking up authors"), fetchAuthorsByWorkCmd(work))
+				return m, cmd
+			}
+			if isFieldQuery(m.authorInput.Value()) {
+				cmd := m.startLoading(tr("Searching books"), fetchBooksCmd(source, m.authorInput.Value()))
+				return m, cmd
+			}
 			if item, ok := m.authorList.SelectedItem().(authorItem); ok {
-				m.status = "Searching books..."
-				return m, fetchBooksCmd(item.name)
+				cmd := m.startLoading(tr("Searching books"), fetchBooksCmd(source, item.name))
+				return m, cmd
 			}
-			if strings.TrimSpace(m.authorInput.Value()) == "" {
-				m.status = "Enter a prefix to search"
+			query := strings.TrimSpace(m.authorInput.Value())
+			if query == "" {
+				m.status = tr("Enter an author name to search")
 				return m, nil
 			}
-		case "b":
+			cmd := m.startLoading(tr("Searching books"), fetchBooksCmd(source, query))
+			return m, cmd
+		case actionNextSource:
+			m.sourceIndex = (m.sourceIndex + 1) % len(m.sources)
+			return m, nil
+		case actionLibrary:
 			m.mode = modeLibrary
 			return m, nil
-		case "esc", "ctrl+c", "q":
+		case actionQuit:
 			return m, tea.Quit
 		}
 	}
@@ -288,27 +735,105 @@ func (m model) updateAuthorSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) updateLibrary(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "enter":
+		if m.libraryList.FilterState() == list.Filtering {
+			break
+		}
+		switch m.keys.lookup(modeLibrary, msg.String()) {
+		case actionOpen:
 			if item, ok := m.libraryList.SelectedItem().(libraryItem); ok {
-				m.status = "Loading book..."
-				return m, openBookCmd(item.path, m.pageWidth, m.pageLines)
+				cmd := m.startLoading(tr("Loading book"), openBookCmd(item.key, m.pageWidth, m.pageLines))
+				return m, cmd
 			}
-		case "s":
+		case actionSearch:
 			m.mode = modeAuthorSearch
 			m.authorInput.Focus()
 			return m, nil
-		case "b":
-			if m.state.CurrentBook != "" && len(m.currentBook.Pages) > 0 {
+		case actionReader:
+			if m.state.CurrentBook != "" && m.currentBook.PageCount() > 0 {
 				m.mode = modeReader
 				return m, nil
 			}
-		case "c":
+		case actionChapters:
 			if len(m.currentBook.Chapters) > 0 {
 				m.mode = modeChapters
 				return m, nil
 			}
-		case "esc", "q", "ctrl+c":
+		case actionFeeds:
+			if len(m.config.OPDSFeeds) == 0 {
+				m.status = tr("No OPDS feeds configured")
+				return m, nil
+			}
+			m.mode = modeFeeds
+			return m, nil
+		case actionPrivacy:
+			m.mode = modePrivacy
+			return m, nil
+		case actionSettings:
+			m.mode = modeSettings
+			return m, nil
+		case actionAuthorIndex:
+			if m.indexLetters == nil {
+				m.indexLetters = buildLetterIndex(m.authors)
+			}
+			m.mode = modeAuthorIndex
+			return m, nil
+		case actionPopular:
+			cmd := m.startLoading(tr("Loading popular books"), fetchPopularCmd(m.config.CacheDir, m.popularPeriod))
+			return m, cmd
+		case actionBookshelves:
+			cmd := m.openBookshelves()
+			return m, cmd
+		case actionAdvancedSearch:
+			cmd := m.openAdvancedSearch()
+			return m, cmd
+		case actionSendBook:
+			if item, ok := m.libraryList.SelectedItem().(libraryItem); ok {
+				status := m.libraryList.NewStatusMessage(trf("Sending %s...", item.title))
+				return m, tea.Batch(status, sendBookCmd(m.config.SMTP, item.path))
+			}
+		case actionExport:
+			if item, ok := m.libraryList.SelectedItem().(libraryItem); ok {
+				status := m.libraryList.NewStatusMessage(trf("Exporting %s...", item.title))
+				return m, tea.Batch(status, exportBookCmd(item.key, exportMarkdown, m.config.ExportDir))
+			}
+		case actionPairTranslation:
+			if item, ok := m.libraryList.SelectedItem().(libraryItem); ok && m.state.CurrentBook != "" {
+				m.state.pairTranslation(m.state.CurrentBook, item.key)
+				msg := trf("Paired %s with %s (v in the reader shows both)", item.title, filepath.Base(m.state.CurrentBook))
+				if item.key == m.state.CurrentBook {
+					msg = trf("Removed the translation of %s", item.title)
+				}
+				return m, tea.Batch(m.libraryList.NewStatusMessage(msg), saveStateCmd(m.store, m.state))
+			}
+		case actionFilters:
+			if item, ok := m.libraryList.SelectedItem().(libraryItem); ok {
+				preset, err := cycleBookPreset(item.path)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				item.preset = preset
+				cmd := m.libraryList.SetItem(m.libraryList.Index(), item)
+				status := m.libraryList.NewStatusMessage(trf("Filters for %s: %s (applies when the book is opened)", item.title, preset))
+				return m, tea.Batch(cmd, status)
+			}
+		case actionCollection:
+			if item, ok := m.libraryList.SelectedItem().(libraryItem); ok {
+				return m, toggleCollectionCmd(item.path)
+			}
+		case actionQuiet:
+			save := m.toggleQuiet()
+			return m, tea.Batch(save, m.libraryList.NewStatusMessage(m.status))
+		case actionQuotes:
+			m.openQuotes()
+			return m, m.libraryList.NewStatusMessage(m.status)
+		case actionVocabulary:
+			m.openVocabulary()
+			return m, m.libraryList.NewStatusMessage(m.status)
+		case actionWebPage:
+			cmd := m.openWebPage()
+			return m, cmd
+		case actionQuit:
 			return m, tea.Quit
 		}
 	}
@@ -320,76 +845,207 @@ func (m model) updateLibrary(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) updateBooks(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "enter":
+		switch m.keys.lookup(modeBooks, msg.String()) {
+		case actionOpen:
 			if item, ok := m.bookList.SelectedItem().(bookItem); ok {
-				m.status = "Downloading book..."
-				return m, downloadAndLoadCmd(item.url, item.subtitle, item.title, m.config.BooksDir, m.pageWidth, m.pageLines)
+				if item.result.Feed {
+					key := feedPageKey(item.result.URL)
+					msg, running := m.prefetch.take(key)
+					if msg != nil {
+						return m.Update(msg)
+					}
+					if running {
+						m.status = tr("Loading feed...")
+						m.awaiting = key
+						return m, nil
+					}
+					cmd := m.startLoading(tr("Loading feed"), fetchFeedCmd(item.source, item.result.URL))
+					return m, cmd
+				}
+				if item.downloading {
+					return m, nil
+				}
+				cmd := m.download(item)
+				return m, cmd
+			}
+		case actionPopular:
+			if m.showingPopular {
+				m.popularPeriod = (m.popularPeriod + 1) % len(popularPeriods)
 			}
-		case "b":
+			cmd := m.startLoading(tr("Loading popular books"), fetchPopularCmd(m.config.CacheDir, m.popularPeriod))
+			return m, cmd
+		case actionBookshelves:
+			cmd := m.openBookshelves()
+			return m, cmd
+		case actionLibrary:
 			m.mode = modeLibrary
 			return m, nil
-		case "s":
+		case actionSearch:
 			m.mode = modeAuthorSearch
 			m.authorInput.Focus()
 			return m, nil
-		case "esc", "q", "ctrl+c":
+		case actionQuit:
 			return m, tea.Quit
 		}
 	}
 	var cmd tea.Cmd
 	m.bookList, cmd = m.bookList.Update(msg)
+	if _, ok := msg.(tea.KeyMsg); ok {
+		next, more := m.moreResults()
+		return next, tea.Batch(cmd, more)
+	}
 	return m, cmd
 }
 
 func (m model) updateReader(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
+		if m.scrubbing {
+			return m.updateScrub(msg)
+		}
+		act := m.keys.lookup(modeReader, msg.String())
+		if m.parallel && m.unlinked && m.rightPane && m.translation.PageCount() > 0 {
+			switch act {
+			case actionNextPage:
+				m.turnRightPage(m.rightPage + 1)
+				return m, nil
+			case actionPrevPage:
+				m.turnRightPage(m.rightPage - 1)
+				return m, nil
+			case actionFirstPage:
+				m.turnRightPage(0)
+				return m, nil
+			case actionLastPage:
+				m.turnRightPage(m.translation.PageCount() - 1)
+				return m, nil
+			}
+		}
+		switch act {
+		case actionQuit:
 			return m, tea.Quit
-		case "b":
+		case actionLibrary:
 			m.mode = modeLibrary
 			return m, nil
-		case "s":
+		case actionSearch:
 			m.mode = modeAuthorSearch
 			m.authorInput.Focus()
 			return m, nil
-		case "c":
+		case actionChapters:
 			if len(m.currentBook.Chapters) > 0 {
 				m.mode = modeChapters
 				return m, nil
 			}
-		case "+", "=":
+		case actionBiggerText:
 			m.fontScale++
 			m.applyFontScale()
-			return m, saveStateCmd(m.state, m.config.StateFile)
-		case "-":
+			return m, saveStateCmd(m.store, m.state)
+		case actionSmallerText:
 			m.fontScale--
 			m.applyFontScale()
-			return m, saveStateCmd(m.state, m.config.StateFile)
-		case "enter", " ", "right", "down", "pgdown":
-			if m.state.Page < len(m.currentBook.Pages)-1 {
-				m.state.Page++
-				m.state.Pages[m.state.CurrentBook] = m.state.Page
-				return m, saveStateCmd(m.state, m.config.StateFile)
+			return m, saveStateCmd(m.store, m.state)
+		case actionParallel:
+			cmd := m.toggleParallel()
+			return m, cmd
+		case actionLinkPanes:
+			m.toggleLinkedPaging()
+		case actionSwitchPane:
+			m.switchPane()
+		case actionGloss:
+			cmd := m.toggleGloss()
+			return m, cmd
+		case actionNextParagraph:
+			if m.glossing {
+				m.moveGloss(1)
+			}
+		case actionPrevParagraph:
+			if m.glossing {
+				m.moveGloss(-1)
+			}
+		case actionNextWord:
+			if m.glossing {
+				m.moveGlossWord(1)
+			}
+		case actionPrevWord:
+			if m.glossing {
+				m.moveGlossWord(-1)
+			}
+		case actionLookUp:
+			cmd := m.lookUpWord()
+			return m, cmd
+		case actionLargePrint:
+			m.config.LargePrint = !m.config.LargePrint
+			m.applyFontScale()
+			return m, tea.Batch(saveStateCmd(m.store, m.state), saveConfigCmd(m.config))
+		case actionNextPage:
+			if m.presenting {
+				cmd := m.moveSentence(1)
+				return m, cmd
 			}
-		case "left", "up", "pgup":
-			if m.state.Page > 0 {
-				m.state.Page--
-				m.state.Pages[m.state.CurrentBook] = m.state.Page
-				return m, saveStateCmd(m.state, m.config.StateFile)
+			if m.state.Page == m.currentBook.PageCount()-1 {
+				cmd := m.openFinish()
+				return m, cmd
 			}
-		case "home":
-			m.state.Page = 0
-			m.state.Pages[m.state.CurrentBook] = m.state.Page
-			return m, saveStateCmd(m.state, m.config.StateFile)
-		case "end":
-			if len(m.currentBook.Pages) > 0 {
-				m.state.Page = len(m.currentBook.Pages) - 1
-				m.state.Pages[m.state.CurrentBook] = m.state.Page
-				return m, saveStateCmd(m.state, m.config.StateFile)
+			return m, m.turnPage(m.state.Page + 1)
+		case actionPrevPage:
+			if m.presenting {
+				cmd := m.moveSentence(-1)
+				return m, cmd
 			}
+			return m, m.turnPage(m.state.Page - 1)
+		case actionScrub:
+			if m.currentBook.PageCount() > 0 {
+				m.startScrub()
+			}
+			return m, nil
+		case actionFirstPage:
+			return m, m.turnPage(0)
+		case actionLastPage:
+			return m, m.turnPage(m.currentBook.PageCount() - 1)
+		case actionNextChapter:
+			cmd := m.jumpChapter(1)
+			return m, cmd
+		case actionPrevChapter:
+			cmd := m.jumpChapter(-1)
+			return m, cmd
+		case actionFirstUnread:
+			cmd := m.jumpToFirstUnread()
+			return m, cmd
+		case actionSchedule:
+			m.cycleSchedule()
+			return m, saveStateCmd(m.store, m.state)
+		case actionAutoTurn:
+			cmd := m.toggleAutoTurn()
+			return m, cmd
+		case actionReadAloud:
+			cmd := m.toggleReadAloud()
+			return m, cmd
+		case actionSleepTimer:
+			cmd := m.cycleSleepTimer()
+			return m, cmd
+		case actionQuiet:
+			cmd := m.toggleQuiet()
+			return m, cmd
+		case actionFollow:
+			cmd := m.toggleFollow()
+			return m, cmd
+		case actionPresent:
+			cmd := m.togglePresentation()
+			return m, cmd
+		case actionCopy:
+			cmd := m.copyPage()
+			return m, cmd
+		case actionQuote:
+			cmd := m.captureQuote()
+			return m, cmd
+		case actionQuotes:
+			m.openQuotes()
+			return m, nil
+		case actionVocabulary:
+			m.openVocabulary()
+			return m, nil
+		case actionWebPage:
+			cmd := m.openWebPage()
+			return m, cmd
 		}
 	}
 	return m, nil
@@ -398,20 +1054,24 @@ func (m model) updateReader(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) updateChapters(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "enter":
+		switch m.keys.lookup(modeChapters, msg.String()) {
+		case actionOpen:
 			if item, ok := m.chapterList.SelectedItem().(chapterItem); ok {
 				if item.index >= 0 && item.index < len(m.currentBook.Chapters) {
 					m.state.Page = m.currentBook.Chapters[item.index].StartPage
 					m.state.Pages[m.state.CurrentBook] = m.state.Page
+					m.markPageRead()
 					m.mode = modeReader
-					return m, saveStateCmd(m.state, m.config.StateFile)
+					return m, tea.Batch(saveStateCmd(m.store, m.state), m.prefetchNextChapter())
 				}
 			}
-		case "b", "esc":
+		case actionFirstUnread:
+			cmd := m.jumpToFirstUnread()
+			return m, cmd
+		case actionBack:
 			m.mode = modeReader
 			return m, nil
-		case "q", "ctrl+c":
+		case actionQuit:
 			return m, tea.Quit
 		}
 	}
@@ -420,7 +1080,54 @@ func (m model) updateChapters(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m model) updateFeeds(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.feedList.FilterState() == list.Filtering {
+			break
+		}
+		switch m.keys.lookup(modeFeeds, msg.String()) {
+		case actionOpen:
+			if item, ok := m.feedList.SelectedItem().(feedItem); ok {
+				cmd := m.startLoading(tr("Loading feed"), fetchFeedCmd(item.source, item.source.feed.URL))
+				return m, cmd
+			}
+		case actionBack:
+			m.mode = modeLibrary
+			return m, nil
+		case actionQuit:
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.feedList, cmd = m.feedList.Update(msg)
+	return m, cmd
+}
+
 func (m model) View() string {
+	view := m.modeView()
+	if m.helpOpen {
+		view = m.helpView()
+	}
+	if m.palette.open {
+		view = m.paletteView()
+	}
+	if m.duplicate != nil {
+		view = m.duplicateView()
+	}
+	if m.formats != nil {
+		view = m.formatPickerView()
+	}
+	if m.restore != nil {
+		view = m.restoreView()
+	}
+	if m.largePrint() {
+		return singleWidthLines(view)
+	}
+	return view
+}
+
+func (m model) modeView() string {
 	switch m.mode {
 	case modeAuthorSearch:
 		return m.authorSearchView()
@@ -432,105 +1139,235 @@ func (m model) View() string {
 		return m.readerView()
 	case modeChapters:
 		return m.chapterListView()
+	case modeFeeds:
+		return m.feedListView()
+	case modePrivacy:
+		return m.privacyView()
+	case modeSettings:
+		return m.settingsView()
+	case modeKeyTester:
+		return m.keyTesterView()
+	case modeAuthorIndex:
+		return m.authorIndexView()
+	case modeDiscover:
+		return m.discoverView()
+	case modeFinished:
+		return m.finishView()
+	case modeQuotes:
+		return m.quotesView()
+	case modeVocabulary:
+		return m.vocabularyView()
+	case modeBookshelves:
+		return m.bookshelvesView()
+	case modeAdvancedSearch:
+		return m.advancedSearchView()
 	default:
 		return ""
 	}
 }
 
 func (m model) authorSearchView() string {
-	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63")).Render("Gutenberg Reader")
-	prompt := "Search authors by prefix"
-	status := m.status
+	status := m.statusText()
 	if status == "" {
-		status = "Type to filter, enter to select, b: library, q: quit"
+		status = tr("Type to filter") + "  " + m.keys.hint(modeAuthorSearch, actionOpen, actionNextSource, actionDiscover, actionAdvancedSearch, actionLibrary, actionTutorial, actionHelp, actionQuit)
 	}
-	listView := m.authorList.View()
-	return strings.Join([]string{title, "", prompt, m.authorInput.View(), "", listView, "", status}, "\n")
+	return strings.Join(append(m.authorSearchTop(), m.authorList.View(), "", status), "\n")
+}
+
+// authorSearchTop is what the search screen shows above the author list.
+func (m model) authorSearchTop() []string {
+	title := titleStyle().Render(tr("Gutenberg Reader"))
+	prompt := tr("Search authors, or use author: title: subject: lang: century: work: fields")
+	source := helpLine(sourceLabel(m.sources, m.sourceIndex))
+	return []string{title, source, "", prompt, m.authorInput.View(), ""}
 }
 
 func (m model) libraryView() string {
-	return m.libraryList.View() + "\n" + helpLine("enter: open  s: search  c: chapters  b: back  q: quit")
+	library := m.libraryList
+	if m.config.Profile != "" {
+		library.Title += " (" + m.config.Profile + ")"
+	}
+	if progress := goalProgress(m.config, m.state.Reading, time.Now()); progress != "" {
+		library.Title += " · " + progress
+	}
+	return library.View() + "\n" + m.footerLine(m.keys.hint(modeLibrary, actionOpen, actionSearch, actionAuthorIndex, actionReader, actionSettings, actionHelp, actionQuit))
 }
 
 func (m model) bookListView() string {
-	return m.bookList.View() + "\n" + helpLine("enter: download/read  b: library  s: search  q: quit")
+	return m.bookList.View() + "\n" + m.footerLine(m.keys.hint(modeBooks, actionOpen, actionLibrary, actionSearch, actionPopular, actionBookshelves, actionHelp, actionQuit))
 }
 
 func (m model) chapterListView() string {
-	return m.chapterList.View() + "\n" + helpLine("enter: open  b/esc: back  q: quit")
+	return m.chapterList.View() + "\n" + m.footerLine(m.keys.hint(modeChapters, actionOpen, actionFirstUnread, actionBack, actionHelp, actionQuit))
+}
+
+func (m model) feedListView() string {
+	return m.feedList.View() + "\n" + m.footerLine(m.keys.hint(modeFeeds, actionOpen, actionBack, actionHelp, actionQuit))
 }
 
 func (m model) readerView() string {
-	if len(m.currentBook.Pages) == 0 {
-		return "No pages available."
+	if m.currentBook.PageCount() == 0 {
+		return tr("No pages available.")
 	}
-	page := m.currentBook.Pages[m.state.Page]
-
-	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63"))
-	metaStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("242"))
-	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	if m.presenting {
+		return m.presentationView()
+	}
+	page := m.currentBook.Page(m.state.Page)
 
-	header := titleStyle.Render(m.currentBook.Title)
-	status := metaStyle.Render(fmt.Sprintf("Page %d/%d", m.state.Page+1, len(m.currentBook.Pages)))
+	values := m.statusValues()
 
 	contentWidth := m.pageWidth
 	if contentWidth == 0 {
 		contentWidth = pageLineWidth
 	}
-	paddingLeft := 2
-	content := lipgloss.NewStyle().Width(contentWidth+paddingLeft).PaddingLeft(paddingLeft).Render(page)
-	footer := footerStyle.Render("Enter/Espacio: next  pgup: prev  +/-: size  c: chapters  b: library  s: search  q: quit")
+	if m.speaking {
+		page, _ = markSentences(page, m.sentence, lipgloss.NewStyle(), spokenSentence)
+	}
+	page = m.transitionPage(m.glossPage(page, contentWidth), contentWidth)
+	if m.parallel && m.translation.PageCount() > 0 {
+		page = m.parallelContent(page, contentWidth)
+		contentWidth = lipgloss.Width(page)
+	}
+	content := lipgloss.NewStyle().Width(contentWidth + pagePadding).PaddingLeft(pagePadding).Render(page)
+	if m.showMargin() {
+		content = m.withMargin(content, m.currentBook.Page(m.state.Page))
+	}
+	if m.config.LargePrint {
+		content = largePrintLines(content)
+	}
+
+	lines := m.readerTop(values)
+	if m.config.ProgressBar {
+		lines = append(lines, strings.Repeat(" ", pagePadding)+m.progressBar(m.barWidth()))
+	}
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, content)
+	status := m.statusText()
+	if m.scrubbing {
+		status = m.scrubLine()
+	}
+	if status == "" {
+		status = m.tourPrompt()
+	}
+	if status != "" {
+		lines = append(lines, "", metaStyle().Render(status))
+	}
+	if footer := renderTemplate(tr(m.config.Footer), values); footer != "" {
+		lines = append(lines, "", helpStyle().Render(footer))
+	}
+	return strings.Join(lines, "\n")
+}
 
-	return strings.Join([]string{header, status, "", content, "", footer}, "\n")
+// readerTop are the header and status bar lines above the page.
+func (m model) readerTop(values map[string]string) []string {
+	var lines []string
+	if header := renderTemplate(tr(m.config.Header), values); header != "" {
+		lines = append(lines, titleStyle().Render(header))
+	}
+	if status := renderTemplate(tr(m.config.StatusBar), values); status != "" {
+		if values["print_page"] != "" && !strings.Contains(m.config.StatusBar, "{print_page}") {
+			status += " · " + trf("print page %s", values["print_page"])
+		}
+		lines = append(lines, metaStyle().Render(status))
+	}
+	return lines
 }
 
 func helpLine(msg string) string {
-	return lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Render(msg)
+	return helpStyle().Render(msg)
 }
 
-func fetchBooksCmd(author string) tea.Cmd {
-	return func() tea.Msg {
-		books, err := fetchBooks(author)
+func fetchBooksCmd(source BookSource, query string) loadCmd {
+	return func(ctx context.Context) tea.Msg {
+		books, err := source.Search(ctx, query)
+		if err == nil {
+			books, err = filterResults(ctx, books)
+		}
 		if err != nil {
 			return booksMsg{err: err}
 		}
-		items := make([]list.Item, 0, len(books))
-		for _, b := range books {
-			items = append(items, bookItem{title: b.Title, url: b.URL, subtitle: b.Subtitle, extra: b.Extra})
+		return booksMsg{items: buildBookItems(source, books), source: source, query: query}
+	}
+}
+
+func fetchFeedCmd(source BookSource, feedURL string) loadCmd {
+	return func(ctx context.Context) tea.Msg {
+		results, err := fetchOPDSItems(ctx, featureCatalog, feedURL)
+		if err == nil {
+			results, err = filterResults(ctx, results)
+		}
+		if err != nil {
+			return booksMsg{err: err}
 		}
-		return booksMsg{items: items}
+		return booksMsg{items: buildBookItems(source, results)}
 	}
 }
 
-func downloadAndLoadCmd(bookURL, author, title, outDir string, width, lines int) tea.Cmd {
-	return func() tea.Msg {
-		path, err := downloadBookHTML(bookURL, author, title, outDir)
+func downloadAndLoadCmd(source BookSource, result bookResult, outDir string, width, lines int) tea.Cmd {
+	updates := make(chan tea.Msg, 1)
+	go func() {
+		report := func(done, total int64) {
+			select {
+			case updates <- downloadProgressMsg{url: result.URL, done: done, total: total, updates: updates}:
+			default:
+			}
+		}
+		path, err := source.Download(context.Background(), result, outDir, report)
 		if err != nil {
-			return bookLoadedMsg{err: err}
+			updates <- bookLoadedMsg{url: result.URL, err: err}
+			return
 		}
-		book, err := loadBookFromHTML(path, width, lines)
+		book, err := loadAddedBook(path, width, lines)
 		if err != nil {
-			return bookLoadedMsg{err: err}
+			updates <- bookLoadedMsg{url: result.URL, err: err}
+			return
 		}
-		return bookLoadedMsg{book: book, path: path}
+		updates <- bookLoadedMsg{book: book, path: path, url: result.URL}
+	}()
+	return waitForDownload(updates)
+}
+
+func buildBookItems(source BookSource, results []bookResult) []list.Item {
+	items := make([]list.Item, 0, len(results))
+	for _, r := range results {
+		items = append(items, bookItem{result: r, source: source})
 	}
+	return items
 }
 
-func buildChapterItems(book Book) []list.Item {
+func buildFeedItems(feeds []OPDSFeed) []list.Item {
+	items := make([]list.Item, 0, len(feeds))
+	for _, feed := range feeds {
+		items = append(items, feedItem{source: opdsSource{feed: feed}})
+	}
+	return items
+}
+
+func buildChapterItems(book Book, read []int) []list.Item {
 	items := make([]list.Item, 0, len(book.Chapters))
 	for i, ch := range book.Chapters {
 		title := ch.Title
 		if title == "" {
-			title = fmt.Sprintf("Chapter %d", i+1)
+			title = trf("Chapter %d", i+1)
+		}
+		mark := " "
+		if slices.Contains(read, i) {
+			mark = readMark
 		}
-		items = append(items, chapterItem{title: fmt.Sprintf("%3d. %s", i+1, title), index: i})
+		item := chapterItem{title: fmt.Sprintf("%s %3d. %s", mark, i+1, title), index: i}
+		if ch.Language != book.Language {
+			item.language = ch.Language
+		}
+		items = append(items, item)
 	}
 	return items
 }
 
-func openBookCmd(path string, width, lines int) tea.Cmd {
-	return func() tea.Msg {
-		book, err := loadBookFromHTML(path, width, lines)
+func openBookCmd(path string, width, lines int) loadCmd {
+	return func(context.Context) tea.Msg {
+		book, err := loadBook(path, width, lines)
 		if err != nil {
 			return bookLoadedMsg{err: err}
 		}
@@ -538,11 +1375,17 @@ func openBookCmd(path string, width, lines int) tea.Cmd {
 	}
 }
 
-func loadLibraryItems(dir string) ([]list.Item, error) {
+// loadLibraryItems lists the books in dir, a collection as one item per
+// story with its length and whether it was started or finished.
+func loadLibraryItems(dir string, state State, wpm int) ([]list.Item, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, err
 	}
+	lib, err := loadLibrary(dir)
+	if err != nil {
+		return nil, err
+	}
 	items := make([]list.Item, 0, len(entries))
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -555,70 +1398,123 @@ func loadLibraryItems(dir string) ([]list.Item, error) {
 		title := strings.TrimSuffix(name, ".html")
 		title = strings.TrimSuffix(title, ".images")
 		title = strings.ReplaceAll(title, "_", " ")
+		path := filepath.Join(dir, name)
 		items = append(items, libraryItem{
-			title: title,
-			path:  filepath.Join(dir, name),
+			title:   title,
+			path:    path,
+			key:     path,
+			id:      lib.Books[name].ID,
+			edition: lib.Books[name].Edition,
+			preset:  lib.Books[name].Preset,
 		})
 	}
-	sort.Slice(items, func(i, j int) bool {
-		return items[i].(libraryItem).title < items[j].(libraryItem).title
-	})
-	return items, nil
-}
-
-func filterAuthors(authors []string, authorsLower []string, prefix string, limit int) []list.Item {
-	prefix = strings.TrimSpace(strings.ToLower(prefix))
-	if prefix == "" {
-		return nil
-	}
-	start := sort.Search(len(authorsLower), func(i int) bool {
-		return authorsLower[i] >= prefix
+	groupSeries(items, lib)
+	collator := newCollator()
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].(libraryItem), items[j].(libraryItem)
+		if a.series != "" && b.series != "" && foldString(a.series) == foldString(b.series) {
+			return a.volume < b.volume
+		}
+		return collator.CompareString(sortTitle(a.sortName()), sortTitle(b.sortName())) < 0
 	})
-
-	items := make([]list.Item, 0, limit)
-	for i := start; i < len(authorsLower); i++ {
-		if !strings.HasPrefix(authorsLower[i], prefix) {
-			break
+	expanded := make([]list.Item, 0, len(items))
+	for _, item := range items {
+		entry := lib.Books[filepath.Base(item.(libraryItem).path)]
+		if !entry.Collection || len(entry.Stories) == 0 {
+			expanded = append(expanded, item)
+			continue
 		}
-		items = append(items, authorItem{name: authors[i]})
-		if limit > 0 && len(items) >= limit {
-			break
+		expanded = append(expanded, storyItems(item.(libraryItem), entry.Stories, state, wpm)...)
+	}
+	now := time.Now()
+	for i, item := range expanded {
+		item := item.(libraryItem)
+		if s, ok := state.Schedules[item.key]; ok {
+			item.schedule = scheduleStatus(s, state.Pages[item.key], s.Pages, now)
 		}
+		item.finished = state.Books[item.key].finishedMark()
+		expanded[i] = item
 	}
-	return items
+	return expanded, nil
 }
 
-func saveStateCmd(state State, path string) tea.Cmd {
+func saveStateCmd(store stateStore, state State) tea.Cmd {
+	seq := stateSaves.ask(store, state)
 	return func() tea.Msg {
-		if err := saveState(path, state); err != nil {
+		if err := stateSaves.flush(seq); err != nil {
 			return errMsg{err: err}
 		}
 		return nil
 	}
 }
 
-func (m *model) applyFontScale() {
+// resizeSettle is how long the window size must stay put before the book
+// is paginated again: dragging a window edge sends a stream of sizes.
+const resizeSettle = 150 * time.Millisecond
+
+type resizeSettledMsg struct{ id int }
+
+func resizeSettleCmd(id int) tea.Cmd {
+	return tea.Tick(resizeSettle, func(time.Time) tea.Msg { return resizeSettledMsg{id: id} })
+}
+
+func (m *model) applyFontScale() bool {
 	if m.fontScale > 5 {
 		m.fontScale = 5
 	}
 	if m.fontScale < -5 {
 		m.fontScale = -5
 	}
-	pageWidth, pageLines := computePageLayout(m.width, m.height, m.fontScale)
-	if pageWidth != m.pageWidth || pageLines != m.pageLines {
-		oldTotal := len(m.currentBook.Pages)
-		oldPage := m.state.Page
-		m.pageWidth = pageWidth
-		m.pageLines = pageLines
-		if len(m.currentBook.Chapters) > 0 {
-			m.currentBook.Pages, m.currentBook.Chapters = buildBookPagesForSize(m.currentBook, m.pageWidth, m.pageLines)
-			if oldTotal > 0 && len(m.currentBook.Pages) > 0 {
-				m.state.Page = remapPage(oldPage, oldTotal, len(m.currentBook.Pages))
-			} else if len(m.currentBook.Pages) > 0 && m.state.Page >= len(m.currentBook.Pages) {
-				m.state.Page = len(m.currentBook.Pages) - 1
-			}
+	width, height := m.width, m.height
+	if m.marginFits() {
+		width -= marginGap + marginWidth
+	}
+	if m.config.ProgressBar && height > 0 {
+		height--
+	}
+	pageWidth, pageLines := computePageLayout(width, height, m.fontScale, m.largePrint())
+	if m.parallel {
+		pageWidth = max((pageWidth-lipgloss.Width(parallelGutter))/2, minParallelWidth)
+	}
+	if pageWidth == m.pageWidth && pageLines == m.pageLines {
+		return false
+	}
+	oldTotal := m.currentBook.PageCount()
+	oldPage := m.state.Page
+	anchor, anchored := anchorAt(m.currentBook, oldPage)
+	m.pageWidth = pageWidth
+	m.pageLines = pageLines
+	if len(m.currentBook.Chapters) > 0 {
+		m.currentBook.layoutPages(m.pageWidth, m.pageLines)
+		if page, ok := anchorPage(m.currentBook, anchor); anchored && ok {
+			m.state.Page = page
+		} else if oldTotal > 0 && m.currentBook.PageCount() > 0 {
+			m.state.Page = remapPage(oldPage, oldTotal, m.currentBook.PageCount())
+		} else if m.currentBook.PageCount() > 0 && m.state.Page >= m.currentBook.PageCount() {
+			m.state.Page = m.currentBook.PageCount() - 1
+		}
+	}
+	if len(m.translation.Chapters) > 0 {
+		oldTotal := m.translation.PageCount()
+		m.translation.layoutPages(m.pageWidth, m.pageLines)
+		if oldTotal > 0 && m.translation.PageCount() > 0 {
+			m.rightPage = remapPage(m.rightPage, oldTotal, m.translation.PageCount())
 		}
 	}
+	return true
+}
+
+func minutesLeft(book Book, page, wpm int) int {
+	total := book.PageCount()
+	if wpm <= 0 || total == 0 {
+		return 0
+	}
+	remaining := float64(total-page) / float64(total)
+	minutes := int(remaining*float64(book.Words)/float64(wpm) + 0.5)
+	if minutes < 1 {
+		minutes = 1
+	}
+	return minutes
 }
 
 func remapPage(oldPage, oldTotal, newTotal int) int {
@@ -636,7 +1532,7 @@ func remapPage(oldPage, oldTotal, newTotal int) int {
 	return newPage
 }
 
-func computePageLayout(width, height, scale int) (int, int) {
+func computePageLayout(width, height, scale int, large bool) (int, int) {
 	baseWidth := pageLineWidth
 	baseLines := pageLineCount
 	if width > 0 {
@@ -645,13 +1541,21 @@ func computePageLayout(width, height, scale int) (int, int) {
 	if height > 0 {
 		baseLines = height - 8
 	}
+	if large {
+		baseWidth /= 2
+		baseLines /= 2
+	}
+	minWidth, minLines := 40, 10
+	if large {
+		minWidth, minLines = 20, 5
+	}
 	pageWidth := baseWidth - (scale * 4)
 	pageLines := baseLines - (scale * 2)
-	if pageWidth < 40 {
-		pageWidth = 40
+	if pageWidth < minWidth {
+		pageWidth = minWidth
 	}
-	if pageLines < 10 {
-		pageLines = 10
+	if pageLines < minLines {
+		pageLines = minLines
 	}
 	return pageWidth, pageLines
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tutorialFiles are the "Welcome to gutberg" book, one per interface
+// language. {action} in the text is replaced by the keys bound to it.
+//
+//go:embed tutorial
+var tutorialFiles embed.FS
+
+// tourStep is one lesson of the tutorial: the key it teaches and how to
+// tell, from the model before and after a key press, that it was done.
+type tourStep struct {
+	mode   mode
+	action action
+	prompt string
+	done   func(before, after model) bool
+}
+
+// tourSteps follow the chapters of the tutorial book, in order; the last
+// one leaves the book for the library.
+var tourSteps = []tourStep{
+	{modeReader, actionNextPage, "press %s to turn the page", func(b, a model) bool { return a.state.Page > b.state.Page }},
+	{modeReader, actionPrevPage, "press %s to go back a page", func(b, a model) bool { return a.state.Page < b.state.Page }},
+	{modeReader, actionChapters, "press %s and pick a chapter with enter", func(b, a model) bool { return b.mode == modeChapters && a.mode == modeReader }},
+	{modeReader, actionBiggerText, "press %s to make the text bigger", func(b, a model) bool { return a.fontScale != b.fontScale }},
+	{modeReader, actionHelp, "press %s to list every key", func(b, a model) bool { return a.helpOpen }},
+	{modeReader, actionPalette, "press %s to open the command palette", func(b, a model) bool { return a.palette.open }},
+	{modeReader, actionPalette, "press %s, type theme and press enter", func(b, a model) bool { return a.config.Theme != b.config.Theme }},
+	{modeReader, actionPalette, "press %s, type loc 1 and press enter", func(b, a model) bool {
+		return b.palette.open && !a.palette.open && a.state.Page != b.state.Page
+	}},
+	{modeReader, actionLibrary, "press %s to go to your library", func(b, a model) bool { return a.mode == modeLibrary }},
+}
+
+// tutorialTour is the tutorial being followed in the tutorial book.
+type tutorialTour struct {
+	path string
+	step int
+}
+
+// tutorialHTML is the tutorial book in the interface language, with the
+// keys of the keymap in use.
+func tutorialHTML(keys keymap) ([]byte, error) {
+	data, err := tutorialFiles.ReadFile("tutorial/" + uiLanguage + ".html")
+	if err != nil {
+		data, err = tutorialFiles.ReadFile("tutorial/en.html")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pairs []string
+	for _, b := range keys.bindings[modeReader] {
+		if len(b.keys) > 0 {
+			pairs = append(pairs, "{"+string(b.action)+"}", html.EscapeString(bindingKeys(b.keys[:min(len(b.keys), 2)])))
+		}
+	}
+	for _, b := range keys.bindings[modeLibrary] {
+		if b.action == actionSearch && len(b.keys) > 0 {
+			pairs = append(pairs, "{library_search}", html.EscapeString(bindingKeys(b.keys[:1])))
+		}
+	}
+	return []byte(strings.NewReplacer(pairs...).Replace(string(data))), nil
+}
+
+// tutorialCmd writes the tutorial book to the cache dir, outside the
+// library, and opens it.
+func tutorialCmd(path string, data []byte, width, lines int) loadCmd {
+	return func(ctx context.Context) tea.Msg {
+		if old, err := os.ReadFile(path); err != nil || !bytes.Equal(old, data) {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return bookLoadedMsg{err: err}
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				return bookLoadedMsg{err: err}
+			}
+		}
+		return openBookCmd(path, width, lines)(ctx)
+	}
+}
+
+// startTutorial opens the tutorial book at its first page and starts the
+// tour of its lessons.
+func (m *model) startTutorial() tea.Cmd {
+	data, err := tutorialHTML(m.keys)
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+	path := filepath.Join(m.config.CacheDir, "tutorial", uiLanguage+".html")
+	delete(m.state.Pages, path)
+	m.tour = &tutorialTour{path: path}
+	return m.startLoading(tr("Opening the tutorial"), tutorialCmd(path, data, m.pageWidth, m.pageLines))
+}
+
+// updateTour runs a key press and checks whether it did what the current
+// lesson asks. Opening another book ends the tour.
+func (m model) updateTour(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	tour := m.tour
+	m.tour = nil
+	next, cmd := m.Update(msg)
+	after := next.(model)
+	switch {
+	case after.tour != nil:
+		return after, cmd
+	case m.state.CurrentBook != tour.path:
+		// Still opening the tutorial book.
+		after.tour = tour
+		return after, cmd
+	case after.state.CurrentBook != tour.path:
+		return after, cmd
+	}
+	after.tour = tour
+	if !tourSteps[tour.step].done(m, after) {
+		return after, cmd
+	}
+	if tour.step == len(tourSteps)-1 {
+		after.tour = nil
+		done := trf("Tutorial complete! %s searches Project Gutenberg for your first book", after.keys.keysFor(modeLibrary, actionSearch))
+		return after, tea.Batch(cmd, after.libraryList.NewStatusMessage(done))
+	}
+	after.tour = &tutorialTour{path: tour.path, step: tour.step + 1}
+	after.status = "✓ " + after.tourPrompt()
+	return after, cmd
+}
+
+// tourPrompt is what the current lesson asks for, shown in the reader
+// while no other status is.
+func (m model) tourPrompt() string {
+	if m.tour == nil || m.tour.step >= len(tourSteps) || m.state.CurrentBook != m.tour.path {
+		return ""
+	}
+	step := tourSteps[m.tour.step]
+	return trf("Tutorial %d/%d: %s", m.tour.step+1, len(tourSteps), trf(step.prompt, m.keys.keysFor(step.mode, step.action)))
+}
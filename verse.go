@@ -0,0 +1,94 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	xhtml "golang.org/x/net/html"
+)
+
+// verseMark starts every line whose breaks must survive wrapping: poems,
+// stanzas and preformatted blocks.
+const verseMark = "\x1e"
+
+const maxStanzaLineWidth = 60
+
+var spaceRunRe = regexp.MustCompile(`\s+`)
+
+var verseClasses = map[string]bool{"poem": true, "poetry": true, "stanza": true, "verse": true, "lg": true}
+
+func hasVerseClass(n *xhtml.Node) bool {
+	class, _ := attr(n, "class")
+	for _, c := range strings.Fields(class) {
+		if verseClasses[strings.ToLower(c)] {
+			return true
+		}
+	}
+	return false
+}
+
+// isStanza spots verse set as a paragraph of short <br>-separated lines.
+func isStanza(n *xhtml.Node) bool {
+	if n.Data != "p" {
+		return false
+	}
+	breaks := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == xhtml.ElementNode && c.Data == "br" {
+			breaks++
+		}
+	}
+	if breaks < 2 {
+		return false
+	}
+	for _, l := range verseLines(n, false) {
+		if visibleWidth(l) > maxStanzaLineWidth {
+			return false
+		}
+	}
+	return true
+}
+
+func isVerseLine(line string) bool {
+	return strings.HasPrefix(line, verseMark)
+}
+
+// wrapVerse keeps the lines of a verse paragraph as they are, cutting the
+// ones wider than the page with an ellipsis.
+func wrapVerse(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		if isVerseLine(l) {
+			lines[i] = truncateVisible(strings.TrimPrefix(l, verseMark), width)
+		} else {
+			lines[i] = wrapParagraph(l, width)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func truncateVisible(line string, width int) string {
+	if visibleWidth(line) <= width {
+		return line
+	}
+	var b strings.Builder
+	used := 0
+	for i := 0; i < len(line); {
+		if code := styleCodeRe.FindString(line[i:]); code != "" && strings.HasPrefix(line[i:], code) {
+			b.WriteString(code)
+			i += len(code)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		w := runewidth.RuneWidth(r)
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+		i += size
+	}
+	return b.String() + "…"
+}
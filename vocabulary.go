@@ -0,0 +1,303 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const vocabularyFileName = "vocabulary.json"
+
+// reviewIntervals are the days until a word is reviewed again after it was
+// known, by the box it moves up to: a Leitner system, where a word forgotten
+// goes back to the first box.
+var reviewIntervals = []int{1, 3, 7, 16, 35, 80}
+
+// VocabWord is a word looked up in the reader, with the sentence it was
+// read in, and where it stands in the reviews.
+type VocabWord struct {
+	Word     string `json:"word"`
+	Gloss    string `json:"gloss,omitempty"`
+	Sentence string `json:"sentence,omitempty"`
+	Book     string `json:"book,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Language string `json:"language,omitempty"`
+	Lookups  int    `json:"lookups"`
+	Added    string `json:"added"`
+	Box      int    `json:"box"`
+	Due      string `json:"due"`
+}
+
+// vocabularyReview is the review of the words due, one card at a time.
+type vocabularyReview struct {
+	words    []VocabWord
+	queue    []int
+	revealed bool
+	back     mode
+}
+
+type vocabularySavedMsg struct {
+	err error
+}
+
+func loadVocabulary(store stateStore) ([]VocabWord, error) {
+	var words []VocabWord
+	err := loadJSONFile(store, vocabularyFileName, &words)
+	return words, err
+}
+
+// changeWordCmd changes the saved vocabulary: change gets the index of w in
+// it, or -1, and returns the words to save.
+func changeWordCmd(store stateStore, w VocabWord, change func(words []VocabWord, index int) []VocabWord) tea.Cmd {
+	return func() tea.Msg {
+		var words []VocabWord
+		err := updateJSONFile(store, vocabularyFileName, &words, func() error {
+			index := slices.IndexFunc(words, func(saved VocabWord) bool {
+				return strings.EqualFold(saved.Word, w.Word) && saved.Language == w.Language
+			})
+			words = change(words, index)
+			return nil
+		})
+		return vocabularySavedMsg{err: err}
+	}
+}
+
+// addWordCmd records a lookup. A word looked up again counts the lookup
+// and, if it was being learned, goes back to the first box.
+func addWordCmd(store stateStore, w VocabWord) tea.Cmd {
+	return changeWordCmd(store, w, func(words []VocabWord, index int) []VocabWord {
+		if index < 0 {
+			return append(words, w)
+		}
+		words[index].Lookups++
+		words[index].Box, words[index].Due = 0, w.Due
+		return words
+	})
+}
+
+// sentenceAt is the sentence of a paragraph the word at index is in.
+func sentenceAt(para string, index int) string {
+	words := strings.Fields(para)
+	if index < 0 || index >= len(words) {
+		return ""
+	}
+	start := index
+	for start > 0 && !sentenceEndRe.MatchString(words[start-1]) {
+		start--
+	}
+	end := index
+	for end < len(words)-1 && !sentenceEndRe.MatchString(words[end]) {
+		end++
+	}
+	return strings.Join(words[start:end+1], " ")
+}
+
+// lookUpWord looks up the word picked in the glossed paragraph, shows its
+// meaning and records it in the vocabulary.
+func (m *model) lookUpWord() tea.Cmd {
+	paras := pageParagraphs(m.currentBook.Page(m.state.Page))
+	if !m.glossing || m.dict == nil || len(paras) == 0 {
+		return nil
+	}
+	para := paras[min(m.glossIndex, len(paras)-1)]
+	words := strings.Fields(para)
+	if len(words) == 0 {
+		return nil
+	}
+	index := min(m.glossWord, len(words)-1)
+	word := trimWord(words[index])
+	if word == "" {
+		return nil
+	}
+	gloss := m.dict.lookup(word)
+	if gloss == "" {
+		m.status = trf("%s is not in the dictionary", word)
+	} else {
+		m.status = word + ": " + gloss
+	}
+	today := time.Now().Format(scheduleDateLayout)
+	return addWordCmd(m.store, VocabWord{
+		Word:     strings.ToLower(word),
+		Gloss:    gloss,
+		Sentence: sentenceAt(para, index),
+		Book:     m.state.CurrentBook,
+		Title:    displayTitle(m.currentBook.Title),
+		Language: m.glossLanguage(),
+		Lookups:  1,
+		Added:    today,
+		Due:      today,
+	})
+}
+
+func (m model) updateVocabularySaved(msg vocabularySavedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = msg.err.Error()
+	}
+	return m, nil
+}
+
+// openVocabulary starts a review of the words due today.
+func (m *model) openVocabulary() {
+	words, err := loadVocabulary(m.store)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	today := time.Now().Format(scheduleDateLayout)
+	review := vocabularyReview{words: words, back: m.mode}
+	if m.mode == modeVocabulary {
+		review.back = m.vocabulary.back
+	}
+	for i, w := range words {
+		if w.Due <= today {
+			review.queue = append(review.queue, i)
+		}
+	}
+	m.vocabulary = review
+	m.mode = modeVocabulary
+}
+
+// answer moves the word under review up a box when it was known, or back to
+// the first when it was not, which brings it up again in this review.
+func (m *model) answer(known bool) tea.Cmd {
+	r := &m.vocabulary
+	if len(r.queue) == 0 {
+		return nil
+	}
+	index := r.queue[0]
+	r.queue = r.queue[1:]
+	r.revealed = false
+	w := &r.words[index]
+	now := time.Now()
+	if known {
+		w.Box = min(w.Box+1, len(reviewIntervals))
+		w.Due = now.AddDate(0, 0, reviewIntervals[w.Box-1]).Format(scheduleDateLayout)
+	} else {
+		w.Box = 0
+		w.Due = now.Format(scheduleDateLayout)
+		r.queue = append(r.queue, index)
+	}
+	reviewed := *w
+	return changeWordCmd(m.store, reviewed, func(words []VocabWord, index int) []VocabWord {
+		if index >= 0 {
+			words[index].Box, words[index].Due = reviewed.Box, reviewed.Due
+		}
+		return words
+	})
+}
+
+// deleteWord drops the word under review from the vocabulary.
+func (m *model) deleteWord() tea.Cmd {
+	r := &m.vocabulary
+	if len(r.queue) == 0 {
+		return nil
+	}
+	index := r.queue[0]
+	deleted := r.words[index]
+	r.words = append(r.words[:index], r.words[index+1:]...)
+	r.queue = r.queue[1:]
+	for i := range r.queue {
+		if r.queue[i] > index {
+			r.queue[i]--
+		}
+	}
+	r.revealed = false
+	return changeWordCmd(m.store, deleted, func(words []VocabWord, index int) []VocabWord {
+		if index >= 0 {
+			return slices.Delete(words, index, index+1)
+		}
+		return words
+	})
+}
+
+func (m model) updateVocabulary(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch m.keys.lookup(modeVocabulary, key.String()) {
+	case actionReveal:
+		if len(m.vocabulary.queue) > 0 {
+			m.vocabulary.revealed = !m.vocabulary.revealed
+		}
+	case actionKnown:
+		if m.vocabulary.revealed {
+			return m, m.answer(true)
+		}
+	case actionForgot:
+		if m.vocabulary.revealed {
+			return m, m.answer(false)
+		}
+	case actionDelete:
+		return m, m.deleteWord()
+	case actionBack:
+		m.mode = m.vocabulary.back
+	case actionQuit:
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// highlightWord marks the word in the sentence it was looked up in.
+func highlightWord(sentence, word string) string {
+	words := strings.Fields(sentence)
+	for i, w := range words {
+		if strings.EqualFold(trimWord(w), word) {
+			words[i] = lipgloss.NewStyle().Bold(true).Underline(true).Render(w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func (m model) vocabularyView() string {
+	r := m.vocabulary
+	lines := []string{titleStyle().Render(trf("Vocabulary · %d due", len(r.queue))), ""}
+	width := max(min(m.width-4, 80), 20)
+	if len(r.queue) == 0 {
+		switch {
+		case len(r.words) == 0:
+			keys := func(act action) string { return m.keys.keysFor(modeReader, act) }
+			lines = append(lines, trf("No words yet: in the reader, %s shows glosses, %s/%s pick a word and %s looks it up.", keys(actionGloss), keys(actionPrevWord), keys(actionNextWord), keys(actionLookUp)))
+		default:
+			next := ""
+			for _, w := range r.words {
+				if next == "" || w.Due < next {
+					next = w.Due
+				}
+			}
+			lines = append(lines, trf("Nothing to review: %d words collected, the next due on %s.", len(r.words), next))
+		}
+	} else {
+		w := r.words[r.queue[0]]
+		lines = append(lines, titleStyle().Render(w.Word), "")
+		if w.Sentence != "" {
+			lines = append(lines, wrapParagraph("“"+highlightWord(w.Sentence, w.Word)+"”", width))
+		}
+		if w.Title != "" {
+			lines = append(lines, metaStyle().Render("— "+w.Title))
+		}
+		lines = append(lines, "")
+		if r.revealed {
+			gloss := w.Gloss
+			if gloss == "" {
+				gloss = tr("(not in the dictionary)")
+			}
+			lines = append(lines, wrapParagraph(gloss, width))
+		} else {
+			lines = append(lines, metaStyle().Render(trf("%s: show the meaning", m.keys.keysFor(modeVocabulary, actionReveal))))
+		}
+	}
+	if status := m.statusText(); status != "" {
+		lines = append(lines, "", metaStyle().Render(status))
+	}
+	hint := []action{actionBack, actionHelp, actionQuit}
+	if len(r.queue) > 0 {
+		hint = append([]action{actionReveal, actionKnown, actionForgot, actionDelete}, hint...)
+	}
+	lines = append(lines, "", helpLine(m.keys.hint(modeVocabulary, hint...)))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const libraryWatchInterval = 2 * time.Second
+
+type libraryWatchMsg struct {
+	dir       string
+	signature uint64
+}
+
+// librarySignature sums the names, sizes and modification times of what is
+// in the books dir, so a file copied in, removed or rewritten by another
+// program changes it.
+func librarySignature(dir string) uint64 {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	h := fnv.New64a()
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return h.Sum64()
+}
+
+// libraryWatchCmd looks at the books dir every couple of seconds. Polling
+// keeps it to the standard library and works the same on network mounts,
+// where file change notifications often don't arrive.
+func libraryWatchCmd(dir string) tea.Cmd {
+	return tea.Tick(libraryWatchInterval, func(time.Time) tea.Msg {
+		return libraryWatchMsg{dir: dir, signature: librarySignature(dir)}
+	})
+}
+
+// updateLibraryWatch rebuilds the library list when the books dir changed
+// since the last look. The first look only takes the signature.
+func (m model) updateLibraryWatch(msg libraryWatchMsg) (tea.Model, tea.Cmd) {
+	next := libraryWatchCmd(m.config.BooksDir)
+	if msg.dir != m.config.BooksDir {
+		m.librarySignature = 0
+		return m, next
+	}
+	changed := m.librarySignature != 0 && msg.signature != m.librarySignature
+	m.librarySignature = msg.signature
+	if !changed {
+		return m, next
+	}
+	items, err := loadLibraryItems(m.config.BooksDir, m.state, m.config.WPM)
+	if err != nil {
+		debugLog.Warn("library rescan failed", "dir", m.config.BooksDir, "err", err)
+		return m, next
+	}
+	debugLog.Info("library changed on disk", "dir", m.config.BooksDir, "books", len(items))
+	return m, tea.Batch(
+		next,
+		m.libraryList.SetItems(items),
+		fetchCoversCmd(m.config.CacheDir, coverIDs(items, m.covers)),
+	)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type webPageMsg struct {
+	url    string
+	copied bool
+}
+
+// bookPageURL is the web page of a library book: the page it was
+// downloaded from, or its Gutenberg page for entries that only kept the
+// number. Imported files have none.
+func bookPageURL(path string) string {
+	lib, err := loadLibrary(filepath.Dir(path))
+	if err != nil {
+		return ""
+	}
+	entry := lib.Books[filepath.Base(path)]
+	if strings.HasPrefix(entry.Source, "https://") || strings.HasPrefix(entry.Source, "http://") {
+		return entry.Source
+	}
+	if entry.ID != "" {
+		return fmt.Sprintf("https://www.gutenberg.org/ebooks/%s", entry.ID)
+	}
+	return ""
+}
+
+// openWebPageCmd opens url in the browser. Over SSH the browser would open
+// on the remote machine, so the address is copied to the clipboard instead,
+// as it is when no browser can be started.
+func openWebPageCmd(url string, remote bool) tea.Cmd {
+	return func() tea.Msg {
+		if !remote {
+			cmd := browserCommand(url)
+			err := cmd.Start()
+			if err == nil {
+				go cmd.Wait()
+				return webPageMsg{url: url}
+			}
+			debugLog.Warn("could not start the browser", "url", url, "err", err)
+		}
+		copyToClipboard(url)()
+		return webPageMsg{url: url, copied: true}
+	}
+}
+
+// webPageCommands are the palette commands for the web page of the book a
+// palette command acts on.
+func (m model) webPageCommands() []paletteCommand {
+	path, title, ok := m.paletteBook()
+	if !ok {
+		return nil
+	}
+	url := bookPageURL(path)
+	if url == "" {
+		return nil
+	}
+	return []paletteCommand{
+		{
+			label: trf("open the web page of %s", title),
+			run: func(m model) (tea.Model, tea.Cmd) {
+				return m, openWebPageCmd(url, m.remoteHost != "")
+			},
+		},
+		{
+			label: trf("copy the web address of %s", title),
+			run: func(m model) (tea.Model, tea.Cmd) {
+				return m, func() tea.Msg {
+					copyToClipboard(url)()
+					return webPageMsg{url: url, copied: true}
+				}
+			},
+		},
+	}
+}
+
+// openWebPage opens the web page of the book a palette command would act
+// on: the library selection, or the open book.
+func (m *model) openWebPage() tea.Cmd {
+	path, title, ok := m.paletteBook()
+	if !ok {
+		return nil
+	}
+	url := bookPageURL(path)
+	if url == "" {
+		m.status = trf("%s has no web page: it was not downloaded by gutberg", title)
+		return m.libraryList.NewStatusMessage(m.status)
+	}
+	return openWebPageCmd(url, m.remoteHost != "")
+}
+
+func (m model) updateWebPage(msg webPageMsg) (tea.Model, tea.Cmd) {
+	m.status = trf("Opened %s", msg.url)
+	if msg.copied {
+		m.status = trf("Copied %s to the clipboard", msg.url)
+	}
+	return m, m.libraryList.NewStatusMessage(m.status)
+}
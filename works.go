@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const maxWorksPerAuthor = 3
+
+type authorsMsg struct {
+	work  string
+	items []list.Item
+	err   error
+}
+
+func searchAuthorsByWork(ctx context.Context, work string) ([]list.Item, error) {
+	var data struct {
+		Results []gutendexBook `json:"results"`
+	}
+	if err := fetchGutendex(ctx, "https://gutendex.com/books/?search="+url.QueryEscape(work), &data); err != nil {
+		return nil, err
+	}
+
+	folded := foldString(work)
+	var items []list.Item
+	index := make(map[string]int)
+	for _, book := range data.Results {
+		if !strings.Contains(foldString(book.Title), folded) || !allowedBook(book) {
+			continue
+		}
+		for _, author := range book.Authors {
+			i, ok := index[author.Name]
+			if !ok {
+				i = len(items)
+				index[author.Name] = i
+				items = append(items, authorItem{name: author.Name})
+			}
+			item := items[i].(authorItem)
+			if len(item.works) < maxWorksPerAuthor {
+				item.works = append(item.works, book.Title)
+				items[i] = item
+			}
+		}
+	}
+	return items, nil
+}
+
+func fetchAuthorsByWorkCmd(work string) loadCmd {
+	return func(ctx context.Context) tea.Msg {
+		items, err := searchAuthorsByWork(ctx, work)
+		if err != nil {
+			return authorsMsg{work: work, err: err}
+		}
+		if len(items) == 0 {
+			return authorsMsg{work: work, err: fmt.Errorf(tr("no authors found for %q"), work)}
+		}
+		return authorsMsg{work: work, items: items}
+	}
+}